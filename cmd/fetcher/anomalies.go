@@ -0,0 +1,83 @@
+package main
+
+import (
+	"log/slog"
+	"sort"
+)
+
+// bulkAnomalies are data errors in the cached cards that would otherwise be silently merged away
+type bulkAnomalies struct {
+	DuplicateIDs   []string            // printing ids listed more than once
+	ConflictNames  map[string][]string // oracle_id -> distinct names of its printings, for those with several
+	EmptyOracleIDs []string            // printing ids without an oracle_id, e.g. reversible cards
+}
+
+// findBulkAnomalies checks the cards for duplicate printings, oracle_ids with several names and
+// printings without an oracle_id
+func findBulkAnomalies(cards []Card) bulkAnomalies {
+	var anomalies bulkAnomalies
+	seenIDs := make(map[string]int)
+	names := make(map[string]map[string]bool)
+	for _, card := range cards {
+		seenIDs[card.ID]++
+		if seenIDs[card.ID] == 2 {
+			anomalies.DuplicateIDs = append(anomalies.DuplicateIDs, card.ID)
+		}
+
+		if card.OracleID == "" {
+			anomalies.EmptyOracleIDs = append(anomalies.EmptyOracleIDs, card.ID)
+			continue
+		}
+		if names[card.OracleID] == nil {
+			names[card.OracleID] = make(map[string]bool)
+		}
+		names[card.OracleID][card.Name] = true
+	}
+
+	for oracleID, oracleNames := range names {
+		if len(oracleNames) < 2 {
+			continue
+		}
+		if anomalies.ConflictNames == nil {
+			anomalies.ConflictNames = make(map[string][]string)
+		}
+		list := make([]string, 0, len(oracleNames))
+		for name := range oracleNames {
+			list = append(list, name)
+		}
+		sort.Strings(list)
+		anomalies.ConflictNames[oracleID] = list
+	}
+
+	sort.Strings(anomalies.DuplicateIDs)
+	sort.Strings(anomalies.EmptyOracleIDs)
+	return anomalies
+}
+
+// report logs a warning with the counts when there are anomalies, and each of them at debug level
+func (anomalies bulkAnomalies) report() {
+	if len(anomalies.DuplicateIDs) == 0 && len(anomalies.ConflictNames) == 0 && len(anomalies.EmptyOracleIDs) == 0 {
+		return
+	}
+
+	slog.Warn("Bulk data has anomalies", "duplicate_ids", len(anomalies.DuplicateIDs),
+		"conflicting_names", len(anomalies.ConflictNames), "empty_oracle_ids", len(anomalies.EmptyOracleIDs))
+	for _, cardID := range anomalies.DuplicateIDs {
+		slog.Debug("Printing listed more than once", "card_id", cardID)
+	}
+	for _, oracleID := range sortedOracleIDs(anomalies.ConflictNames) {
+		slog.Debug("Oracle id has printings with different names", "oracle_id", oracleID, "names", anomalies.ConflictNames[oracleID])
+	}
+	for _, cardID := range anomalies.EmptyOracleIDs {
+		slog.Debug("Printing without oracle id is not tracked", "card_id", cardID)
+	}
+}
+
+func sortedOracleIDs(conflicts map[string][]string) []string {
+	oracleIDs := make([]string, 0, len(conflicts))
+	for oracleID := range conflicts {
+		oracleIDs = append(oracleIDs, oracleID)
+	}
+	sort.Strings(oracleIDs)
+	return oracleIDs
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindBulkAnomalies(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "anomalies.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var cards []Card
+	if _, err := streamCards(file, func(card Card) { cards = append(cards, card) }); err != nil {
+		t.Fatal(err)
+	}
+
+	anomalies := findBulkAnomalies(cards)
+	// A printing listed three times is one duplicate
+	if want := []string{"0002", "0003"}; !reflect.DeepEqual(anomalies.DuplicateIDs, want) {
+		t.Errorf("duplicate ids = %v, want %v", anomalies.DuplicateIDs, want)
+	}
+	// Printings of one oracle_id with the same name are no conflict
+	wantConflicts := map[string][]string{"o-giant": {"Bonecrusher Giant", "Bonecrusher Giant // Stomp"}}
+	if !reflect.DeepEqual(anomalies.ConflictNames, wantConflicts) {
+		t.Errorf("conflicting names = %v, want %v", anomalies.ConflictNames, wantConflicts)
+	}
+	// Both a missing and an empty oracle_id
+	if want := []string{"0009", "0010"}; !reflect.DeepEqual(anomalies.EmptyOracleIDs, want) {
+		t.Errorf("empty oracle ids = %v, want %v", anomalies.EmptyOracleIDs, want)
+	}
+}
+
+func TestFindBulkAnomaliesNone(t *testing.T) {
+	anomalies := findBulkAnomalies([]Card{testCard("a"), testCard("b")})
+	if !reflect.DeepEqual(anomalies, bulkAnomalies{}) {
+		t.Errorf("findBulkAnomalies() = %+v for clean cards, want none", anomalies)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sort"
+)
+
+// GamesState is the last-known platform availability of every tracked oracle_id
+type GamesState struct {
+	Games map[string][]string `json:"games"`
+}
+
+// buildOracleGames collects the union of games across all printings of each oracle_id
+func buildOracleGames(cards []Card) map[string][]string {
+	sets := make(map[string]map[string]bool)
+	for _, card := range cards {
+		if card.OracleID == "" {
+			continue
+		}
+		if sets[card.OracleID] == nil {
+			sets[card.OracleID] = make(map[string]bool)
+		}
+		for _, game := range card.Games {
+			sets[card.OracleID][game] = true
+		}
+	}
+
+	oracleGames := make(map[string][]string, len(sets))
+	for oracleID, games := range sets {
+		list := make([]string, 0, len(games))
+		for game := range games {
+			list = append(list, game)
+		}
+		sort.Strings(list)
+		oracleGames[oracleID] = list
+	}
+	return oracleGames
+}
+
+// findNowOnArena returns known oracle_ids that were tracked without Arena last time and have it now.
+// Oracles missing from the previous state are new cards, which are reported as additions instead.
+func findNowOnArena(previous, current map[string][]string, knownOracles map[string]bool) []string {
+	var nowOnArena []string
+	for oracleID, games := range current {
+		previousGames, tracked := previous[oracleID]
+		if !tracked || !knownOracles[oracleID] {
+			continue
+		}
+		if hasArenaInFetcher(games) && !hasArenaInFetcher(previousGames) {
+			nowOnArena = append(nowOnArena, oracleID)
+		}
+	}
+	sort.Strings(nowOnArena)
+	return nowOnArena
+}
+
+// UnmarshalJSON also reads arena_added under now_on_arena, its name in histories written before it
+// was renamed; saving the history moves it over
+func (day *DayResult) UnmarshalJSON(data []byte) error {
+	// Without its methods the type decodes as a plain struct instead of recursing
+	type plain DayResult
+	var decoded struct {
+		plain
+		NowOnArena []string `json:"now_on_arena"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*day = DayResult(decoded.plain)
+	if len(day.ArenaAdded) == 0 {
+		day.ArenaAdded = decoded.NowOnArena
+	}
+	return nil
+}
+
+// loadGamesState reads the previous games state; found is false when there is no usable state
+func loadGamesState(filename string) (map[string][]string, bool) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, false
+	}
+
+	var state GamesState
+	if err := json.Unmarshal(data, &state); err != nil {
+		slog.Warn("Ignoring unreadable games state", "file", filename, "err", err)
+		return nil, false
+	}
+	return state.Games, state.Games != nil
+}
+
+func saveGamesState(oracleGames map[string][]string, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// Indented and key-sorted so the committed file produces small git diffs
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(GamesState{Games: oracleGames})
+}
+
+// mergeOracleIDs returns the sorted union of both lists
+func mergeOracleIDs(a, b []string) []string {
+	seen := make(map[string]bool)
+	var merged []string
+	for _, list := range [][]string{a, b} {
+		for _, oracleID := range list {
+			if !seen[oracleID] {
+				seen[oracleID] = true
+				merged = append(merged, oracleID)
+			}
+		}
+	}
+	sort.Strings(merged)
+	return merged
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindNowOnArena(t *testing.T) {
+	previous := map[string][]string{
+		"paper-only": {"paper"},
+		"both":       {"arena", "paper"},
+		"unknown":    {"paper"},
+	}
+	current := map[string][]string{
+		"paper-only": {"arena", "paper"},
+		"both":       {"arena", "paper"},
+		"unknown":    {"arena", "paper"},
+		"new":        {"arena"},
+	}
+	known := map[string]bool{"paper-only": true, "both": true, "new": true}
+
+	got := findNowOnArena(previous, current, known)
+	if want := []string{"paper-only"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("findNowOnArena() = %v, want %v", got, want)
+	}
+}
+
+func TestDayResultArenaAdded(t *testing.T) {
+	for _, input := range []string{
+		`{"date":"2024-05-01","arena_added":["a","b"]}`,
+		`{"date":"2024-05-01","now_on_arena":["a","b"]}`,
+	} {
+		var day DayResult
+		if err := json.Unmarshal([]byte(input), &day); err != nil {
+			t.Fatalf("decoding %s: %v", input, err)
+		}
+		if want := []string{"a", "b"}; day.Date != "2024-05-01" || !reflect.DeepEqual(day.ArenaAdded, want) {
+			t.Errorf("decoding %s = %+v, want arena_added %v", input, day, want)
+		}
+
+		saved, err := json.Marshal(day)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(saved), `"arena_added":["a","b"]`) || strings.Contains(string(saved), "now_on_arena") {
+			t.Errorf("saving %s = %s, want arena_added only", input, saved)
+		}
+	}
+}
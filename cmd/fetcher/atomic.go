@@ -0,0 +1,30 @@
+package main
+
+import (
+	"io"
+	"os"
+)
+
+// writeFileAtomic writes a file through a temporary file in the same directory that is synced and
+// renamed over the original, so a failed or interrupted write leaves the previous content intact
+func writeFileAtomic(filename string, write func(io.Writer) error) error {
+	tmpFile := filename + ".tmp"
+	file, err := os.Create(tmpFile)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile)
+	defer file.Close()
+
+	if err := write(file); err != nil {
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile, filename)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomicKeepsOriginalOnEncodeFailure(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "history.json")
+	original := []byte(`{"days":[{"date":"2024-01-01","added_oracles":["a"],"total_cards":1,"first_run":true}]}` + "\n")
+	if err := os.WriteFile(filename, original, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := writeFileAtomic(filename, func(w io.Writer) error {
+		// Part of the content is written before the encoder fails, as when the disk fills up
+		if _, err := io.WriteString(w, `{"days":[`); err != nil {
+			return err
+		}
+		return json.NewEncoder(w).Encode(map[string]interface{}{"days": make(chan int)})
+	})
+	if err == nil {
+		t.Fatal("writeFileAtomic() succeeded with a failing encoder")
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != string(original) {
+		t.Errorf("original changed to %q", content)
+	}
+	if _, err := os.Stat(filename + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+}
+
+func TestWriteFileAtomicReplacesFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "history.json")
+	if err := os.WriteFile(filename, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	history := HistoryData{Days: []DayResult{{Date: "2024-01-01", AddedOracles: []string{"a"}, TotalCards: 1, FirstRun: true}}}
+	if err := saveHistory(history, filename); err != nil {
+		t.Fatalf("saveHistory() = %v", err)
+	}
+	loaded, err := loadHistory(filename)
+	if err != nil {
+		t.Fatalf("loadHistory() = %v", err)
+	}
+	if len(loaded.Days) != 1 || loaded.Days[0].Date != "2024-01-01" {
+		t.Errorf("saved history read back as %+v", loaded)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// parseRunDate validates the -date flag; empty means today (UTC)
+func parseRunDate(value string, now time.Time) (string, error) {
+	today := now.UTC().Format("2006-01-02")
+	if value == "" {
+		return today, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return "", fmt.Errorf("-date must be a date in YYYY-MM-DD format, e.g. 2025-01-31, got %q", value)
+	}
+	if formatted := date.Format("2006-01-02"); formatted > today {
+		return "", fmt.Errorf("-date %s is in the future (today is %s)", formatted, today)
+	}
+	return date.Format("2006-01-02"), nil
+}
+
+// latestEntryDate returns the date of the newest day in the history, empty when there are none
+func latestEntryDate(history HistoryData) string {
+	latest := ""
+	for _, day := range history.Days {
+		if day.Date > latest {
+			latest = day.Date
+		}
+	}
+	return latest
+}
+
+// historyUntil returns the days up to and including date, which is what a backfilled day is diffed against
+func historyUntil(history HistoryData, date string) HistoryData {
+	earlier := history
+	earlier.Days = nil
+	for _, day := range history.Days {
+		if day.Date <= date {
+			earlier.Days = append(earlier.Days, day)
+		}
+	}
+	return earlier
+}
+
+// unrecordAfter takes cards out of the days after date, because a backfilled day found them first.
+// It returns the number of cards taken out.
+func unrecordAfter(history HistoryData, date string, oracleIDs []string, oracleToCard map[string]Card) int {
+	moved := make(map[string]bool)
+	for _, oracleID := range oracleIDs {
+		moved[oracleID] = true
+	}
+
+	count := 0
+	for i := range history.Days {
+		day := &history.Days[i]
+		if day.Date <= date {
+			continue
+		}
+
+		var kept []string
+		for _, oracleID := range day.AddedOracles {
+			if !moved[oracleID] {
+				kept = append(kept, oracleID)
+				continue
+			}
+			count++
+			if card, found := oracleToCard[oracleID]; found {
+				day.Sets = uncountSet(day.Sets, card.Set)
+				if day.Stats != nil {
+					day.Stats.add(card, -1)
+				}
+			}
+			delete(day.CardMapping, oracleID)
+			delete(day.Tags, oracleID)
+		}
+		day.AddedOracles = kept
+
+		var hits []string
+		for _, oracleID := range day.WatchlistHits {
+			if !moved[oracleID] {
+				hits = append(hits, oracleID)
+			}
+		}
+		day.WatchlistHits = hits
+	}
+	return count
+}
+
+// sortDays orders the history by date, keeping backfilled days in place
+func sortDays(history HistoryData) {
+	sort.SliceStable(history.Days, func(i, j int) bool {
+		return history.Days[i].Date < history.Days[j].Date
+	})
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// backupTimeLayout is the timestamp in backup names, e.g. history-20250101-120000.json
+const backupTimeLayout = "20060102-150405"
+
+// backupHistory copies the history to backupDir before it is modified and prunes the oldest
+// backups of the same history beyond keep. Nothing is done when keep is 0 or there is no history yet.
+// A sharded history is backed up as one monolithic file, which -restore accepts either way.
+func backupHistory(historyFile string, backupDir string, keep int, now time.Time) error {
+	if keep <= 0 {
+		return nil
+	}
+
+	data, err := readHistoryBackup(historyFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return err
+	}
+
+	name := strings.TrimSuffix(filepath.Base(historyFile), ".json")
+	backupFile := filepath.Join(backupDir, name+"-"+now.UTC().Format(backupTimeLayout)+".json")
+	if err := writeFileAtomic(backupFile, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	return pruneBackups(backupDir, name, keep)
+}
+
+// readHistoryBackup returns the bytes to back up, the file itself or a sharded history joined into one
+func readHistoryBackup(historyFile string) ([]byte, error) {
+	if !isSharded(historyFile) {
+		return os.ReadFile(historyFile)
+	}
+	history, err := loadShardedHistory(shardDir(historyFile))
+	if err != nil {
+		return nil, err
+	}
+	var encoded bytes.Buffer
+	if err := encodeHistory(&encoded, history); err != nil {
+		return nil, err
+	}
+	return encoded.Bytes(), nil
+}
+
+// pruneBackups removes all but the newest keep backups of one history. The exact name pattern
+// keeps history.json from pruning the backups of history-<format>.json.
+func pruneBackups(backupDir string, name string, keep int) error {
+	entries, err := os.ReadDir(backupDir)
+	if err != nil {
+		return err
+	}
+
+	pattern := regexp.MustCompile(`^` + regexp.QuoteMeta(name) + `-\d{8}-\d{6}\.json$`)
+	var backups []string
+	for _, entry := range entries {
+		if !entry.IsDir() && pattern.MatchString(entry.Name()) {
+			backups = append(backups, entry.Name())
+		}
+	}
+
+	// Timestamps sort chronologically
+	sort.Strings(backups)
+	for len(backups) > keep {
+		if err := os.Remove(filepath.Join(backupDir, backups[0])); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// restoreHistory validates a backup and swaps it in for the history of the format it records.
+// The history being replaced is backed up first, so a restore can be undone.
+func restoreHistory(backupFile string, dataDir string, brawlHistory string, backupDir string, keep int) error {
+	data, err := os.ReadFile(backupFile)
+	if err != nil {
+		return err
+	}
+
+	var history HistoryData
+	if err := json.Unmarshal(data, &history); err != nil {
+		return fmt.Errorf("backup %s is not a valid history: %w", backupFile, describeJSONError(data, err))
+	}
+	if len(history.Days) == 0 {
+		return fmt.Errorf("backup %s has no days", backupFile)
+	}
+	if err := history.Validate(); err != nil {
+		return fmt.Errorf("backup %s: %w", backupFile, err)
+	}
+
+	// Backups from before meta.format was recorded are Brawl histories
+	format := history.Meta.Format
+	if format == "" {
+		format = "brawl"
+	}
+	if !formatNamePattern.MatchString(format) {
+		return fmt.Errorf("backup %s has invalid format %q", backupFile, format)
+	}
+	historyFile := newFormatFiles(dataDir, format, history.Meta.Games, brawlHistory).History
+
+	if err := backupHistory(historyFile, backupDir, keep, time.Now()); err != nil {
+		return fmt.Errorf("backing up current history: %w", err)
+	}
+	if isSharded(historyFile) {
+		if err := saveShardedHistory(history, shardDir(historyFile)); err != nil {
+			return err
+		}
+	} else if err := writeFileAtomic(historyFile, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	slog.Info("Restored history", "format", format, "history", historyFile, "backup", backupFile,
+		"days", len(history.Days), "last", history.Days[len(history.Days)-1].Date)
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+)
+
+// errInvalidBulkData is returned by loadBulkFile when the download is truncated or has far fewer cards than the last one
+var errInvalidBulkData = errors.New("invalid bulk data")
+
+// exitInvalidBulkData is the exit code of a run stopped by a download that failed validation
+const exitInvalidBulkData = 2
+
+// bodyPreviewLength is how much of an unexpected download is quoted in the error, enough to recognize an error page
+const bodyPreviewLength = 200
+
+// checkBulkBody makes sure a download looks like the JSON array of a bulk file before it is saved, and
+// not e.g. an HTML error page a misbehaving CDN served with status 200. The returned reader still
+// yields the whole body.
+func checkBulkBody(contentType string, body io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(body)
+	preview, err := buffered.Peek(bodyPreviewLength)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	start := bytes.TrimLeft(preview, " \t\r\n")
+	if mediaType == "text/html" || len(start) == 0 || start[0] != '[' {
+		if contentType == "" {
+			contentType = "no Content-Type"
+		}
+		return nil, fmt.Errorf("%w: expected a JSON array of cards, got %s starting with %q", errInvalidBulkData, contentType, preview)
+	}
+	return buffered, nil
+}
+
+// streamCards decodes a JSON array of cards one element at a time and hands each card to visit.
+// Memory stays proportional to what visit keeps rather than to the size of the input.
+func streamCards(r io.Reader, visit func(Card)) (int, error) {
+	decoder := json.NewDecoder(r)
+	if err := expectDelim(decoder, '['); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for decoder.More() {
+		var card Card
+		if err := decoder.Decode(&card); err != nil {
+			return count, fmt.Errorf("decoding card %d: %w", count+1, err)
+		}
+		visit(card)
+		count++
+	}
+
+	if err := expectDelim(decoder, ']'); err != nil {
+		return count, err
+	}
+	return count, nil
+}
+
+// expectDelim reads the next token and checks it is the given array delimiter
+func expectDelim(decoder *json.Decoder, want json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("expected %q in card list, got %v", want, token)
+	}
+	return nil
+}
+
+// checkBulkCount rejects a complete but suspiciously small download, e.g. a partial dump served by a broken mirror
+func checkBulkCount(count int, minCards int) error {
+	if count < minCards {
+		return fmt.Errorf("%w: only %d cards, expected at least %d", errInvalidBulkData, count, minCards)
+	}
+	return nil
+}
+
+// loadBulkFile streams a downloaded bulk file through visit, rejecting truncated or suspiciously small dumps
+func loadBulkFile(filename string, minCards int, visit func(Card)) (int, error) {
+	count, err := loadCards(filename, visit)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errInvalidBulkData, err)
+	}
+	return count, checkBulkCount(count, minCards)
+}
+
+// writeSlimBulkFile re-encodes a bulk file keeping only the fields of Card, which cover everything the
+// fetcher and the renderer read. Prices, rulings, purchase links and the like are dropped.
+func writeSlimBulkFile(source string, destination string) error {
+	return writeCompressedFile(destination, func(w io.Writer) error {
+		if _, err := io.WriteString(w, "["); err != nil {
+			return err
+		}
+
+		encoder := json.NewEncoder(w)
+		var encodeErr error
+		first := true
+		_, err := loadCards(source, func(card Card) {
+			if encodeErr != nil {
+				return
+			}
+			if !first {
+				if _, encodeErr = io.WriteString(w, ","); encodeErr != nil {
+					return
+				}
+			}
+			first = false
+			encodeErr = encoder.Encode(card)
+		})
+		if err == nil {
+			err = encodeErr
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = io.WriteString(w, "]\n")
+		return err
+	})
+}
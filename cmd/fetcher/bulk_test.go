@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const cdnErrorPage = `<!DOCTYPE html>
+<html><head><title>502 Bad Gateway</title></head>
+<body><h1>Bad Gateway</h1><p>The origin is unreachable.</p></body></html>`
+
+// downloadFrom runs downloadCards against a server answering with contentType and body
+func downloadFrom(t *testing.T, contentType string, body string) (string, error) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	client, err := newScryfallClient(server.URL, "brawl-chronicle-test", http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(t.TempDir(), "brawl-cards.json")
+	_, err = downloadCards(context.Background(), client, server.URL+"/default-cards.json", filename, CacheMeta{}, downloadOptions{Attempts: 1})
+	return filename, err
+}
+
+func TestDownloadCardsRejectsHTML(t *testing.T) {
+	for _, contentType := range []string{"text/html; charset=utf-8", "application/json"} {
+		filename, err := downloadFrom(t, contentType, cdnErrorPage)
+		if !errors.Is(err, errInvalidBulkData) {
+			t.Fatalf("%s: downloadCards() = %v, want %v", contentType, err, errInvalidBulkData)
+		}
+		if !strings.Contains(err.Error(), "<!DOCTYPE html>") || !strings.Contains(err.Error(), contentType) {
+			t.Errorf("%s: error %q doesn't quote the body and its type", contentType, err)
+		}
+		if _, err := os.Stat(filename + ".gz"); !os.IsNotExist(err) {
+			t.Errorf("%s: an error page was cached: %v", contentType, err)
+		}
+	}
+}
+
+func TestDownloadCardsKeepsCacheOnHTML(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(cdnErrorPage))
+	}))
+	defer server.Close()
+	client, err := newScryfallClient(server.URL, "brawl-chronicle-test", http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filename := filepath.Join(t.TempDir(), "brawl-cards.json")
+	cached := []byte(`[{"id":"1","oracle_id":"a","name":"Cached"}]`)
+	if err := writeCompressedFile(filename, func(w io.Writer) error {
+		_, err := w.Write(cached)
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := downloadCards(context.Background(), client, server.URL, filename, CacheMeta{}, downloadOptions{Attempts: 1}); !errors.Is(err, errInvalidBulkData) {
+		t.Fatalf("downloadCards() = %v, want %v", err, errInvalidBulkData)
+	}
+	var names []string
+	if _, err := loadCards(filename, func(card Card) { names = append(names, card.Name) }); err != nil {
+		t.Fatalf("cache unreadable after the failed download: %v", err)
+	}
+	if len(names) != 1 || names[0] != "Cached" {
+		t.Errorf("cache has %v after the failed download, want the cached card", names)
+	}
+}
+
+func TestDownloadCardsAcceptsJSON(t *testing.T) {
+	filename, err := downloadFrom(t, "application/json", "\n  "+`[{"id":"1","oracle_id":"a","name":"Card"}]`)
+	if err != nil {
+		t.Fatalf("downloadCards() = %v", err)
+	}
+	count, err := loadCards(filename, func(Card) {})
+	if err != nil || count != 1 {
+		t.Errorf("loadCards() = %d, %v; want 1 card", count, err)
+	}
+}
+
+// writeBulkFixture writes a gzipped bulk file of generated printings, with the fields of Scryfall's
+// cards the fetcher skips, and returns its name as loadCards takes it
+func writeBulkFixture(b *testing.B, count int) string {
+	b.Helper()
+	type bulkCard struct {
+		Card
+		Artist     string            `json:"artist"`
+		FlavorText string            `json:"flavor_text"`
+		Prices     map[string]string `json:"prices"`
+		Keywords   []string          `json:"keywords"`
+	}
+	cards := make([]bulkCard, count)
+	for i := range cards {
+		cards[i] = bulkCard{
+			Card: Card{
+				ID:              fmt.Sprintf("card-%06d", i),
+				OracleID:        fmt.Sprintf("oracle-%06d", i/3),
+				Name:            fmt.Sprintf("Card %d", i/3),
+				ManaCost:        "{2}{G}",
+				CMC:             3,
+				TypeLine:        "Creature — Elf Druid",
+				Colors:          []string{"G"},
+				Rarity:          "common",
+				Set:             fmt.Sprintf("s%02d", i%40),
+				SetName:         fmt.Sprintf("Set %d", i%40),
+				SetType:         "expansion",
+				Layout:          "normal",
+				ReleasedAt:      "2024-01-01",
+				CollectorNumber: fmt.Sprint(i),
+				Legalities:      map[string]string{"brawl": "legal", "standard": "legal", "commander": "legal", "vintage": "legal", "pauper": "not_legal"},
+				ImageURIs: map[string]string{
+					"small":    "https://cards.scryfall.io/small/front/" + fmt.Sprint(i) + ".jpg",
+					"normal":   "https://cards.scryfall.io/normal/front/" + fmt.Sprint(i) + ".jpg",
+					"large":    "https://cards.scryfall.io/large/front/" + fmt.Sprint(i) + ".jpg",
+					"art_crop": "https://cards.scryfall.io/art_crop/front/" + fmt.Sprint(i) + ".jpg",
+				},
+				Games:      []string{"paper", "arena", "mtgo"},
+				OracleText: "{T}: Add {G}.\nWhen this creature enters, you gain 2 life.",
+			},
+			Artist:     "Some Artist",
+			FlavorText: "The elves of the forest answer to no one.",
+			Prices:     map[string]string{"usd": "0.25", "eur": "0.20", "tix": "0.03"},
+			Keywords:   []string{"Reach"},
+		}
+	}
+
+	filename := filepath.Join(b.TempDir(), "default-cards.json")
+	if err := writeCompressedFile(filename, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(cards)
+	}); err != nil {
+		b.Fatal(err)
+	}
+	return filename
+}
+
+func BenchmarkLoadCards(b *testing.B) {
+	const count = 5000
+	filename := writeBulkFixture(b, count)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		loaded, err := loadCards(filename, func(Card) {})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if loaded != count {
+			b.Fatalf("loaded %d cards, want %d", loaded, count)
+		}
+	}
+}
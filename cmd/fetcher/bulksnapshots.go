@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// bulkSnapshotName names the snapshot of a bulk download after its dataset and updated_at,
+// e.g. default-cards-20250101-090000.json; the timestamp of an unparseable updated_at is the download time
+func bulkSnapshotName(bulkType string, updatedAt string, now time.Time) string {
+	stamp, err := time.Parse(time.RFC3339, updatedAt)
+	if err != nil {
+		stamp = now
+	}
+	return strings.ReplaceAll(bulkType, "_", "-") + "-" + stamp.UTC().Format(backupTimeLayout) + ".json"
+}
+
+// saveBulkSnapshot keeps a slim, compressed copy of a validated download in snapshotDir and prunes
+// the oldest snapshots of the same bulk type beyond keep. A snapshot of the same updated_at is replaced.
+func saveBulkSnapshot(downloadFile string, snapshotDir string, bulkType string, updatedAt string, keep int) (string, error) {
+	if err := os.MkdirAll(snapshotDir, 0755); err != nil {
+		return "", err
+	}
+	filename := filepath.Join(snapshotDir, bulkSnapshotName(bulkType, updatedAt, time.Now()))
+	if err := writeSlimBulkFile(downloadFile, filename); err != nil {
+		return "", err
+	}
+	return filename + ".gz", pruneBulkSnapshots(snapshotDir, bulkType, keep)
+}
+
+// pruneBulkSnapshots removes all but the newest keep snapshots of one bulk type
+func pruneBulkSnapshots(snapshotDir string, bulkType string, keep int) error {
+	entries, err := os.ReadDir(snapshotDir)
+	if err != nil {
+		return err
+	}
+
+	prefix := strings.ReplaceAll(bulkType, "_", "-")
+	pattern := regexp.MustCompile(`^` + regexp.QuoteMeta(prefix) + `-\d{8}-\d{6}\.json\.gz$`)
+	var snapshots []string
+	for _, entry := range entries {
+		if !entry.IsDir() && pattern.MatchString(entry.Name()) {
+			snapshots = append(snapshots, entry.Name())
+		}
+	}
+
+	// Timestamps sort chronologically
+	sort.Strings(snapshots)
+	for len(snapshots) > keep {
+		if err := os.Remove(filepath.Join(snapshotDir, snapshots[0])); err != nil {
+			return err
+		}
+		snapshots = snapshots[1:]
+	}
+	return nil
+}
+
+// snapshotLegality is what a bulk snapshot says about one oracle_id in a format
+type snapshotLegality struct {
+	Name   string
+	Status string
+}
+
+// loadSnapshotLegalities streams a bulk snapshot and collects the legality of every oracle_id in a
+// format, preferring an accepted status when printings disagree like buildOracleLegalities
+func loadSnapshotLegalities(filename string, format string, statuses []string) (map[string]snapshotLegality, error) {
+	legalities := make(map[string]snapshotLegality)
+	_, err := loadCards(filename, func(card Card) {
+		status := card.Legalities[format]
+		if card.OracleID == "" || status == "" {
+			return
+		}
+		existing, found := legalities[card.OracleID]
+		if !found || (acceptsStatus(statuses, status) && !acceptsStatus(statuses, existing.Status)) {
+			legalities[card.OracleID] = snapshotLegality{Name: card.Name, Status: status}
+		}
+	})
+	return legalities, err
+}
+
+// compareWithSnapshot explains the difference between a bulk snapshot and the current cards of a
+// format: every oracle_id legal on one side only is listed with its status in both, "missing" when
+// the side doesn't have the card at all. current only holds legal and recorded cards, like the cache.
+func compareWithSnapshot(w io.Writer, snapshotFile string, format string, statuses []string, current []Card) error {
+	before, err := loadSnapshotLegalities(snapshotFile, format, statuses)
+	if err != nil {
+		return fmt.Errorf("reading snapshot %s: %w", snapshotFile, err)
+	}
+	after := make(map[string]snapshotLegality)
+	for oracleID, status := range buildOracleLegalities(current, format, statuses) {
+		after[oracleID] = snapshotLegality{Status: status}
+	}
+	for _, card := range current {
+		if entry, found := after[card.OracleID]; found && entry.Name == "" {
+			entry.Name = card.Name
+			after[card.OracleID] = entry
+		}
+	}
+
+	legal := func(side map[string]snapshotLegality, oracleID string) bool {
+		entry, found := side[oracleID]
+		return found && acceptsStatus(statuses, entry.Status)
+	}
+	var added, removed []string
+	for oracleID := range after {
+		if legal(after, oracleID) && !legal(before, oracleID) {
+			added = append(added, oracleID)
+		}
+	}
+	for oracleID := range before {
+		if legal(before, oracleID) && !legal(after, oracleID) {
+			removed = append(removed, oracleID)
+		}
+	}
+
+	name := filepath.Base(snapshotFile)
+	fmt.Fprintf(w, "%s: %d cards %s now but not in %s\n", format, len(added), describeStatuses(statuses), name)
+	writeSnapshotDiff(w, added, before, after)
+	fmt.Fprintf(w, "%s: %d cards %s in %s but not now\n", format, len(removed), describeStatuses(statuses), name)
+	writeSnapshotDiff(w, removed, before, after)
+	return nil
+}
+
+// writeSnapshotDiff lists oracle_ids by name with their status before and after, followed by how
+// many cards made each transition, which tells a data hiccup from a real change at a glance
+func writeSnapshotDiff(w io.Writer, oracleIDs []string, before, after map[string]snapshotLegality) {
+	status := func(side map[string]snapshotLegality, oracleID string) string {
+		if entry, found := side[oracleID]; found {
+			return entry.Status
+		}
+		return "missing"
+	}
+	cardName := func(oracleID string) string {
+		if entry, found := after[oracleID]; found && entry.Name != "" {
+			return entry.Name
+		}
+		return before[oracleID].Name
+	}
+
+	sort.Slice(oracleIDs, func(i, j int) bool {
+		return cardName(oracleIDs[i]) < cardName(oracleIDs[j])
+	})
+	counts := make(map[string]int)
+	for _, oracleID := range oracleIDs {
+		transition := status(before, oracleID) + " -> " + status(after, oracleID)
+		counts[transition]++
+		fmt.Fprintf(w, "  %s (%s): %s\n", cardName(oracleID), oracleID, transition)
+	}
+
+	var transitions []string
+	for transition := range counts {
+		transitions = append(transitions, transition)
+	}
+	sort.Strings(transitions)
+	for _, transition := range transitions {
+		fmt.Fprintf(w, "  %d x %s\n", counts[transition], transition)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// errNotModified is returned by downloadCards when Scryfall reports the bulk file unchanged
+var errNotModified = errors.New("bulk data not modified")
+
+// defaultMaxCardDrop is the percentage of cards a new download may lose against the last one before it is refused
+const defaultMaxCardDrop = 20.0
+
+// CacheMeta describes the download the card cache was built from, making the next download conditional
+type CacheMeta struct {
+	BulkType      string   `json:"bulk_type,omitempty"`
+	Formats       []string `json:"formats,omitempty"`        // formats whose legal cards the cache holds
+	LegalStatuses []string `json:"legal_statuses,omitempty"` // statuses counted as legal, empty for only "legal"
+	DownloadURI   string   `json:"download_uri"`
+	UpdatedAt     string   `json:"updated_at,omitempty"` // updated_at of the bulk-data entry that was downloaded
+	ETag          string   `json:"etag,omitempty"`
+	LastModified  string   `json:"last_modified,omitempty"`
+	CardCount     int      `json:"card_count,omitempty"`    // cards in the downloaded bulk file, not just the cached ones
+	DownloadSize  int64    `json:"download_size,omitempty"` // bytes received for the bulk file, compressed when Scryfall sent gzip
+	CachedCount   int      `json:"cached_count,omitempty"`  // cards kept in the card cache
+	CacheSHA256   string   `json:"cache_sha256,omitempty"`  // checksum of the compressed card cache as written
+	CacheSize     int64    `json:"cache_size,omitempty"`    // length of the compressed card cache in bytes
+}
+
+// loadCacheMeta reads the cache metadata; a missing or unreadable file yields empty metadata
+func loadCacheMeta(filename string) CacheMeta {
+	var meta CacheMeta
+	if data, err := os.ReadFile(filename); err == nil {
+		json.Unmarshal(data, &meta)
+	}
+	return meta
+}
+
+// bulkType returns the dataset the cache was built from; caches from before the field existed are default_cards
+func (meta CacheMeta) bulkType() string {
+	if meta.BulkType == "" {
+		return bulkTypeDefaultCards
+	}
+	return meta.BulkType
+}
+
+// formats returns the formats the cache holds cards of; caches from before the field existed only had brawl
+func (meta CacheMeta) formats() []string {
+	if len(meta.Formats) == 0 {
+		return []string{"brawl"}
+	}
+	return meta.Formats
+}
+
+// coversFormats reports whether the cache has the cards of every wanted format
+func (meta CacheMeta) coversFormats(wanted []string) bool {
+	cached := make(map[string]bool)
+	for _, format := range meta.formats() {
+		cached[format] = true
+	}
+	for _, format := range wanted {
+		if !cached[format] {
+			return false
+		}
+	}
+	return true
+}
+
+// legalStatuses returns the statuses the cache holds cards with; caches from before the field existed only had legal cards
+func (meta CacheMeta) legalStatuses() []string {
+	if len(meta.LegalStatuses) == 0 {
+		return defaultLegalStatuses
+	}
+	return meta.LegalStatuses
+}
+
+// coversStatuses reports whether the cache has the cards of every wanted legality status
+func (meta CacheMeta) coversStatuses(wanted []string) bool {
+	for _, status := range wanted {
+		if !acceptsStatus(meta.legalStatuses(), status) {
+			return false
+		}
+	}
+	return true
+}
+
+// minCards returns the number of cards a new download of bulkType must have to be trusted,
+// losing at most maxDrop percent of the last download's cards
+func (meta CacheMeta) minCards(bulkType string, maxDrop float64) int {
+	if meta.bulkType() != bulkType || meta.CardCount == 0 {
+		// Nothing to compare with, but an empty dump is never right
+		return 1
+	}
+	return int(float64(meta.CardCount) * (1 - maxDrop/100))
+}
+
+// minCachedCards returns the number of cards a new download must leave in the cache to be trusted.
+// Only a cache of the same bulk type, formats and statuses is comparable; otherwise there is no minimum.
+func (meta CacheMeta) minCachedCards(bulkType string, formats []string, statuses []string, maxDrop float64) int {
+	if meta.bulkType() != bulkType || meta.CachedCount == 0 ||
+		len(meta.formats()) != len(formats) || !meta.coversFormats(formats) ||
+		!sameStatuses(meta.LegalStatuses, statuses) {
+		return 0
+	}
+	return int(float64(meta.CachedCount) * (1 - maxDrop/100))
+}
+
+// recordCache stores the card count, checksum and length of the card cache just written to filename
+func (meta *CacheMeta) recordCache(filename string, count int) error {
+	sum, size, err := fileChecksum(filename)
+	if err != nil {
+		return err
+	}
+	meta.CachedCount = count
+	meta.CacheSHA256 = sum
+	meta.CacheSize = size
+	return nil
+}
+
+// verifyCache checks the card cache against the checksum and length recorded when it was written.
+// Caches from before the fields existed can't be checked and are trusted.
+func (meta CacheMeta) verifyCache(filename string) error {
+	if meta.CacheSHA256 == "" {
+		return nil
+	}
+	sum, size, err := fileChecksum(filename)
+	if err != nil {
+		return err
+	}
+	if size != meta.CacheSize {
+		return fmt.Errorf("cache is %d bytes, expected %d", size, meta.CacheSize)
+	}
+	if sum != meta.CacheSHA256 {
+		return fmt.Errorf("cache checksum is %s, expected %s", sum, meta.CacheSHA256)
+	}
+	return nil
+}
+
+// fileChecksum returns the hex SHA-256 and the length of a file
+func fileChecksum(filename string) (string, int64, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+func saveCacheMeta(meta CacheMeta, filename string) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(data, '\n'), 0644)
+}
+
+// isNewerBulkData compares two bulk-data updated_at timestamps; unparseable values count as newer when they differ
+func isNewerBulkData(published string, cached string) bool {
+	publishedAt, err1 := time.Parse(time.RFC3339, published)
+	cachedAt, err2 := time.Parse(time.RFC3339, cached)
+	if err1 != nil || err2 != nil {
+		return published != cached
+	}
+	return publishedAt.After(cachedAt)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+)
+
+// Card files are stored gzip-compressed as <name>.gz. The plain <name> written by older runs is
+// still read when there is no compressed file, and removed once the compressed one is written.
+
+// cardFilePath returns the file to read for a card file, preferring the compressed form
+func cardFilePath(filename string) string {
+	if _, err := os.Stat(filename + ".gz"); err == nil {
+		return filename + ".gz"
+	}
+	return filename
+}
+
+// gzipFile closes both the decompressor and the file under it
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (f gzipFile) Close() error {
+	f.Reader.Close()
+	return f.file.Close()
+}
+
+// openCardFile opens a card file, decompressing the .gz form
+func openCardFile(filename string) (io.ReadCloser, error) {
+	path := cardFilePath(filename)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return file, nil
+	}
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return gzipFile{Reader: reader, file: file}, nil
+}
+
+// writeCompressedFile atomically writes <filename>.gz and removes the plain file of older runs
+func writeCompressedFile(filename string, write func(io.Writer) error) error {
+	err := writeFileAtomic(filename+".gz", func(w io.Writer) error {
+		gz := gzip.NewWriter(w)
+		if err := write(gz); err != nil {
+			return err
+		}
+		return gz.Close()
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import "sort"
+
+// SetCount is the number of a day's new cards whose chosen printing is from one set
+type SetCount struct {
+	Code  string `json:"code"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// summarizeSets counts added cards per set of their chosen printing, largest set first
+func summarizeSets(oracleIDs []string, oracleToCard map[string]Card) []SetCount {
+	counts := make(map[string]*SetCount)
+	for _, oracleID := range oracleIDs {
+		card, found := oracleToCard[oracleID]
+		if !found || card.Set == "" {
+			continue
+		}
+		if counts[card.Set] == nil {
+			counts[card.Set] = &SetCount{Code: card.Set, Name: card.SetName}
+		}
+		counts[card.Set].Count++
+	}
+
+	var sets []SetCount
+	for _, set := range counts {
+		sets = append(sets, *set)
+	}
+	sortSetCounts(sets)
+	return sets
+}
+
+// sortSetCounts orders sets by count, then by code so the history stays stable
+func sortSetCounts(sets []SetCount) {
+	sort.Slice(sets, func(i, j int) bool {
+		if sets[i].Count != sets[j].Count {
+			return sets[i].Count > sets[j].Count
+		}
+		return sets[i].Code < sets[j].Code
+	})
+}
+
+// uncountSet takes one card of a set out of a day's summary, dropping the set once empty
+func uncountSet(sets []SetCount, code string) []SetCount {
+	var kept []SetCount
+	for _, set := range sets {
+		if set.Code == code {
+			set.Count--
+		}
+		if set.Count > 0 {
+			kept = append(kept, set)
+		}
+	}
+	sortSetCounts(kept)
+	return kept
+}
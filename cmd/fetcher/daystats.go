@@ -0,0 +1,55 @@
+package main
+
+// colorBucket groups a card by color for day statistics: a single color letter (W, U, B, R, G),
+// "multicolor" or "colorless". Cards whose colors are only on their faces use the first face's.
+func colorBucket(card Card) string {
+	colors := card.Colors
+	if len(colors) == 0 && len(card.CardFaces) > 0 {
+		colors = card.CardFaces[0].Colors
+	}
+	switch len(colors) {
+	case 0:
+		return "colorless"
+	case 1:
+		return colors[0]
+	default:
+		return "multicolor"
+	}
+}
+
+// DayStats counts a day's new cards by color bucket and by rarity of the chosen printing
+type DayStats struct {
+	Colors   map[string]int `json:"colors"`
+	Rarities map[string]int `json:"rarities"`
+}
+
+// summarizeStats counts the added cards by color and rarity, nil when there are none
+func summarizeStats(oracleIDs []string, oracleToCard map[string]Card) *DayStats {
+	stats := DayStats{Colors: make(map[string]int), Rarities: make(map[string]int)}
+	for _, oracleID := range oracleIDs {
+		card, found := oracleToCard[oracleID]
+		if !found {
+			continue
+		}
+		stats.add(card, 1)
+	}
+	if len(stats.Colors) == 0 {
+		return nil
+	}
+	return &stats
+}
+
+// add counts a card delta times, dropping buckets that reach zero
+func (stats *DayStats) add(card Card, delta int) {
+	increment(stats.Colors, colorBucket(card), delta)
+	if card.Rarity != "" {
+		increment(stats.Rarities, card.Rarity, delta)
+	}
+}
+
+func increment(counts map[string]int, key string, delta int) {
+	counts[key] += delta
+	if counts[key] <= 0 {
+		delete(counts, key)
+	}
+}
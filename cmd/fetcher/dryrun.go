@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// DryRunReport is the machine-readable result of a dry run for one format
+type DryRunReport struct {
+	Format   string     `json:"format"`
+	Changed  bool       `json:"changed"`         // whether a real run would write today's entry
+	Entry    *DayResult `json:"entry,omitempty"` // the entry a real run would write
+	NewCards []string   `json:"new_cards"`       // names of the added cards
+}
+
+// reportDryRun prints the entry a real run would write, as text followed by a single line of JSON
+func reportDryRun(format string, entry *DayResult, oracleToCard map[string]Card) error {
+	report := DryRunReport{Format: format, Changed: entry != nil, Entry: entry, NewCards: []string{}}
+
+	if entry == nil {
+		fmt.Printf("Dry run: %s history would not change\n", format)
+	} else if entry.FirstRun {
+		fmt.Printf("Dry run: %s history would start with a baseline of %d cards on %s\n", format, entry.TotalCards, entry.Date)
+	} else {
+		for _, oracleID := range entry.AddedOracles {
+			report.NewCards = append(report.NewCards, oracleToCard[oracleID].Name)
+		}
+		sort.Strings(report.NewCards)
+
+		fmt.Printf("Dry run: %s entry for %s would have %d new cards (%d total)\n", format, entry.Date, len(report.NewCards), entry.TotalCards)
+		for _, name := range report.NewCards {
+			fmt.Printf("  + %s\n", name)
+		}
+		if count := len(entry.ArenaAdded); count > 0 {
+			fmt.Printf("  %d cards now on Arena\n", count)
+		}
+		if count := len(entry.RemovedOracles); count > 0 {
+			fmt.Printf("  %d cards no longer legal\n", count)
+		}
+		if count := len(entry.LegalityChanges); count > 0 {
+			fmt.Printf("  %d legality changes\n", count)
+		}
+		for _, rename := range entry.Renamed {
+			fmt.Printf("  ~ %s is now %s\n", rename.OldName, rename.NewName)
+		}
+		if count := len(entry.Previews); count > 0 {
+			fmt.Printf("  %d cards previewed\n", count)
+		}
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
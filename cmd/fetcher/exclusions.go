@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Layouts and set types that are never cards to play, whatever legality Scryfall gives them.
+// Funny sets are not excluded: Unfinity's cards without acorns are legal in eternal formats.
+var (
+	defaultExcludedLayouts  = []string{"art_series", "double_faced_token", "emblem", "token"}
+	defaultExcludedSetTypes = []string{"memorabilia", "token"}
+)
+
+// scryfallKeyPattern matches Scryfall layout and set type names such as "art_series"
+var scryfallKeyPattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// setCodePattern matches Scryfall set codes such as "blb" or "pa1e"
+var setCodePattern = regexp.MustCompile(`^[a-z0-9]+$`)
+
+// cardExclusions are the layouts, set types and sets left out of tracking
+type cardExclusions struct {
+	Layouts  map[string]bool
+	SetTypes map[string]bool
+
+	IgnoredSets map[string]bool // Set codes whose printings are never tracked
+	OnlySets    map[string]bool // When not empty, the only set codes whose printings are tracked
+}
+
+// parseExclusionList splits and validates -exclude-layouts or -exclude-set-types; empty excludes nothing
+func parseExclusionList(value string) (map[string]bool, error) {
+	excluded := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !scryfallKeyPattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid name %q", name)
+		}
+		excluded[name] = true
+	}
+	return excluded, nil
+}
+
+// parseSetList splits and validates set codes given to -ignore-sets or -only-sets, or read from
+// data/ignore-sets.txt, adding them to sets
+func parseSetList(sets map[string]bool, codes []string) error {
+	for _, code := range codes {
+		code = strings.ToLower(strings.TrimSpace(code))
+		if code == "" {
+			continue
+		}
+		if !setCodePattern.MatchString(code) {
+			return fmt.Errorf("invalid set code %q", code)
+		}
+		sets[code] = true
+	}
+	return nil
+}
+
+// excludes reports whether a card has an excluded layout or set type, or is from a set not tracked
+func (exclusions cardExclusions) excludes(card Card) bool {
+	if exclusions.IgnoredSets[card.Set] || (len(exclusions.OnlySets) > 0 && !exclusions.OnlySets[card.Set]) {
+		return true
+	}
+	return exclusions.Layouts[card.Layout] || exclusions.SetTypes[card.SetType]
+}
+
+// excludeCards drops cards with an excluded layout or set type or from a set not tracked and returns
+// the remaining cards and the dropped oracle_ids. An oracle_id is listed even when other printings
+// of the card are kept.
+func excludeCards(cards []Card, exclusions cardExclusions) ([]Card, map[string]bool) {
+	var kept []Card
+	excluded := make(map[string]bool)
+	for _, card := range cards {
+		if exclusions.excludes(card) {
+			slog.Debug("Skipping excluded card", "name", card.Name, "card_id", card.ID, "layout", card.Layout, "set_type", card.SetType, "set", card.Set)
+			excluded[card.OracleID] = true
+			continue
+		}
+		kept = append(kept, card)
+	}
+	return kept, excluded
+}
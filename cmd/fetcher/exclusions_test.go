@@ -0,0 +1,95 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// defaultExclusions are the exclusions of a run without -exclude-layouts and -exclude-set-types
+func defaultExclusions(t *testing.T) cardExclusions {
+	t.Helper()
+	layouts, err := parseExclusionList(strings.Join(defaultExcludedLayouts, ","))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setTypes, err := parseExclusionList(strings.Join(defaultExcludedSetTypes, ","))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cardExclusions{Layouts: layouts, SetTypes: setTypes}
+}
+
+func TestExcludeCardsDefaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		layout   string
+		setType  string
+		excluded bool
+	}{
+		{"art series card", "art_series", "memorabilia", true},
+		{"art series layout alone", "art_series", "expansion", true},
+		{"double-faced token", "double_faced_token", "token", true},
+		{"double-faced token in a main set", "double_faced_token", "expansion", true},
+		{"emblem", "emblem", "expansion", true},
+		{"token", "token", "expansion", true},
+		{"memorabilia", "normal", "memorabilia", true},
+		{"token set", "normal", "token", true},
+
+		// Near misses that are cards to play
+		{"normal", "normal", "expansion", false},
+		{"transform", "transform", "expansion", false},
+		{"modal double-faced", "modal_dfc", "core", false},
+		{"reversible card", "reversible_card", "box", false},
+		{"funny set", "normal", "funny", false},
+		{"commander set", "normal", "commander", false},
+		{"promo", "normal", "promo", false},
+		{"no layout or set type", "", "", false},
+	}
+	exclusions := defaultExclusions(t)
+
+	var cards []Card
+	var want []string
+	for _, test := range tests {
+		card := testCard(test.name)
+		card.Layout = test.layout
+		card.SetType = test.setType
+		cards = append(cards, card)
+		if !test.excluded {
+			want = append(want, test.name)
+		}
+	}
+	kept, excluded := excludeCards(cards, exclusions)
+
+	var keptNames []string
+	for _, card := range kept {
+		keptNames = append(keptNames, card.OracleID)
+	}
+	if !reflect.DeepEqual(keptNames, want) {
+		t.Errorf("kept %q, want %q", keptNames, want)
+	}
+	for _, test := range tests {
+		if excluded[test.name] != test.excluded {
+			t.Errorf("%s: excluded = %v, want %v", test.name, excluded[test.name], test.excluded)
+		}
+	}
+}
+
+// An oracle_id is reported as excluded even when other printings of the card are kept
+func TestExcludeCardsPrintings(t *testing.T) {
+	card := testCard("a")
+	card.Layout = "normal"
+	card.SetType = "expansion"
+	artSeries := card
+	artSeries.ID = "art-series-a"
+	artSeries.Layout = "art_series"
+	artSeries.SetType = "memorabilia"
+
+	kept, excluded := excludeCards([]Card{card, artSeries}, defaultExclusions(t))
+	if len(kept) != 1 || kept[0].ID != card.ID {
+		t.Errorf("kept %v, want only %s", kept, card.ID)
+	}
+	if !excluded["a"] {
+		t.Error("the oracle_id of the art series printing is not reported")
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+// exitNoChanges is the exit code of a run that found nothing new: it modified no history, or as a dry
+// run found no new cards
+const exitNoChanges = 3
+
+// runExitCode decides how a tracking run exits: 1 when it failed, 0 when it modified a history, and
+// exitNoChanges otherwise. An entry that only records the day's total modifies the history. A dry run
+// writes nothing and exits 0 when it found new cards a real run would add.
+func runExitCode(err error, changed bool, dryRun bool, newCards int) int {
+	switch {
+	case err != nil:
+		return 1
+	case dryRun && newCards > 0, !dryRun && changed:
+		return 0
+	default:
+		return exitNoChanges
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Scryfall's API; -api-url points the fetcher at a mirror or caching proxy instead
+	defaultAPIURL = "https://api.scryfall.com"
+
+	// Identifies the fetcher to Scryfall, the build's version is appended
+	defaultUserAgent = "BrawlChronicle/1.0"
+
+	// Whole-request limit for API requests other than the bulk-data listing
+	apiTimeout = 30 * time.Second
+
+	// Whole-request limit for the small bulk-data listing
+	bulkDataTimeout = 30 * time.Second
+
+	// Whole-request limit for the bulk file, a few hundred megabytes
+	downloadTimeout = 20 * time.Minute
+
+	// A connection that delivers no bytes for this long is considered hung
+	idleReadTimeout = time.Minute
+)
+
+// scryfallClient sends the fetcher's requests: API paths are resolved against BaseURL and every
+// request carries UserAgent. Tests and embedders can swap the Transport.
+type scryfallClient struct {
+	BaseURL   string
+	UserAgent string
+	Transport http.RoundTripper
+}
+
+// newScryfallClient returns a client for the API at baseURL. The fetcher's version is appended to
+// userAgent when the build records one, and a nil transport drops connections that stall.
+func newScryfallClient(baseURL string, userAgent string, transport http.RoundTripper) (*scryfallClient, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("%q is not an http(s) URL", baseURL)
+	}
+	if strings.TrimSpace(userAgent) == "" {
+		return nil, fmt.Errorf("the user agent must not be empty")
+	}
+
+	if version := fetcherVersion(); version != "" {
+		userAgent += " fetcher/" + version
+	}
+	if transport == nil {
+		transport = newIdleTimeoutTransport()
+	}
+	return &scryfallClient{BaseURL: strings.TrimSuffix(baseURL, "/"), UserAgent: userAgent, Transport: transport}, nil
+}
+
+// apiURL resolves an API path such as "/bulk-data"
+func (c *scryfallClient) apiURL(path string) string {
+	return c.BaseURL + path
+}
+
+// newRequest creates a GET request with the fetcher's headers
+func (c *scryfallClient) newRequest(ctx context.Context, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+	req.Header.Set("Accept", "application/json;q=0.9,*/*;q=0.8")
+	return req, nil
+}
+
+// httpClient returns a client that gives up on the whole request after timeout
+func (c *scryfallClient) httpClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: c.Transport}
+}
+
+// newIdleTimeoutTransport returns a transport that gives up on any single read after idleReadTimeout,
+// so a stalled connection can't hang the run
+func newIdleTimeoutTransport() http.RoundTripper {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: func(ctx context.Context, network string, address string) (net.Conn, error) {
+			conn, err := dialer.DialContext(ctx, network, address)
+			if err != nil {
+				return nil, err
+			}
+			return &idleTimeoutConn{Conn: conn, timeout: idleReadTimeout}, nil
+		},
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   15 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+	}
+}
+
+// idleTimeoutConn moves the read deadline forward before every read
+type idleTimeoutConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c *idleTimeoutConn) Read(b []byte) (int, error) {
+	if err := c.Conn.SetReadDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(b)
+}
+
+// requestFailure says how a request failed, so the log tells timeouts and interruptions from other errors
+func requestFailure(err error) string {
+	if errors.Is(err, context.Canceled) {
+		return "interrupted"
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return "timed out"
+	}
+	return "failed"
+}
@@ -0,0 +1,115 @@
+package main
+
+import "log/slog"
+
+// isLegacyDay reports whether a day still records printing ids in added_cards instead of oracle_ids.
+// Only histories of schema version 0 have such days.
+func isLegacyDay(day DayResult) bool {
+	return day.AddedOracles == nil && len(day.AddedCards) > 0
+}
+
+// legacyMigration counts what migrateLegacyDays did with the printing ids of legacy days
+type legacyMigration struct {
+	Days      int // legacy days rewritten into the oracle format
+	Resolved  int // printing ids translated to an oracle_id
+	Ambiguous int // printings without a single oracle_id, e.g. reversible cards with one per face
+	Unknown   int // printing ids missing from the cards, e.g. no longer on Scryfall
+	Pinned    int // card_mapping printings completed with their set, collector number and release date
+}
+
+// migrateLegacyDays rewrites legacy days in place into the oracle format, translating printing ids
+// with the cached cards. The printing becomes the day's card_mapping so it is still the one shown.
+// Ids that can't be translated are kept in unresolved_cards and retried on later runs.
+func migrateLegacyDays(history HistoryData, cards []Card) (HistoryData, legacyMigration) {
+	return translatePrintings(history, cards, true)
+}
+
+// retryUnresolvedCards translates the unresolved_cards left by an earlier migration with the cached cards
+func retryUnresolvedCards(history HistoryData, cards []Card) (HistoryData, legacyMigration) {
+	return translatePrintings(history, cards, false)
+}
+
+// translatePrintings moves printing ids that resolve to an oracle_id into added_oracles, from
+// unresolved_cards and, when legacy is set, from the added_cards of legacy days
+func translatePrintings(history HistoryData, cards []Card, legacy bool) (HistoryData, legacyMigration) {
+	printings := make(map[string]Card)
+	for _, card := range cards {
+		printings[card.ID] = card
+	}
+
+	var migration legacyMigration
+	known := make(map[string]bool)
+	for i, day := range history.Days {
+		legacyDay := legacy && isLegacyDay(day)
+		if !legacyDay && len(day.UnresolvedCards) == 0 {
+			for _, oracleID := range day.AddedOracles {
+				known[oracleID] = true
+			}
+			for _, oracleID := range day.RemovedOracles {
+				delete(known, oracleID)
+			}
+			continue
+		}
+
+		cardIDs := day.UnresolvedCards
+		if legacyDay {
+			cardIDs = day.AddedCards
+			day.AddedOracles = []string{}
+			day.AddedCards = nil
+			migration.Days++
+		}
+
+		var unresolved []string
+		for _, cardID := range cardIDs {
+			card, found := printings[cardID]
+			if !found || card.OracleID == "" {
+				if found {
+					migration.Ambiguous++
+				} else {
+					migration.Unknown++
+				}
+				unresolved = append(unresolved, cardID)
+				continue
+			}
+			migration.Resolved++
+			// Legacy days list every new printing, a reprint of a card seen before is not an addition
+			if known[card.OracleID] {
+				continue
+			}
+			known[card.OracleID] = true
+			day.AddedOracles = append(day.AddedOracles, card.OracleID)
+
+			// The first run's cards are not shown, so its printings don't matter
+			if day.FirstRun {
+				continue
+			}
+			if day.CardMapping == nil {
+				day.CardMapping = make(map[string]PinnedPrinting)
+			}
+			day.CardMapping[card.OracleID] = newPinnedPrinting(card)
+		}
+		day.AddedOracles = mergeOracleIDs(day.AddedOracles, nil)
+		if day.AddedOracles == nil {
+			day.AddedOracles = []string{}
+		}
+		day.UnresolvedCards = unresolved
+
+		if len(unresolved) > 0 {
+			slog.Warn("Cards could not be resolved to oracle ids, kept in unresolved_cards", "date", day.Date, "count", len(unresolved))
+		}
+		history.Days[i] = day
+	}
+	return history, migration
+}
+
+// legacyPrintingIDs collects the printing ids legacy days still need resolved, so the card cache keeps those printings
+func legacyPrintingIDs(history HistoryData) []string {
+	var ids []string
+	for _, day := range history.Days {
+		if history.SchemaVersion < 1 && isLegacyDay(day) {
+			ids = append(ids, day.AddedCards...)
+		}
+		ids = append(ids, day.UnresolvedCards...)
+	}
+	return ids
+}
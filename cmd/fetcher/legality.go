@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+)
+
+// defaultLegalStatuses are the legality statuses a card is tracked with unless -legal-statuses says otherwise
+var defaultLegalStatuses = []string{"legal"}
+
+// acceptableStatuses are the Scryfall legality statuses -legal-statuses may list; not_legal never counts
+var acceptableStatuses = map[string]bool{"legal": true, "restricted": true, "banned": true}
+
+// LegalityChange is a card's legality moving between two statuses, e.g. legal -> banned
+type LegalityChange struct {
+	OracleID string `json:"oracle_id"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+}
+
+// LegalityState is the last-known legality of every cached oracle_id in one format
+type LegalityState struct {
+	Legalities map[string]string `json:"legalities"`
+}
+
+// parseLegalStatuses splits and validates the -legal-statuses flag, returning the statuses sorted
+func parseLegalStatuses(value string) ([]string, error) {
+	var statuses []string
+	seen := make(map[string]bool)
+	for _, status := range strings.Split(value, ",") {
+		status = strings.ToLower(strings.TrimSpace(status))
+		if status == "" || seen[status] {
+			continue
+		}
+		if !acceptableStatuses[status] {
+			return nil, fmt.Errorf("invalid legality status %q, expected legal, restricted or banned", status)
+		}
+		seen[status] = true
+		statuses = append(statuses, status)
+	}
+	if len(statuses) == 0 {
+		return nil, fmt.Errorf("no legality statuses to accept")
+	}
+	sort.Strings(statuses)
+	return statuses, nil
+}
+
+// acceptsStatus reports whether a legality status is one of the accepted ones
+func acceptsStatus(statuses []string, status string) bool {
+	for _, accepted := range statuses {
+		if accepted == status {
+			return true
+		}
+	}
+	return false
+}
+
+// sameStatuses compares two sorted status lists, an empty list standing for the default
+func sameStatuses(a, b []string) bool {
+	if len(a) == 0 {
+		a = defaultLegalStatuses
+	}
+	if len(b) == 0 {
+		b = defaultLegalStatuses
+	}
+	return strings.Join(a, ",") == strings.Join(b, ",")
+}
+
+// describeStatuses names a list of statuses for messages, e.g. "legal or restricted"
+func describeStatuses(statuses []string) string {
+	if len(statuses) == 0 {
+		statuses = defaultLegalStatuses
+	}
+	return strings.Join(statuses, " or ")
+}
+
+// recordedStatuses returns the statuses to record in metadata, nil for the default so files
+// written with it stay as they were
+func recordedStatuses(statuses []string) []string {
+	if sameStatuses(statuses, nil) {
+		return nil
+	}
+	return statuses
+}
+
+// buildOracleLegalities collects the legality of each oracle_id in a format. Printings normally agree;
+// when they don't, an accepted status wins so the state matches what the diff considers legal.
+func buildOracleLegalities(cards []Card, format string, statuses []string) map[string]string {
+	legalities := make(map[string]string)
+	for _, card := range cards {
+		status := card.Legalities[format]
+		if status == "" || card.OracleID == "" {
+			continue
+		}
+		if existing, found := legalities[card.OracleID]; !found || (acceptsStatus(statuses, status) && !acceptsStatus(statuses, existing)) {
+			legalities[card.OracleID] = status
+		}
+	}
+	return legalities
+}
+
+// findLegalityChanges compares legalities of oracle_ids present in both states.
+// Cards new to the state are additions and are reported as such instead.
+func findLegalityChanges(previous, current map[string]string) []LegalityChange {
+	var changes []LegalityChange
+	for oracleID, to := range current {
+		if from, found := previous[oracleID]; found && from != to {
+			changes = append(changes, LegalityChange{OracleID: oracleID, From: from, To: to})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].OracleID < changes[j].OracleID
+	})
+	return changes
+}
+
+// mergeLegalityChanges combines changes recorded by an earlier run of the day with new ones,
+// keeping the first "from" and the last "to" of each card and dropping round trips
+func mergeLegalityChanges(earlier, later []LegalityChange) []LegalityChange {
+	merged := make(map[string]LegalityChange)
+	for _, change := range earlier {
+		merged[change.OracleID] = change
+	}
+	for _, change := range later {
+		if first, found := merged[change.OracleID]; found {
+			change.From = first.From
+		}
+		merged[change.OracleID] = change
+	}
+
+	var changes []LegalityChange
+	for _, change := range merged {
+		if change.From != change.To {
+			changes = append(changes, change)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].OracleID < changes[j].OracleID
+	})
+	return changes
+}
+
+// loadLegalityState reads the previous legalities; found is false when there is no state yet
+func loadLegalityState(filename string) (map[string]string, bool) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, false
+	}
+
+	var state LegalityState
+	if err := json.Unmarshal(data, &state); err != nil {
+		slog.Warn("Ignoring unreadable legality state", "file", filename, "err", err)
+		return nil, false
+	}
+	return state.Legalities, state.Legalities != nil
+}
+
+func saveLegalityState(legalities map[string]string, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(LegalityState{Legalities: legalities})
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+const (
+	// Lock file in the data directory held by a running fetcher
+	lockFileName = ".fetcher.lock"
+
+	// A lock this old is left over from a run that was killed, a real run takes minutes
+	staleLockAge = 2 * time.Hour
+
+	// How often a waiting run checks whether the lock was released
+	lockPollInterval = time.Second
+)
+
+// errLocked is returned by acquireLock when another run holds the lock for longer than we wait
+var errLocked = errors.New("another fetcher run holds the lock")
+
+// lockInfo is the content of the lock file, telling who holds it
+type lockInfo struct {
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// releaseLock removes the lock file of this run; it is replaced once the lock is acquired
+var releaseLock = func() {}
+
+// exit releases the lock and ends the process with the given status, since os.Exit skips deferred calls
+func exit(code int) {
+	releaseLock()
+	os.Exit(code)
+}
+
+// acquireLock creates the lock file exclusively, waiting up to wait for another run to finish.
+// A lock older than staleLockAge is broken with a warning.
+func acquireLock(filename string, wait time.Duration) error {
+	deadline := time.Now().Add(wait)
+	waiting := false
+	for {
+		file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			info := lockInfo{PID: os.Getpid(), StartedAt: time.Now().UTC()}
+			err = json.NewEncoder(file).Encode(info)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(filename)
+				return err
+			}
+			releaseLock = func() {
+				if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+					slog.Warn("Could not remove lock file", "file", filename, "err", err)
+				}
+			}
+			return nil
+		}
+		if !os.IsExist(err) {
+			return err
+		}
+
+		holder, age, err := readLock(filename)
+		if os.IsNotExist(err) {
+			// Released between our attempt and the read
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if age > staleLockAge {
+			slog.Warn("Breaking stale lock", "file", filename, "pid", holder.PID, "age", age.Round(time.Minute))
+			if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("%w: pid %d since %s (%s); wait with -lock-wait or remove %s if that run is gone",
+				errLocked, holder.PID, holder.StartedAt.Format(time.RFC3339), age.Round(time.Second), filename)
+		}
+		if !waiting {
+			slog.Info("Waiting for another fetcher run to finish", "pid", holder.PID, "lock", filename, "wait", wait)
+			waiting = true
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// readLock returns the holder of a lock and how long it has held it. A lock file that can't be
+// parsed, e.g. one caught while being written, is dated by its modification time.
+func readLock(filename string) (lockInfo, time.Duration, error) {
+	stat, err := os.Stat(filename)
+	if err != nil {
+		return lockInfo{}, 0, err
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return lockInfo{}, 0, err
+	}
+
+	var holder lockInfo
+	if err := json.Unmarshal(data, &holder); err != nil || holder.StartedAt.IsZero() {
+		holder.StartedAt = stat.ModTime()
+	}
+	return holder, time.Since(holder.StartedAt), nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestMain lets the test binary stand in for a second fetcher run, see startLockHolder
+func TestMain(m *testing.M) {
+	if dir := os.Getenv("FETCHER_TEST_LOCK_HOLDER"); dir != "" {
+		holdLock(dir)
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// holdLock takes the lock of dir like a fetcher run, and keeps it until its stdin is closed
+func holdLock(dir string) {
+	if err := acquireLock(filepath.Join(dir, lockFileName), 0); err != nil {
+		os.Stdout.WriteString("error: " + err.Error() + "\n")
+		os.Exit(1)
+	}
+	os.Stdout.WriteString("locked\n")
+	bufio.NewReader(os.Stdin).ReadString('\n')
+	releaseLock()
+	os.Exit(0)
+}
+
+// startLockHolder starts another process holding the lock of dir; closing the returned function
+// releases it and waits for the process to end
+func startLockHolder(t *testing.T, dir string) func() {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	cmd.Env = append(os.Environ(), "FETCHER_TEST_LOCK_HOLDER="+dir)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil || line != "locked\n" {
+		cmd.Process.Kill()
+		cmd.Wait()
+		t.Fatalf("lock holder did not get the lock: %q %v", line, err)
+	}
+
+	released := false
+	release := func() {
+		if released {
+			return
+		}
+		released = true
+		stdin.Close()
+		if err := cmd.Wait(); err != nil {
+			t.Errorf("lock holder failed: %v", err)
+		}
+	}
+	t.Cleanup(release)
+	return release
+}
+
+func TestAcquireLockFailsWhileAnotherRunHoldsIt(t *testing.T) {
+	dir := t.TempDir()
+	startLockHolder(t, dir)
+
+	err := acquireLock(filepath.Join(dir, lockFileName), 0)
+	if !errors.Is(err, errLocked) {
+		t.Fatalf("acquireLock() = %v, want %v", err, errLocked)
+	}
+}
+
+func TestAcquireLockWaitsForAnotherRun(t *testing.T) {
+	dir := t.TempDir()
+	release := startLockHolder(t, dir)
+	time.AfterFunc(500*time.Millisecond, release)
+
+	if err := acquireLock(filepath.Join(dir, lockFileName), 10*time.Second); err != nil {
+		t.Fatalf("acquireLock() = %v, want the lock once the other run is done", err)
+	}
+	defer releaseLock()
+
+	holder, _, err := readLock(filepath.Join(dir, lockFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if holder.PID != os.Getpid() {
+		t.Errorf("lock held by pid %d, want %d", holder.PID, os.Getpid())
+	}
+}
+
+func TestAcquireLockBreaksStaleLock(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, lockFileName)
+	stale, err := json.Marshal(lockInfo{PID: 1, StartedAt: time.Now().Add(-staleLockAge - time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filename, stale, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := acquireLock(filename, 0); err != nil {
+		t.Fatalf("acquireLock() = %v, want the stale lock broken", err)
+	}
+	releaseLock()
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Errorf("lock file left after release: %v", err)
+	}
+}
+
+func TestMigrateInPlaceRespectsLock(t *testing.T) {
+	dir := t.TempDir()
+	historyFile := filepath.Join(dir, "history.json")
+	original := []byte(`{"days":[{"date":"2024-01-01","added_cards":["a"]}]}`)
+	if err := os.WriteFile(historyFile, original, 0644); err != nil {
+		t.Fatal(err)
+	}
+	startLockHolder(t, dir)
+
+	err := runMigrate([]string{"-data-dir", dir, "-in-place", "-quiet", historyFile})
+	if !errors.Is(err, errLocked) {
+		t.Fatalf("runMigrate() = %v, want %v", err, errLocked)
+	}
+	content, err := os.ReadFile(historyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != string(original) {
+		t.Errorf("history rewritten while locked: %s", content)
+	}
+}
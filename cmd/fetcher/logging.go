@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// logOptions are the logging flags shared by the fetcher and its subcommands
+type logOptions struct {
+	format  *string
+	verbose *bool
+	quiet   *bool
+}
+
+// addLogFlags registers -log-format, -verbose and -quiet
+func addLogFlags(flags *flag.FlagSet) logOptions {
+	return logOptions{
+		format:  flags.String("log-format", "text", "Log format: text, or json for log shippers such as Loki"),
+		verbose: flags.Bool("verbose", false, "Also log per-card decisions, e.g. which printing was chosen and why cards were skipped"),
+		quiet:   flags.Bool("quiet", false, "Only log warnings and errors"),
+	}
+}
+
+// summaryLogger logs what -quiet still shows, the summaries of long steps such as the bulk download. It
+// is the default logger until setup.
+var summaryLogger = slog.Default()
+
+// setup installs the default logger and summaryLogger. Info and debug records go to stdout, warnings and
+// errors to stderr.
+func (options logOptions) setup() error {
+	level := slog.LevelInfo
+	if *options.verbose {
+		level = slog.LevelDebug
+	}
+	if *options.quiet {
+		level = slog.LevelWarn
+	}
+
+	var newHandler func(io.Writer, slog.Level) slog.Handler
+	switch *options.format {
+	case "text":
+		newHandler = func(w io.Writer, level slog.Level) slog.Handler {
+			return slog.NewTextHandler(w, &slog.HandlerOptions{Level: level})
+		}
+	case "json":
+		newHandler = func(w io.Writer, level slog.Level) slog.Handler {
+			return slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})
+		}
+	default:
+		return fmt.Errorf("-log-format must be text or json, got %q", *options.format)
+	}
+
+	slog.SetDefault(slog.New(splitHandler{out: newHandler(os.Stdout, level), err: newHandler(os.Stderr, level)}))
+	summaryLogger = slog.New(splitHandler{out: newHandler(os.Stdout, slog.LevelInfo), err: newHandler(os.Stderr, slog.LevelInfo)})
+	return nil
+}
+
+// splitHandler sends warnings and errors to one handler and everything else to another
+type splitHandler struct {
+	out slog.Handler
+	err slog.Handler
+}
+
+func (h splitHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.out.Enabled(ctx, level)
+}
+
+func (h splitHandler) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level >= slog.LevelWarn {
+		return h.err.Handle(ctx, record)
+	}
+	return h.out.Handle(ctx, record)
+}
+
+func (h splitHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return splitHandler{out: h.out.WithAttrs(attrs), err: h.err.WithAttrs(attrs)}
+}
+
+func (h splitHandler) WithGroup(name string) slog.Handler {
+	return splitHandler{out: h.out.WithGroup(name), err: h.err.WithGroup(name)}
+}
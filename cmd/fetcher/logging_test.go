@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"testing"
+)
+
+func TestQuietKeepsSummaries(t *testing.T) {
+	defaultLogger, defaultSummaryLogger := slog.Default(), summaryLogger
+	t.Cleanup(func() {
+		slog.SetDefault(defaultLogger)
+		summaryLogger = defaultSummaryLogger
+	})
+
+	flags := flag.NewFlagSet("fetcher", flag.ContinueOnError)
+	options := addLogFlags(flags)
+	if err := flags.Parse([]string{"-quiet"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := options.setup(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if slog.Default().Enabled(ctx, slog.LevelInfo) {
+		t.Error("-quiet logs info records such as the download progress")
+	}
+	if !slog.Default().Enabled(ctx, slog.LevelWarn) {
+		t.Error("-quiet hides warnings")
+	}
+	if !summaryLogger.Enabled(ctx, slog.LevelInfo) {
+		t.Error("-quiet hides the download summary")
+	}
+}
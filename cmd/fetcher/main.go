@@ -1,327 +1,1039 @@
 package main
 
 import (
+	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
 	"time"
 )
 
 type BulkDataInfo struct {
-	Data []struct {
-		Type        string `json:"type"`
-		DownloadURI string `json:"download_uri"`
-		UpdatedAt   string `json:"updated_at"`
-	} `json:"data"`
+	Data []BulkDataEntry `json:"data"`
 }
 
+// BulkDataEntry is one downloadable dataset listed by /bulk-data
+type BulkDataEntry struct {
+	Type        string `json:"type"`
+	DownloadURI string `json:"download_uri"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+// Card holds the fields the fetcher tracks plus everything the renderer reads from the card cache
 type Card struct {
-	ID         string            `json:"id"`
-	OracleID   string            `json:"oracle_id"`
+	ID              string            `json:"id"`
+	OracleID        string            `json:"oracle_id"`
+	Name            string            `json:"name"`
+	ManaCost        string            `json:"mana_cost,omitempty"`
+	CMC             float64           `json:"cmc"`
+	TypeLine        string            `json:"type_line,omitempty"`
+	Colors          []string          `json:"colors,omitempty"`
+	Rarity          string            `json:"rarity,omitempty"`
+	Set             string            `json:"set,omitempty"`
+	SetName         string            `json:"set_name,omitempty"`
+	SetType         string            `json:"set_type,omitempty"`
+	Layout          string            `json:"layout,omitempty"`
+	ReleasedAt      string            `json:"released_at,omitempty"`
+	CollectorNumber string            `json:"collector_number,omitempty"`
+	Legalities      map[string]string `json:"legalities"`
+	ImageURIs       map[string]string `json:"image_uris,omitempty"`
+	Games           []string          `json:"games"`
+	OracleText      string            `json:"oracle_text,omitempty"`
+	CardFaces       []CardFace        `json:"card_faces,omitempty"`
+	PromoTypes      []string          `json:"promo_types,omitempty"`
+}
+
+// CardFace holds the per-face data of multi-faced cards
+type CardFace struct {
 	Name       string            `json:"name"`
-	Legalities map[string]string `json:"legalities"`
-	Games      []string          `json:"games"`
+	ManaCost   string            `json:"mana_cost,omitempty"`
+	TypeLine   string            `json:"type_line,omitempty"`
+	OracleText string            `json:"oracle_text,omitempty"`
+	Colors     []string          `json:"colors,omitempty"`
+	ImageURIs  map[string]string `json:"image_uris,omitempty"`
 }
 
 // Oracle-based data structure - track oracle_ids for unique cards
 type DayResult struct {
-	Date         string   `json:"date"`
-	AddedOracles []string `json:"added_oracles"` // oracle_ids of new cards
-	TotalCards   int      `json:"total_cards"`
-	FirstRun     bool     `json:"first_run"`
+	Date         string                    `json:"date"`
+	AddedOracles []string                  `json:"added_oracles"`          // oracle_ids of new cards
+	CardMapping  map[string]PinnedPrinting `json:"card_mapping,omitempty"` // printing chosen for each added oracle_id
+	TotalCards   int                       `json:"total_cards"`
+	FirstRun     bool                      `json:"first_run"`
+	ArenaAdded   []string                  `json:"arena_added,omitempty"` // known oracle_ids that became available on Arena
+
+	// Sets the chosen printings of the added cards are from, largest first
+	Sets []SetCount `json:"sets,omitempty"`
+
+	// Added cards by color bucket and rarity, for trends without the card data
+	Stats *DayStats `json:"stats,omitempty"`
+
+	// Scryfall Tagger tags per added oracle_id (only with -tags)
+	Tags map[string][]string `json:"tags,omitempty"`
+
+	// Added oracle_ids that are on data/watchlist.txt
+	WatchlistHits []string `json:"watchlist_hits,omitempty"`
 	
+	// Known oracle_ids that are no longer legal
+	RemovedOracles []string `json:"removed_oracles,omitempty"`
+
+	// Legality status transitions of known cards, e.g. legal -> banned
+	LegalityChanges []LegalityChange `json:"legality_changes,omitempty"`
+
+	// Known cards whose Oracle name changed
+	Renamed []NameChange `json:"renamed,omitempty"`
+
+	// Cards previewed on Scryfall that are not legal yet (only with -previews)
+	Previews []PreviewCard `json:"previews,omitempty"`
+
+	// Scryfall dump and fetcher build of the run that last wrote the entry
+	Source *BulkSource `json:"source,omitempty"`
+
 	// Legacy support for old format
 	AddedCards []string `json:"added_cards"`
+
+	// Legacy printing ids that couldn't be translated to oracle_ids yet
+	UnresolvedCards []string `json:"unresolved_cards,omitempty"`
 }
 
 type HistoryData struct {
+	// Version of the format, see historySchemaVersion
+	SchemaVersion int `json:"schema_version"`
+
+	Meta HistoryMeta `json:"meta"`
+
+	// oracle_ids known before the first day, left by the days "fetcher prune" moved to an archive
+	BaselineOracles []string `json:"baseline_oracles,omitempty"`
+
 	Days []DayResult `json:"days"`
 }
 
-func main() {
-	dataDir := "data"
-	resultsDir := filepath.Join(dataDir, "results")
-	oracleFile := filepath.Join(dataDir, "default-cards.json")
+// HistoryMeta describes how the latest run produced the history and the card cache
+type HistoryMeta struct {
+	// Scryfall format the history tracks
+	Format string `json:"format,omitempty"`
 
-	os.MkdirAll(resultsDir, 0755)
+	// Scryfall bulk dataset the card cache was built from (default_cards or oracle_cards)
+	BulkType string `json:"bulk_type,omitempty"`
 
-	historyFile := filepath.Join(dataDir, "history.json")
+	// Game the tracked printings must be available in (arena, paper, mtgo), empty for all
+	Games string `json:"games,omitempty"`
 
-	// Check if we already have default cards cached and if it's fresh (less than 23 hours old)
-	var currentCards []Card
-	shouldDownload := true
-	
-	if stat, err := os.Stat(oracleFile); err == nil {
-		// Check if cache is less than 23 hours old
-		cacheAge := time.Since(stat.ModTime())
-		if cacheAge < 23*time.Hour {
-			fmt.Printf("Using cached default cards data (%.1f hours old)\n", cacheAge.Hours())
-			shouldDownload = false
-		} else {
-			fmt.Printf("Cache is %.1f hours old, refreshing...\n", cacheAge.Hours())
+	// Legality statuses a card is tracked with, empty for only "legal"
+	LegalStatuses []string `json:"legal_statuses,omitempty"`
+}
+
+const (
+	// Every printing of every card
+	bulkTypeDefaultCards = "default_cards"
+
+	// One representative printing per oracle_id, about a tenth of the size
+	bulkTypeOracleCards = "oracle_cards"
+)
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			slog.Error("Migrating history failed", "err", err)
+			os.Exit(1)
 		}
+		return
 	}
-	
-	if shouldDownload {
-		// Download and cache default cards
-		fmt.Println("Fetching Scryfall bulk data info...")
-		downloadURL, err := getDownloadURL()
-		if err != nil {
-			fmt.Printf("Error getting download URL: %v\n", err)
+	if len(os.Args) > 1 && os.Args[1] == "prune" {
+		if err := runPrune(os.Args[2:]); err != nil {
+			slog.Error("Pruning history failed", "err", err)
 			os.Exit(1)
 		}
+		return
+	}
 
-		fmt.Printf("Downloading from: %s\n", downloadURL)
-		rawData, err := downloadCards(downloadURL)
-		if err != nil {
-			fmt.Printf("Error downloading cards: %v\n", err)
-			os.Exit(1)
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: go run ./cmd/fetcher [flags]")
+		fmt.Fprintln(flag.CommandLine.Output(), "       go run ./cmd/fetcher migrate [flags] <history.json>")
+		fmt.Fprintln(flag.CommandLine.Output(), "       go run ./cmd/fetcher prune -before <YYYY-MM-DD> [flags]")
+		fmt.Fprintln(flag.CommandLine.Output(), "\nFlags:")
+		flag.PrintDefaults()
+	}
+
+	dataDirFlag := flag.String("data-dir", envDefault("BRAWL_CHRONICLE_DATA_DIR", "data"), "Directory for history, caches and state files (env BRAWL_CHRONICLE_DATA_DIR)")
+	historyFlag := flag.String("history", "", "Brawl history file (default <data-dir>/history.json, or history-brawl-<game>.json with -games)")
+	outputFlag := flag.String("output", "", "Directory for the daily snapshots of added cards (default <data-dir>/results)")
+	cacheTTL := flag.Duration("cache-ttl", 23*time.Hour, "Age after which the card cache is refreshed when there is no bulk-data metadata to compare")
+	tagsFlag := flag.String("tags", "", "Comma-separated Scryfall Tagger tags to label new cards with, e.g. \"removal,ramp,draw\" (disabled when empty)")
+	tagCacheTTL := flag.Duration("tag-cache-ttl", 24*time.Hour, "How long cached tag queries are reused")
+	previewsFlag := flag.Bool("previews", false, "Also record cards previewed on Scryfall before they are legal, found with -preview-query")
+	previewQuery := flag.String("preview-query", defaultPreviewQuery, "Scryfall search for -previews; {date} is replaced with the run date")
+	bulkType := flag.String("bulk-type", bulkTypeDefaultCards, "Scryfall bulk dataset to download: default_cards or oracle_cards")
+	retries := flag.Int("retries", 5, "Attempts for Scryfall bulk-data requests before giving up")
+	maxCardDrop := flag.Float64("max-card-drop", defaultMaxCardDrop, "Percentage of cards a new download may lose against the previous one, in the whole dump or in the cache, before it is refused")
+	apiURL := flag.String("api-url", envDefault("BRAWL_CHRONICLE_API_URL", defaultAPIURL), "Base URL of the Scryfall API, e.g. a mirror or caching proxy (env BRAWL_CHRONICLE_API_URL)")
+	userAgent := flag.String("user-agent", envDefault("BRAWL_CHRONICLE_USER_AGENT", defaultUserAgent), "User-Agent sent to Scryfall, followed by the fetcher version (env BRAWL_CHRONICLE_USER_AGENT)")
+	formatsFlag := flag.String("formats", "brawl", "Comma-separated Scryfall format names to track, e.g. \"brawl,standard,commander\"")
+	legalStatusesFlag := flag.String("legal-statuses", "legal", "Comma-separated legality statuses a card is tracked with: legal, restricted, banned")
+	keepRaw := flag.Bool("keep-raw", false, "Also keep the Scryfall bulk dump in <data-dir>/default-cards.json.gz")
+	bulkSnapshots := flag.Int("bulk-snapshots", 0, "Keep the last N validated bulk downloads, slimmed and compressed, in <data-dir>/snapshots for -compare-with (0 keeps none)")
+	compareWith := flag.String("compare-with", "", "Compare the current cards with a bulk snapshot, listing the cards legal in only one of them, and exit without writing")
+	fullCache := flag.Bool("full-cache", false, "With -keep-raw, keep every field of the bulk dump instead of only those the fetcher and renderer read")
+	input := flag.String("input", "", "Track the cards of a local bulk file (.json or .json.gz) instead of asking Scryfall, e.g. an archived dump")
+	updateCache := flag.Bool("update-cache", false, "With -input, also replace the card cache with the input's cards")
+	forceRefresh := flag.Bool("force-refresh", false, "Download the bulk data even when the cache is current, ignoring its age and ETag")
+	cacheOnly := flag.Bool("cache-only", false, "Never download, use the card cache and fail when there is none")
+	gamesFlag := flag.String("games", "", "Only track printings available in one game, e.g. \"arena\"; each game keeps its own history")
+	excludeLayouts := flag.String("exclude-layouts", strings.Join(defaultExcludedLayouts, ","), "Comma-separated card layouts never tracked, whatever their legality (empty tracks all)")
+	excludeSetTypes := flag.String("exclude-set-types", strings.Join(defaultExcludedSetTypes, ","), "Comma-separated set types whose printings are never tracked (empty tracks all)")
+	ignoreSets := flag.String("ignore-sets", "", "Comma-separated set codes whose printings are never tracked, in addition to <data-dir>/ignore-sets.txt")
+	onlySets := flag.String("only-sets", "", "Comma-separated set codes; when set, only their printings are tracked")
+	reconcile := flag.Bool("reconcile", false, "Report known cards that the exclusions now leave out as no longer legal, instead of keeping them")
+	excludeRebalancedFlag := flag.Bool("exclude-rebalanced", true, "Leave out Alchemy rebalanced cards (\"A-\" names); -exclude-rebalanced=false tracks them")
+	dryRun := flag.Bool("dry-run", false, "Show what would be recorded without writing history, state or caches; exits with status 3 when there are new cards")
+	forceInit := flag.Bool("force-init", false, "Start a new history when the existing one can't be parsed, instead of stopping")
+	backups := flag.Int("backups", 14, "Number of history backups kept in <data-dir>/backups (0 disables backups)")
+	restore := flag.String("restore", "", "Restore a history backup, e.g. data/backups/history-20250101-120000.json, and exit")
+	shard := flag.Bool("shard", false, "Split each tracked history into yearly files under data/history/ (history-<format>/ for other formats) and exit")
+	dateFlag := flag.String("date", "", "Record the run under this date (YYYY-MM-DD) instead of today, to backfill missed days")
+	allowOutOfOrder := flag.Bool("allow-out-of-order", false, "With -date, allow a date before the latest entry; cards it finds are moved there from later days")
+	lockWait := flag.Duration("lock-wait", 0, "How long to wait for another run holding <data-dir>/.fetcher.lock before giving up")
+	metricsFlag := flag.String("metrics", metricsLatest, "Run metrics to write to <data-dir>/metrics: latest (latest.json), history (also append to history.jsonl) or off")
+	changedFlagFile := flag.String("changed-flag-file", "", "File created when a history was modified and removed otherwise, e.g. to skip rendering and deploying")
+	logging := addLogFlags(flag.CommandLine)
+	flag.Parse()
+
+	if err := logging.setup(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if flag.NArg() > 0 {
+		slog.Error("Unexpected arguments", "args", flag.Args())
+		flag.Usage()
+		os.Exit(1)
+	}
+	if *dataDirFlag == "" {
+		slog.Error("-data-dir must not be empty")
+		os.Exit(1)
+	}
+	if *cacheTTL < 0 {
+		slog.Error("-cache-ttl must not be negative")
+		os.Exit(1)
+	}
+
+	formats, err := parseFormatList(*formatsFlag)
+	if err != nil {
+		slog.Error("Invalid -formats", "err", err)
+		os.Exit(1)
+	}
+	var exclusions cardExclusions
+	if exclusions.Layouts, err = parseExclusionList(*excludeLayouts); err != nil {
+		slog.Error("Invalid -exclude-layouts", "err", err)
+		os.Exit(1)
+	}
+	if exclusions.SetTypes, err = parseExclusionList(*excludeSetTypes); err != nil {
+		slog.Error("Invalid -exclude-set-types", "err", err)
+		os.Exit(1)
+	}
+	exclusions.IgnoredSets = make(map[string]bool)
+	if err := parseSetList(exclusions.IgnoredSets, strings.Split(*ignoreSets, ",")); err != nil {
+		slog.Error("Invalid -ignore-sets", "err", err)
+		os.Exit(1)
+	}
+	exclusions.OnlySets = make(map[string]bool)
+	if err := parseSetList(exclusions.OnlySets, strings.Split(*onlySets, ",")); err != nil {
+		slog.Error("Invalid -only-sets", "err", err)
+		os.Exit(1)
+	}
+	legalStatuses, err := parseLegalStatuses(*legalStatusesFlag)
+	if err != nil {
+		slog.Error("Invalid -legal-statuses", "err", err)
+		os.Exit(1)
+	}
+	games, err := parseGames(*gamesFlag)
+	if err != nil {
+		slog.Error("Invalid -games", "err", err)
+		os.Exit(1)
+	}
+	tags, err := parseTagList(*tagsFlag)
+	if err != nil {
+		slog.Error("Invalid -tags", "err", err)
+		os.Exit(1)
+	}
+	if *tagCacheTTL < 0 {
+		slog.Error("-tag-cache-ttl must not be negative")
+		os.Exit(1)
+	}
+	if *bulkType != bulkTypeDefaultCards && *bulkType != bulkTypeOracleCards {
+		slog.Error("-bulk-type must be "+bulkTypeDefaultCards+" or "+bulkTypeOracleCards, "bulk_type", *bulkType)
+		os.Exit(1)
+	}
+	if *retries < 1 {
+		slog.Error("-retries must be at least 1")
+		os.Exit(1)
+	}
+	if *backups < 0 {
+		slog.Error("-backups must not be negative")
+		os.Exit(1)
+	}
+	if *bulkSnapshots < 0 {
+		slog.Error("-bulk-snapshots must not be negative")
+		os.Exit(1)
+	}
+	if *compareWith != "" {
+		// The comparison only needs the cards, nothing is tracked or saved
+		*dryRun = true
+	}
+	if *maxCardDrop < 0 || *maxCardDrop > 100 {
+		slog.Error("-max-card-drop must be between 0 and 100")
+		os.Exit(1)
+	}
+	if (*forceRefresh && *cacheOnly) || (*input != "" && (*forceRefresh || *cacheOnly)) {
+		slog.Error("-input, -force-refresh and -cache-only exclude each other")
+		os.Exit(1)
+	}
+	if *updateCache && *input == "" {
+		slog.Error("-update-cache only applies to -input")
+		os.Exit(1)
+	}
+	metricsMode, err := parseMetricsMode(*metricsFlag)
+	if err != nil {
+		slog.Error("Invalid -metrics", "err", err)
+		os.Exit(1)
+	}
+	if *lockWait < 0 {
+		slog.Error("-lock-wait must not be negative")
+		os.Exit(1)
+	}
+	runDate, err := parseRunDate(*dateFlag, time.Now())
+	if err != nil {
+		slog.Error("Invalid -date", "err", err)
+		os.Exit(1)
+	}
+	client, err := newScryfallClient(*apiURL, *userAgent, nil)
+	if err != nil {
+		slog.Error("Invalid -api-url or -user-agent", "err", err)
+		os.Exit(1)
+	}
+
+	dataDir := *dataDirFlag
+	resultsDir := *outputFlag
+	if resultsDir == "" {
+		resultsDir = filepath.Join(dataDir, "results")
+	}
+	rawCardsFile := filepath.Join(dataDir, "default-cards.json")
+	downloadFile := filepath.Join(dataDir, "default-cards.download.json")
+	cardCacheFile := filepath.Join(dataDir, "brawl-cards.json")
+	cacheMetaFile := filepath.Join(dataDir, "brawl-cards.meta.json")
+	tagCacheDir := filepath.Join(dataDir, "tag-cache")
+	setCalendarFile := filepath.Join(dataDir, "sets.json")
+	symbologyFile := filepath.Join(dataDir, "symbology.json")
+	watchlistFile := filepath.Join(dataDir, "watchlist.txt")
+	ignoreSetsFile := filepath.Join(dataDir, "ignore-sets.txt")
+	backupDir := filepath.Join(dataDir, "backups")
+	metricsDir := filepath.Join(dataDir, "metrics")
+	snapshotDir := filepath.Join(dataDir, "snapshots")
+
+	// Overlapping runs would interleave their read-modify-write of the histories
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		slog.Error("Creating data directory failed", "dir", dataDir, "err", err)
+		os.Exit(1)
+	}
+	if err := acquireLock(filepath.Join(dataDir, lockFileName), *lockWait); err != nil {
+		slog.Error("Acquiring lock failed", "err", err)
+		os.Exit(1)
+	}
+	defer releaseLock()
+
+	// The flag file only ever describes the latest run
+	if *changedFlagFile != "" {
+		if err := os.Remove(*changedFlagFile); err != nil && !os.IsNotExist(err) {
+			slog.Error("Removing changed flag file failed", "file", *changedFlagFile, "err", err)
+			exit(1)
 		}
+	}
 
-		// Save raw default cards to disk
-		fmt.Println("Saving default cards to cache...")
-		if err := saveRawCards(rawData, oracleFile); err != nil {
-			fmt.Printf("Error saving default cards: %v\n", err)
-			os.Exit(1)
+	if *restore != "" {
+		if err := restoreHistory(*restore, dataDir, *historyFlag, backupDir, *backups); err != nil {
+			slog.Error("Restoring history failed", "err", err)
+			exit(1)
 		}
+		return
+	}
 
-		// Parse for processing
-		if err := json.Unmarshal(rawData, &currentCards); err != nil {
-			fmt.Printf("Error parsing default cards: %v\n", err)
-			os.Exit(1)
+	if *shard {
+		for _, format := range formats {
+			historyFile := newFormatFiles(dataDir, format, games, *historyFlag).History
+			if err := shardHistory(historyFile, backupDir, *backups); err != nil {
+				slog.Error("Sharding history failed", "history", historyFile, "err", err)
+				exit(1)
+			}
 		}
-		fmt.Printf("Downloaded %d cards\n", len(currentCards))
-	} else {
-		// Load cached default cards
-		fmt.Println("Loading cached default cards...")
-		var err error
-		currentCards, err = loadRawCards(oracleFile)
-		if err != nil {
-			fmt.Printf("Error loading cached default cards: %v\n", err)
-			os.Exit(1)
+		return
+	}
+
+	if !*dryRun {
+		if err := os.MkdirAll(resultsDir, 0755); err != nil {
+			slog.Error("Creating output directory failed", "dir", resultsDir, "err", err)
+			exit(1)
 		}
-		fmt.Printf("Loaded %d cards from cache\n", len(currentCards))
 	}
 
-	// Filter for Brawl-legal cards and build oracle_id mapping
-	brawlCards := filterBrawlLegalCards(currentCards)
-	fmt.Printf("Found %d Brawl-legal cards\n", len(brawlCards))
-	
-	// Build oracle_id to best card mapping (prefer Arena)
-	oracleToCard := buildOracleMapping(brawlCards)
-	fmt.Printf("Unique oracle cards: %d\n", len(oracleToCard))
+	// Load each format's history up front; cards recorded in any of them stay in the card cache
+	// even when they lose legality, so the renderer can still show them
+	histories := make(map[string]HistoryData)
+	recorded := make(map[string]bool)
+	recordedPrintings := make(map[string]bool) // Legacy days still to be translated to oracle_ids
+	for _, format := range formats {
+		files := newFormatFiles(dataDir, format, games, *historyFlag)
+		history, err := loadHistory(files.History)
+		if err != nil && *forceInit {
+			// The damaged file is still backed up before it is replaced
+			slog.Warn("Ignoring unreadable history and starting over", "file", files.History, "err", err)
+			history = HistoryData{Days: []DayResult{}}
+		} else if err != nil {
+			slog.Error("Loading history failed, nothing was written. Fix the file, restore a backup with -restore, or start over with -force-init",
+				"file", files.History, "err", err)
+			exit(1)
+		}
+		histories[format] = history
+		for _, day := range history.Days {
+			for _, oracleID := range day.AddedOracles {
+				recorded[oracleID] = true
+			}
+		}
+		for _, cardID := range legacyPrintingIDs(history) {
+			recordedPrintings[cardID] = true
+		}
+	}
 
-	// Load existing history
-	history := loadHistory(historyFile)
+	// Resolve the watchlist while streaming all cards, including ones that aren't legal yet
+	watchlist, err := loadWatchlist(watchlistFile)
+	if err != nil {
+		slog.Error("Loading watchlist failed", "file", watchlistFile, "err", err)
+		exit(1)
+	}
+	watchResolver := newWatchlistResolver(watchlist)
 
-	// Build set of all known oracle_ids from history
-	knownOracles := buildKnownOraclesFromHistory(history)
+	ignoredSets, err := readListFile(ignoreSetsFile)
+	if err == nil {
+		err = parseSetList(exclusions.IgnoredSets, ignoredSets)
+	}
+	if err != nil {
+		slog.Error("Loading ignored sets failed", "file", ignoreSetsFile, "err", err)
+		exit(1)
+	}
+	if len(exclusions.IgnoredSets) > 0 {
+		slog.Info("Ignoring sets", "count", len(exclusions.IgnoredSets))
+	}
 
-	// Check if this is first run (no history or transitioning from old format)
-	if len(history.Days) == 0 || len(knownOracles) == 0 {
-		fmt.Println("First run - initializing with all current oracle cards")
+	// Only cards legal in a tracked format or already recorded are kept; everything else is dropped as it is decoded
+	var cachedCards []Card
+	collectCard := func(card Card) {
+		watchResolver.observe(card)
+		if isLegalInAny(card, formats, legalStatuses) || recorded[card.OracleID] || recordedPrintings[card.ID] {
+			cachedCards = append(cachedCards, card)
+		}
+	}
 
-		// On first run, add all current oracle_ids
-		var addedOracles []string
-		
-		for oracleID := range oracleToCard {
-			addedOracles = append(addedOracles, oracleID)
+	// Ctrl-C or a CI cancellation stops the Scryfall requests; temporary files are removed on the way out
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The bulk-data listing is tiny, so always ask Scryfall whether there is a newer dump than the cache
+	var bulkEntry BulkDataEntry
+	var bulkErr error
+	if *input == "" && !*cacheOnly {
+		slog.Info("Fetching Scryfall bulk data info")
+		bulkEntry, bulkErr = getBulkDataEntry(ctx, client, *bulkType, *retries)
+	}
+
+	shouldDownload := true
+	previousMeta := loadCacheMeta(cacheMetaFile)
+	minCards := previousMeta.minCards(*bulkType, *maxCardDrop)
+	minCachedCards := previousMeta.minCachedCards(*bulkType, formats, legalStatuses, *maxCardDrop)
+	
+	if *input != "" {
+		// A local file replaces Scryfall and the cache altogether
+		shouldDownload = false
+	} else if *cacheOnly {
+		if _, err := os.Stat(cardFilePath(cardCacheFile)); err != nil {
+			slog.Error("No card cache to use with -cache-only", "file", cardCacheFile+".gz", "err", err)
+			exit(1)
+		}
+		if err := previousMeta.verifyCache(cardFilePath(cardCacheFile)); err != nil {
+			slog.Error("Card cache failed verification, nothing to use with -cache-only", "file", cardCacheFile+".gz", "err", err)
+			exit(1)
+		}
+		slog.Info("Using cached legal cards without checking for new bulk data (-cache-only)")
+		shouldDownload = false
+	} else if *forceRefresh {
+		// Minimum card count still comes from the last download, a forced dump is validated like any other
+		slog.Info("Bypassing the card cache, downloading fresh bulk data (-force-refresh)")
+		previousMeta = CacheMeta{}
+	} else if stat, err := os.Stat(cardFilePath(cardCacheFile)); err != nil {
+		// Nothing to reuse, and validators of a deleted cache don't apply anymore
+		previousMeta = CacheMeta{}
+	} else if err := previousMeta.verifyCache(cardFilePath(cardCacheFile)); err != nil {
+		// A damaged cache can't be trusted, not even when Scryfall is unreachable
+		slog.Warn("Card cache failed verification, discarding it and downloading", "file", cardCacheFile+".gz", "err", err)
+		previousMeta = CacheMeta{}
+	} else if bulkErr != nil {
+		slog.Warn("Could not check for new bulk data, using cache", "failure", requestFailure(bulkErr), "err", bulkErr)
+		shouldDownload = false
+	} else if previousMeta.bulkType() != *bulkType {
+		slog.Info("Cache was built from another bulk type, downloading", "cached", previousMeta.bulkType(), "bulk_type", *bulkType)
+		previousMeta = CacheMeta{}
+	} else if !previousMeta.coversFormats(formats) {
+		slog.Info("Cache doesn't cover every format, downloading", "cached", previousMeta.formats(), "formats", formats)
+		previousMeta = CacheMeta{}
+	} else if !previousMeta.coversStatuses(legalStatuses) {
+		slog.Info("Cache doesn't cover every legality status, downloading", "cached", previousMeta.legalStatuses(), "legal_statuses", legalStatuses)
+		previousMeta = CacheMeta{}
+	} else if previousMeta.UpdatedAt != "" {
+		if isNewerBulkData(bulkEntry.UpdatedAt, previousMeta.UpdatedAt) {
+			slog.Info("Scryfall published new bulk data, refreshing", "updated_at", bulkEntry.UpdatedAt, "cached", previousMeta.UpdatedAt)
+		} else {
+			slog.Info("Using cached legal cards, bulk data is current", "updated_at", previousMeta.UpdatedAt)
+			shouldDownload = false
 		}
+	} else {
+		// Without metadata from the last download, fall back to the age of the cache file
+		cacheAge := time.Since(stat.ModTime())
+		if cacheAge < *cacheTTL {
+			slog.Info("Using cached legal cards", "age", cacheAge.Round(time.Minute))
+			shouldDownload = false
+		} else {
+			slog.Info("Cache is too old, refreshing", "age", cacheAge.Round(time.Minute))
+		}
+	}
+	
+	metrics := RunMetrics{StartedAt: time.Now().UTC(), Date: runDate}
 
-		result := DayResult{
-			Date:         time.Now().UTC().Format("2006-01-02"),
-			AddedOracles: addedOracles,
-			TotalCards:   len(oracleToCard),
-			FirstRun:     true,
+	// Metadata of the dump the tracked cards come from, recorded in new entries
+	sourceMeta := previousMeta
+	downloaded := false
+	if shouldDownload {
+		if bulkErr != nil {
+			slog.Error("Getting download URL failed", "failure", requestFailure(bulkErr), "err", bulkErr)
+			exit(1)
 		}
+		downloadURL := bulkEntry.DownloadURI
 
-		// Clear history for fresh start with oracle-based format
-		history.Days = []DayResult{result}
-	} else {
-		// Find new oracle_ids (in current but not in our known set)
-		fmt.Println("Comparing with known oracle cards...")
-		newOracles := findNewOracles(knownOracles, oracleToCard)
+		slog.Info("Downloading bulk data", "url", downloadURL)
+		download := downloadOptions{Attempts: *retries}
+		downloadStart := time.Now()
+		meta, err := downloadCards(ctx, client, downloadURL, downloadFile, previousMeta, download)
+		metrics.DownloadSeconds = time.Since(downloadStart).Seconds()
+		if errors.Is(err, errNotModified) {
+			slog.Info("Bulk data has not changed since the last download, reusing cache")
+			metrics.CardSource = cardSourceNotModified
+			meta.UpdatedAt = bulkEntry.UpdatedAt
+			sourceMeta = meta
+			// A dry run reuses the cache as it is, without marking it fresh
+			if !*dryRun {
+				now := time.Now()
+				os.Chtimes(cardFilePath(cardCacheFile), now, now)
+				if err := saveCacheMeta(meta, cacheMetaFile); err != nil {
+					slog.Warn("Could not save cache metadata", "err", err)
+				}
+			}
+		} else if errors.Is(err, errInvalidBulkData) {
+			slog.Error("Downloaded bulk data failed validation, keeping the previous cache", "err", err)
+			exit(exitInvalidBulkData)
+		} else if err != nil {
+			slog.Error("Downloading cards failed", "failure", requestFailure(err), "err", err)
+			exit(1)
+		} else {
+			// Filter the dump from disk; it only replaces the caches once it has been validated
+			parseStart := time.Now()
+			totalCards, err := loadBulkFile(downloadFile, minCards, collectCard)
+			metrics.ParseSeconds = time.Since(parseStart).Seconds()
+			if err == nil && len(cachedCards) < minCachedCards {
+				err = fmt.Errorf("%w: only %d cards to cache, expected at least %d", errInvalidBulkData, len(cachedCards), minCachedCards)
+			}
+			if err != nil {
+				os.Remove(downloadFile + ".gz")
+				// A distinct exit code lets CI tell a bad download apart from other failures
+				slog.Error("Downloaded bulk data failed validation, keeping the previous cache", "err", err)
+				exit(exitInvalidBulkData)
+			}
 
-		fmt.Printf("Found %d new oracle cards\n", len(newOracles))
+			downloaded = true
+			meta.UpdatedAt = bulkEntry.UpdatedAt
+			meta.BulkType = *bulkType
+			meta.Formats = formats
+			meta.LegalStatuses = recordedStatuses(legalStatuses)
+			meta.CardCount = totalCards
+			sourceMeta = meta
+			slog.Info("Downloaded cards", "count", totalCards, "cached", len(cachedCards))
+			metrics.CardSource = cardSourceDownload
+			metrics.DownloadBytes = meta.DownloadSize
+			metrics.TotalPrintings = totalCards
 
-		// Only add entry if there are new cards or if it's been more than a day since last entry
-		shouldAddEntry := len(newOracles) > 0
+			if *dryRun {
+				// The dry run only needed the cards, the caches stay as they were
+				os.Remove(downloadFile + ".gz")
+			} else {
+				slog.Info("Saving legal cards to cache", "file", cardCacheFile+".gz")
+				if err := saveCardCache(cachedCards, cardCacheFile); err != nil {
+					slog.Error("Saving card cache failed", "err", err)
+					exit(1)
+				}
+				if err := meta.recordCache(cardCacheFile+".gz", len(cachedCards)); err != nil {
+					slog.Warn("Could not checksum card cache", "err", err)
+				}
+				if err := saveCacheMeta(meta, cacheMetaFile); err != nil {
+					slog.Warn("Could not save cache metadata", "err", err)
+				}
 
-		// Also add entry if last entry was yesterday or earlier (to track total count changes)
-		if len(history.Days) > 0 {
-			lastDate := history.Days[len(history.Days)-1].Date
-			today := time.Now().UTC().Format("2006-01-02")
-			if lastDate != today {
-				shouldAddEntry = true
+				if *bulkSnapshots > 0 {
+					snapshot, err := saveBulkSnapshot(downloadFile, snapshotDir, *bulkType, bulkEntry.UpdatedAt, *bulkSnapshots)
+					if err != nil {
+						slog.Warn("Could not save bulk snapshot", "err", err)
+					} else {
+						slog.Info("Saved bulk snapshot", "file", snapshot)
+					}
+				}
+
+				// The dump is only kept when asked for, slimmed down unless every field is wanted
+				if *keepRaw && *fullCache {
+					err = os.Rename(downloadFile+".gz", rawCardsFile+".gz")
+					if err == nil {
+						// Drop the uncompressed dump of older runs
+						os.Remove(rawCardsFile)
+					}
+				} else if *keepRaw {
+					if err := writeSlimBulkFile(downloadFile, rawCardsFile); err != nil {
+						slog.Warn("Could not save bulk dump", "err", err)
+					}
+					err = os.Remove(downloadFile + ".gz")
+				} else {
+					err = os.Remove(downloadFile + ".gz")
+				}
+				if err != nil {
+					slog.Warn("Could not clean up bulk download", "err", err)
+				}
 			}
 		}
+	}
 
-		if shouldAddEntry {
-			var addedOracles []string
-			
-			for _, oracleID := range newOracles {
-				addedOracles = append(addedOracles, oracleID)
+	if *input != "" {
+		slog.Info("Reading local bulk file", "file", *input)
+		parseStart := time.Now()
+		totalCards, err := loadBulkFile(*input, 1, collectCard)
+		metrics.ParseSeconds = time.Since(parseStart).Seconds()
+		if err != nil {
+			slog.Error("Reading local bulk file failed", "file", *input, "err", err)
+			exit(1)
+		}
+		downloaded = true
+		slog.Info("Read cards", "count", totalCards, "cached", len(cachedCards))
+		metrics.CardSource = cardSourceInput
+		metrics.TotalPrintings = totalCards
+
+		source, err := filepath.Abs(*input)
+		if err != nil {
+			source = *input
+		}
+		sourceMeta = CacheMeta{
+			BulkType:      *bulkType,
+			Formats:       formats,
+			LegalStatuses: recordedStatuses(legalStatuses),
+			DownloadURI:   source,
+			CardCount:     totalCards,
+		}
+
+		// Experiments with old or edited dumps must not leak into the cache of regular runs
+		if *updateCache && !*dryRun {
+			slog.Info("Saving legal cards to cache", "file", cardCacheFile+".gz")
+			if err := saveCardCache(cachedCards, cardCacheFile); err != nil {
+				slog.Error("Saving card cache failed", "err", err)
+				exit(1)
 			}
+			if err := sourceMeta.recordCache(cardCacheFile+".gz", len(cachedCards)); err != nil {
+				slog.Warn("Could not checksum card cache", "err", err)
+			}
+			if err := saveCacheMeta(sourceMeta, cacheMetaFile); err != nil {
+				slog.Warn("Could not save cache metadata", "err", err)
+			}
+		}
+	}
 
-			result := DayResult{
-				Date:         time.Now().UTC().Format("2006-01-02"),
-				AddedOracles: addedOracles,
-				TotalCards:   len(oracleToCard),
-				FirstRun:     false,
+	if ctx.Err() != nil {
+		slog.Error("Interrupted, nothing was tracked")
+		exit(1)
+	}
+
+	if !downloaded {
+		// Load cached legal cards
+		slog.Info("Loading cached legal cards")
+		parseStart := time.Now()
+		totalCards, err := loadCards(cardCacheFile, collectCard)
+		if err != nil {
+			slog.Error("Loading card cache failed", "err", err)
+			exit(1)
+		}
+		slog.Info("Loaded cards from cache", "count", totalCards)
+		metrics.ParseSeconds = time.Since(parseStart).Seconds()
+		metrics.CacheUsed = true
+		metrics.TotalPrintings = totalCards
+		if metrics.CardSource == "" {
+			metrics.CardSource = cardSourceCache
+		}
+	}
+
+	if *compareWith != "" {
+		// A bare snapshot name is looked up among the kept snapshots
+		snapshot := *compareWith
+		if _, err := os.Stat(snapshot); os.IsNotExist(err) && filepath.Base(snapshot) == snapshot {
+			snapshot = filepath.Join(snapshotDir, snapshot)
+		}
+		for _, format := range formats {
+			if err := compareWithSnapshot(os.Stdout, snapshot, format, legalStatuses, cachedCards); err != nil {
+				slog.Error("Comparing with snapshot failed", "err", err)
+				exit(1)
 			}
+		}
+		exit(0)
+	}
 
-			// Remove existing entry for today if it exists
-			history = removeEntryForToday(history)
-			history.Days = append(history.Days, result)
+	// Keep the set release calendar used for countdowns on upcoming cards, and the symbols mana costs
+	// are drawn with
+	if !*dryRun && *input == "" && !*cacheOnly {
+		refreshSetCalendar(ctx, client, setCalendarFile)
+		refreshSymbology(ctx, client, symbologyFile)
+	}
 
-			fmt.Printf("Added entry with %d new oracle cards\n", len(newOracles))
+	// Anomalies of the bulk data would otherwise be merged away silently
+	findBulkAnomalies(cachedCards).report()
+
+	watched, unresolved := watchResolver.result()
+	if len(watchlist) > 0 {
+		slog.Info("Watching cards", "count", len(watched))
+	}
+	// The cache only has legal cards, so entries for upcoming cards can only be checked against a download
+	if downloaded {
+		for _, entry := range unresolved {
+			slog.Warn("Watchlist entry does not match any card", "entry", entry)
+		}
+	}
+
+	// Previews are searched once, each format keeps those it doesn't know yet
+	var previews []Card
+	if *previewsFlag {
+		query := previewSearch(*previewQuery, runDate)
+		slog.Info("Searching Scryfall for previewed cards", "query", query)
+		previews, err = searchCards(ctx, client, query)
+		if err != nil {
+			slog.Warn("Skipping previews, search failed", "failure", requestFailure(err), "err", err)
 		} else {
-			fmt.Println("No new oracle cards and already have entry for today")
+			slog.Info("Found previewed cards", "count", len(previews))
 		}
 	}
+	if ctx.Err() != nil {
+		slog.Error("Interrupted, nothing was tracked")
+		exit(1)
+	}
 
-	// Save history
-	if err := saveHistory(history, historyFile); err != nil {
-		fmt.Printf("Error saving history: %v\n", err)
-		os.Exit(1)
+	options := trackOptions{
+		BulkType:    *bulkType,
+		Watched:     watched,
+		Tags:        tags,
+		TagCacheDir: tagCacheDir,
+		TagCacheTTL: *tagCacheTTL,
+		BackupDir:   backupDir,
+		Backups:     *backups,
+		ResultsDir:  resultsDir,
+		DryRun:      *dryRun,
+
+		ExcludeRebalanced: *excludeRebalancedFlag,
+		Games:             games,
+		Date:              runDate,
+		AllowOutOfOrder:   *allowOutOfOrder,
+		LegalStatuses:     legalStatuses,
+		Exclusions:        exclusions,
+		Reconcile:         *reconcile,
+		Client:            client,
+		Source:            newBulkSource(sourceMeta),
+		Previews:          previews,
+	}
+	newCards := 0
+	changed := false
+	for _, format := range formats {
+		files := newFormatFiles(dataDir, format, games, *historyFlag)
+		result, err := trackFormat(ctx, format, cachedCards, histories[format], files, options)
+		if err != nil {
+			slog.Error("Updating history failed", "format", format, "err", err)
+			exit(runExitCode(err, changed, *dryRun, newCards))
+		}
+		newCards += result.NewCards
+		changed = changed || result.Changed
+		metrics.Formats = append(metrics.Formats, FormatMetrics{
+			Format:         format,
+			LegalPrintings: result.LegalCards,
+			UniqueOracles:  result.OracleCards,
+			NewCards:       result.NewCards,
+			Changed:        result.Changed,
+		})
+	}
+	// The tag lookups were the last requests, let signals terminate the process as usual again.
+	// Everything written from here on is replaced atomically.
+	stop()
+
+	slog.Info("Run finished", "new_cards", newCards, "changed", changed, "formats", formats, "date", runDate, "dry_run", *dryRun)
+
+	if !*dryRun {
+		metrics.CachedPrintings = len(cachedCards)
+		metrics.NewCards = newCards
+		metrics.Changed = changed
+		metrics.FinishedAt = time.Now().UTC()
+		if err := writeRunMetrics(metricsDir, metricsMode, metrics); err != nil {
+			slog.Warn("Could not write run metrics", "err", err)
+		}
+	}
+
+	if changed && *changedFlagFile != "" {
+		if err := os.WriteFile(*changedFlagFile, nil, 0644); err != nil {
+			slog.Error("Writing changed flag file failed", "file", *changedFlagFile, "err", err)
+			exit(1)
+		}
 	}
 
-	fmt.Printf("Data updated. History saved to %s\n", historyFile)
+	// Lets scripts skip rendering and deploying when nothing changed, or branch on whether a real run
+	// would add cards
+	exit(runExitCode(nil, changed, *dryRun, newCards))
 }
 
-func getDownloadURL() (string, error) {
-	req, err := http.NewRequest("GET", "https://api.scryfall.com/bulk-data", nil)
-	if err != nil {
-		return "", err
+// envDefault returns the environment variable's value, or fallback when it is unset or empty
+func envDefault(name string, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
 	}
+	return fallback
+}
 
-	req.Header.Set("User-Agent", "BrawlChronicle/1.0")
-	req.Header.Set("Accept", "application/json;q=0.9,*/*;q=0.8")
+// getBulkDataEntry looks up the entry of the given type in Scryfall's bulk data listing
+func getBulkDataEntry(ctx context.Context, client *scryfallClient, bulkType string, attempts int) (BulkDataEntry, error) {
+	req, err := client.newRequest(ctx, client.apiURL("/bulk-data"))
+	if err != nil {
+		return BulkDataEntry{}, err
+	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := doWithRetry(client.httpClient(bulkDataTimeout), req, attempts)
 	if err != nil {
-		return "", err
+		return BulkDataEntry{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		return BulkDataEntry{}, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
 	}
 
 	var bulkInfo BulkDataInfo
 	if err := json.NewDecoder(resp.Body).Decode(&bulkInfo); err != nil {
-		return "", err
+		return BulkDataEntry{}, err
 	}
 
 	for _, data := range bulkInfo.Data {
-		if data.Type == "default_cards" {
-			return data.DownloadURI, nil
+		if data.Type == bulkType {
+			return data, nil
 		}
 	}
 
-	return "", fmt.Errorf("default_cards not found in bulk data")
+	return BulkDataEntry{}, fmt.Errorf("%s not found in bulk data", bulkType)
 }
 
-func downloadCards(url string) ([]byte, error) {
-	req, err := http.NewRequest("GET", url, nil)
+// downloadOptions control how the bulk file is downloaded
+type downloadOptions struct {
+	Attempts int
+}
+
+// downloadCards writes the bulk file to <filename>.gz, compressing it as it arrives without holding it
+// in memory. The file is only replaced once the whole body has been received. The validators in previous
+// make the request conditional; an unchanged file yields errNotModified.
+func downloadCards(ctx context.Context, client *scryfallClient, url string, filename string, previous CacheMeta, options downloadOptions) (CacheMeta, error) {
+	req, err := client.newRequest(ctx, url)
 	if err != nil {
-		return nil, err
+		return CacheMeta{}, err
 	}
 
-	req.Header.Set("User-Agent", "BrawlChronicle/1.0")
-	req.Header.Set("Accept", "application/json;q=0.9,*/*;q=0.8")
+	if previous.ETag != "" {
+		req.Header.Set("If-None-Match", previous.ETag)
+	}
+	if previous.LastModified != "" {
+		req.Header.Set("If-Modified-Since", previous.LastModified)
+	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := doWithRetry(client.httpClient(downloadTimeout), req, options.Attempts)
 	if err != nil {
-		return nil, err
+		return CacheMeta{}, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified {
+		return previous, errNotModified
+	}
+	if resp.StatusCode != http.StatusOK {
+		return CacheMeta{}, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	meta := CacheMeta{
+		DownloadURI:  url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	if err := writeCompressedFile(filename, func(w io.Writer) error {
+		meta.DownloadSize, err = copyBody(resp, w)
+		return err
+	}); err != nil {
+		return CacheMeta{}, err
+	}
+	return meta, nil
+}
+
+// copyBody copies the response body to w, decompressing it when it is gzipped, and returns the bytes received
+func copyBody(resp *http.Response, w io.Writer) (int64, error) {
+	// Progress is counted in bytes on the wire, which is what Content-Length describes
+	progress := newProgressReader(resp.Body, resp.ContentLength)
+	defer progress.summary()
+
 	// Check if content is actually gzipped by looking at Content-Encoding header
-	var reader io.Reader = resp.Body
+	var reader io.Reader = progress
 	
 	if resp.Header.Get("Content-Encoding") == "gzip" {
-		gzipReader, err := gzip.NewReader(resp.Body)
+		gzipReader, err := gzip.NewReader(progress)
 		if err != nil {
-			return nil, err
+			return progress.read, err
 		}
 		defer gzipReader.Close()
 		reader = gzipReader
 	}
 
-	// Read all data as bytes
-	data, err := io.ReadAll(reader)
+	reader, err := checkBulkBody(resp.Header.Get("Content-Type"), reader)
 	if err != nil {
-		return nil, err
+		return progress.read, err
 	}
-
-	return data, nil
+	_, err = io.Copy(w, reader)
+	return progress.read, err
 }
 
-func loadCards(filename string) ([]Card, error) {
-	file, err := os.Open(filename)
+// loadCards streams a cached card list through visit and returns the number of cards read
+func loadCards(filename string, visit func(Card)) (int, error) {
+	file, err := openCardFile(filename)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 	defer file.Close()
 
-	var cards []Card
-	if err := json.NewDecoder(file).Decode(&cards); err != nil {
-		return nil, err
-	}
-
-	return cards, nil
+	return streamCards(file, visit)
 }
 
-func saveRawCards(data []byte, filename string) error {
-	return os.WriteFile(filename, data, 0644)
+// saveCardCache writes the filtered cards the renderer reads, replacing the previous cache only on success
+func saveCardCache(cards []Card, filename string) error {
+	return writeCompressedFile(filename, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(cards)
+	})
 }
 
-func loadRawCards(filename string) ([]Card, error) {
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		return nil, err
+// loadHistory reads a history, monolithic or sharded by year; a missing file is an empty history, an
+// unreadable one or one written by a newer fetcher an error
+func loadHistory(filename string) (HistoryData, error) {
+	var history HistoryData
+	if isSharded(filename) {
+		var err error
+		if history, err = loadShardedHistory(shardDir(filename)); err != nil {
+			return HistoryData{}, err
+		}
+	} else {
+		data, err := os.ReadFile(filename)
+		if os.IsNotExist(err) {
+			return HistoryData{SchemaVersion: historySchemaVersion, Days: []DayResult{}}, nil
+		}
+		if err != nil {
+			return HistoryData{}, err
+		}
+		if err := json.Unmarshal(data, &history); err != nil {
+			return HistoryData{}, describeJSONError(data, err)
+		}
 	}
-
-	var cards []Card
-	if err := json.Unmarshal(data, &cards); err != nil {
-		return nil, err
+	if err := history.Validate(); err != nil {
+		return HistoryData{}, err
 	}
-
-	return cards, nil
+	return history, nil
 }
 
-
-func loadHistory(filename string) HistoryData {
-	file, err := os.Open(filename)
-	if err != nil {
-		return HistoryData{Days: []DayResult{}}
+// describeJSONError adds the position of a syntax or type error, so a damaged file can be fixed by hand
+func describeJSONError(data []byte, err error) error {
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) {
+		offset = syntaxErr.Offset
+	} else if errors.As(err, &typeErr) {
+		offset = typeErr.Offset
+	} else {
+		return err
 	}
-	defer file.Close()
 
-	var history HistoryData
-	json.NewDecoder(file).Decode(&history)
-	return history
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	line := 1 + bytes.Count(data[:offset], []byte("\n"))
+	column := offset - int64(bytes.LastIndexByte(data[:offset], '\n'))
+	return fmt.Errorf("%w (at byte offset %d, line %d, column %d)", err, offset, line, column)
 }
 
+// saveHistory replaces the history atomically, a truncated history would look like a first run.
+// Days are kept in date order, backfilled ones included. A sharded history only rewrites the years that changed.
 func saveHistory(history HistoryData, filename string) error {
-	file, err := os.Create(filename)
-	if err != nil {
+	// A bad entry is refused here rather than breaking the next run or the pages
+	if err := history.Validate(); err != nil {
 		return err
 	}
-	defer file.Close()
+	if isSharded(filename) {
+		return saveShardedHistory(history, shardDir(filename))
+	}
+	return writeFileAtomic(filename, func(w io.Writer) error {
+		return encodeHistory(w, history)
+	})
+}
 
-	encoder := json.NewEncoder(file)
+// encodeHistory writes the history the way saveHistory stores it
+func encodeHistory(w io.Writer, history HistoryData) error {
+	sortDays(history)
+	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
 	return encoder.Encode(history)
 }
 
+// historyChanged reports whether saving the history would change the file. A new entry changes it,
+// even one that only records the day's total; a re-run that finds nothing new on an existing day doesn't.
+func historyChanged(history HistoryData, filename string) (bool, error) {
+	if isSharded(filename) {
+		return shardedHistoryChanged(history, shardDir(filename))
+	}
+	var encoded bytes.Buffer
+	if err := encodeHistory(&encoded, history); err != nil {
+		return false, err
+	}
+	existing, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return !bytes.Equal(existing, encoded.Bytes()), nil
+}
+
 // Build set of all known cards from history (legacy - for old format support)
 func buildKnownCardsFromHistory(history HistoryData) map[string]bool {
 	knownCards := make(map[string]bool)
@@ -329,11 +1041,30 @@ func buildKnownCardsFromHistory(history HistoryData) map[string]bool {
 	return knownCards
 }
 
-// Build oracle_id to best card mapping (prefer Arena)
+// buildCardMapping records the printing chosen for each oracle_id, so the renderer shows the same
+// printing even after reprints
+func buildCardMapping(oracleIDs []string, oracleToCard map[string]Card) map[string]PinnedPrinting {
+	if len(oracleIDs) == 0 {
+		return nil
+	}
+	mapping := make(map[string]PinnedPrinting)
+	for _, oracleID := range oracleIDs {
+		if card, found := oracleToCard[oracleID]; found {
+			mapping[oracleID] = newPinnedPrinting(card)
+		}
+	}
+	return mapping
+}
+
+// Build oracle_id to best card mapping (prefer Arena). Printings without an oracle_id, such as
+// reversible cards, are left out instead of all being merged into one bogus card.
 func buildOracleMapping(cards []Card) map[string]Card {
 	oracleToCard := make(map[string]Card)
 	
 	for _, card := range cards {
+		if card.OracleID == "" {
+			continue
+		}
 		existing, exists := oracleToCard[card.OracleID]
 		if !exists || (hasArenaInFetcher(card.Games) && !hasArenaInFetcher(existing.Games)) {
 			oracleToCard[card.OracleID] = card
@@ -343,16 +1074,21 @@ func buildOracleMapping(cards []Card) map[string]Card {
 	return oracleToCard
 }
 
+// buildKnownOraclesFromHistory replays the days in order, so a card removed and later re-added counts as known again
 func buildKnownOraclesFromHistory(history HistoryData) map[string]bool {
 	known := make(map[string]bool)
+	for _, oracleID := range history.BaselineOracles {
+		known[oracleID] = true
+	}
 	for _, day := range history.Days {
-		// Handle new format (AddedOracles)
-		if day.AddedOracles != nil {
-			for _, oracleID := range day.AddedOracles {
-				known[oracleID] = true
-			}
+		// Legacy days with AddedCards are translated by upgradeHistory first
+		for _, oracleID := range day.AddedOracles {
+			known[oracleID] = true
+		}
+
+		for _, oracleID := range day.RemovedOracles {
+			delete(known, oracleID)
 		}
-		// For old data with AddedCards, we'll treat this as a fresh start
 	}
 	return known
 }
@@ -367,6 +1103,18 @@ func findNewOracles(knownOracles map[string]bool, oracleToCard map[string]Card)
 	return newOracles
 }
 
+// findRemovedOracles returns known oracle_ids that are no longer among the legal cards
+func findRemovedOracles(knownOracles map[string]bool, oracleToCard map[string]Card) []string {
+	var removed []string
+	for oracleID := range knownOracles {
+		if _, found := oracleToCard[oracleID]; !found {
+			removed = append(removed, oracleID)
+		}
+	}
+	sort.Strings(removed)
+	return removed
+}
+
 func hasArenaInFetcher(games []string) bool {
 	for _, game := range games {
 		if game == "arena" {
@@ -376,12 +1124,20 @@ func hasArenaInFetcher(games []string) bool {
 	return false
 }
 
-func removeEntryForToday(history HistoryData) HistoryData {
-	today := time.Now().UTC().Format("2006-01-02")
+func findEntryForDate(history HistoryData, date string) (DayResult, bool) {
+	for _, day := range history.Days {
+		if day.Date == date {
+			return day, true
+		}
+	}
+	return DayResult{}, false
+}
+
+func removeEntryForDate(history HistoryData, date string) HistoryData {
 	var filteredDays []DayResult
 	
 	for _, day := range history.Days {
-		if day.Date != today {
+		if day.Date != date {
 			filteredDays = append(filteredDays, day)
 		}
 	}
@@ -402,15 +1158,29 @@ func findNewCards(knownCards map[string]bool, currentCards []Card) []Card {
 	return newCards
 }
 
-func filterBrawlLegalCards(cards []Card) []Card {
-	var brawlCards []Card
-	
+// isLegalIn reports whether a card has one of the accepted statuses in the given Scryfall format
+func isLegalIn(card Card, format string, statuses []string) bool {
+	legality, exists := card.Legalities[format]
+	return exists && acceptsStatus(statuses, legality)
+}
+
+// isLegalInAny reports whether a card is legal in at least one of the formats
+func isLegalInAny(card Card, formats []string, statuses []string) bool {
+	for _, format := range formats {
+		if isLegalIn(card, format, statuses) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterLegalCards keeps the cards with an accepted status in the given format
+func filterLegalCards(cards []Card, format string, statuses []string) []Card {
+	var legal []Card
 	for _, card := range cards {
-		// Check if card is legal in brawl
-		if legality, exists := card.Legalities["brawl"]; exists && legality == "legal" {
-			brawlCards = append(brawlCards, card)
+		if isLegalIn(card, format, statuses) {
+			legal = append(legal, card)
 		}
 	}
-	
-	return brawlCards
+	return legal
 }
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Values of -metrics
+const (
+	metricsOff     = "off"
+	metricsLatest  = "latest"  // Only <data-dir>/metrics/latest.json
+	metricsHistory = "history" // Also append every run to <data-dir>/metrics/history.jsonl
+)
+
+// Where the cards of a run came from, see RunMetrics.CardSource
+const (
+	cardSourceDownload    = "download"
+	cardSourceNotModified = "not_modified" // Scryfall answered 304, the cache was reused
+	cardSourceCache       = "cache"
+	cardSourceInput       = "input"
+)
+
+// RunMetrics describes one fetcher run, for charting its health over time. It is kept apart from the
+// histories, nothing reads it back, so the metrics directory can be deleted at any time.
+type RunMetrics struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Date       string    `json:"date"` // Date the run recorded its entries under
+
+	CardSource      string  `json:"card_source"`
+	CacheUsed       bool    `json:"cache_used"`
+	DownloadBytes   int64   `json:"download_bytes,omitempty"` // Bytes received, compressed when Scryfall sent gzip
+	DownloadSeconds float64 `json:"download_seconds,omitempty"`
+	ParseSeconds    float64 `json:"parse_seconds"`
+	TotalPrintings  int     `json:"total_printings"`  // Printings read from the bulk file or the cache
+	CachedPrintings int     `json:"cached_printings"` // Printings kept, legal in a tracked format or recorded
+
+	Formats  []FormatMetrics `json:"formats"`
+	NewCards int             `json:"new_cards"`
+	Changed  bool            `json:"changed"`
+}
+
+// FormatMetrics are the counts of one tracked format
+type FormatMetrics struct {
+	Format         string `json:"format"`
+	LegalPrintings int    `json:"legal_printings"`
+	UniqueOracles  int    `json:"unique_oracles"`
+	NewCards       int    `json:"new_cards"`
+	Changed        bool   `json:"changed"`
+}
+
+// parseMetricsMode validates the -metrics flag
+func parseMetricsMode(value string) (string, error) {
+	switch value {
+	case metricsOff, metricsLatest, metricsHistory:
+		return value, nil
+	}
+	return "", fmt.Errorf("invalid metrics mode %q, expected %s, %s or %s", value, metricsLatest, metricsHistory, metricsOff)
+}
+
+// writeRunMetrics replaces <dir>/latest.json with the run's metrics and, in history mode, appends
+// them as one line to <dir>/history.jsonl
+func writeRunMetrics(dir string, mode string, metrics RunMetrics) error {
+	if mode == metricsOff {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(filepath.Join(dir, "latest.json"), func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(metrics)
+	}); err != nil {
+		return err
+	}
+	if mode != metricsHistory {
+		return nil
+	}
+
+	line, err := json.Marshal(metrics)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(filepath.Join(dir, "history.jsonl"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
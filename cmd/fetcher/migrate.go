@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// runMigrate implements "fetcher migrate": it rewrites the legacy added_cards days of a history
+// into the oracle format without running the tracker. The result goes to a new file unless
+// -in-place is given, in which case the original is backed up first.
+func runMigrate(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dataDir := flags.String("data-dir", envDefault("BRAWL_CHRONICLE_DATA_DIR", "data"), "Directory of the card cache and backups (env BRAWL_CHRONICLE_DATA_DIR)")
+	cardsFile := flags.String("cards", "", "Cards to resolve printing ids with (default <data-dir>/brawl-cards.json; the dump kept by -keep-raw resolves more)")
+	outputFile := flags.String("out", "", "File to write the migrated history to (default <history>.migrated.json)")
+	inPlace := flags.Bool("in-place", false, "Replace the history itself, after backing it up")
+	backups := flags.Int("backups", 14, "Number of history backups kept in <data-dir>/backups with -in-place")
+	logging := addLogFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: go run ./cmd/fetcher migrate [flags] <history.json>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if err := logging.setup(); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	if *inPlace && *outputFile != "" {
+		return fmt.Errorf("-out and -in-place can't be combined")
+	}
+
+	// Rewriting the history while a run tracks into it would lose one of the two
+	if *inPlace {
+		if err := acquireLock(filepath.Join(*dataDir, lockFileName), 0); err != nil {
+			return fmt.Errorf("acquiring lock: %w", err)
+		}
+		defer releaseLock()
+	}
+
+	historyFile := flags.Arg(0)
+	history, err := loadHistory(historyFile)
+	if err != nil {
+		return fmt.Errorf("loading history: %w", err)
+	}
+	if history.SchemaVersion == historySchemaVersion && len(legacyPrintingIDs(history)) == 0 {
+		slog.Info("History is up to date, nothing to migrate", "history", historyFile)
+		return nil
+	}
+
+	if *cardsFile == "" {
+		*cardsFile = filepath.Join(*dataDir, "brawl-cards.json")
+	}
+	var cards []Card
+	if _, err := loadCards(*cardsFile, func(card Card) { cards = append(cards, card) }); err != nil {
+		return fmt.Errorf("loading cards: %w", err)
+	}
+
+	history, migration := upgradeHistory(history, cards)
+	slog.Info("Migrated legacy days", "days", migration.Days, "resolved", migration.Resolved,
+		"ambiguous", migration.Ambiguous, "unknown", migration.Unknown)
+	if migration.Ambiguous+migration.Unknown > 0 {
+		slog.Warn("Ids that could not be resolved are kept in unresolved_cards and retried by later runs")
+	}
+
+	target := *outputFile
+	if *inPlace {
+		target = historyFile
+		if err := backupHistory(historyFile, filepath.Join(*dataDir, "backups"), *backups, time.Now()); err != nil {
+			return fmt.Errorf("backing up history: %w", err)
+		}
+	} else if target == "" {
+		target = strings.TrimSuffix(historyFile, ".json") + ".migrated.json"
+	}
+	if err := saveHistory(history, target); err != nil {
+		return fmt.Errorf("saving history: %w", err)
+	}
+
+	slog.Info("Migrated history written", "file", target)
+	return nil
+}
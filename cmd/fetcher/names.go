@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sort"
+)
+
+// NameChange is a known card renamed in the Oracle, e.g. for errata; its oracle_id stays the same
+type NameChange struct {
+	OracleID string `json:"oracle_id"`
+	OldName  string `json:"old_name"`
+	NewName  string `json:"new_name"`
+}
+
+// NamesState is the last-known name of every tracked oracle_id in one format
+type NamesState struct {
+	Names map[string]string `json:"names"`
+}
+
+// buildOracleNames collects the current name of each tracked oracle_id
+func buildOracleNames(oracleToCard map[string]Card) map[string]string {
+	names := make(map[string]string, len(oracleToCard))
+	for oracleID, card := range oracleToCard {
+		names[oracleID] = card.Name
+	}
+	return names
+}
+
+// findRenames compares the names of oracle_ids present in both states
+func findRenames(previous, current map[string]string) []NameChange {
+	var renames []NameChange
+	for oracleID, name := range current {
+		if old, found := previous[oracleID]; found && old != name {
+			renames = append(renames, NameChange{OracleID: oracleID, OldName: old, NewName: name})
+		}
+	}
+	sortRenames(renames)
+	return renames
+}
+
+// mergeRenames combines renames recorded by an earlier run of the day with new ones,
+// keeping the first old name and the last new name of each card and dropping round trips
+func mergeRenames(earlier, later []NameChange) []NameChange {
+	merged := make(map[string]NameChange)
+	for _, rename := range earlier {
+		merged[rename.OracleID] = rename
+	}
+	for _, rename := range later {
+		if first, found := merged[rename.OracleID]; found {
+			rename.OldName = first.OldName
+		}
+		merged[rename.OracleID] = rename
+	}
+
+	var renames []NameChange
+	for _, rename := range merged {
+		if rename.OldName != rename.NewName {
+			renames = append(renames, rename)
+		}
+	}
+	sortRenames(renames)
+	return renames
+}
+
+func sortRenames(renames []NameChange) {
+	sort.Slice(renames, func(i, j int) bool {
+		return renames[i].OracleID < renames[j].OracleID
+	})
+}
+
+// loadNamesState reads the previous names; found is false when there is no state yet
+func loadNamesState(filename string) (map[string]string, bool) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, false
+	}
+
+	var state NamesState
+	if err := json.Unmarshal(data, &state); err != nil {
+		slog.Warn("Ignoring unreadable names state", "file", filename, "err", err)
+		return nil, false
+	}
+	return state.Names, state.Names != nil
+}
+
+func saveNamesState(names map[string]string, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(NamesState{Names: names})
+}
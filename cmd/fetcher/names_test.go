@@ -0,0 +1,55 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindRenames(t *testing.T) {
+	previous := map[string]string{"a": "Old Name", "b": "Opt", "c": "Gone"}
+	current := map[string]string{"a": "New Name", "b": "Opt", "d": "Brand New"}
+	want := []NameChange{{OracleID: "a", OldName: "Old Name", NewName: "New Name"}}
+	if renames := findRenames(previous, current); !reflect.DeepEqual(renames, want) {
+		t.Errorf("findRenames() = %+v, want %+v", renames, want)
+	}
+}
+
+// A card renamed on the day it gets a new printing is a rename of a known card, not a new one
+func TestRenameWithNewPrinting(t *testing.T) {
+	dir := t.TempDir()
+	original := testCard("a")
+	original.Name = "Lurrus of the Dream Den"
+	runTrackCards(t, dir, "2024-05-01", bulkTypeDefaultCards, []Card{original, testCard("b")})
+
+	renamed := original
+	renamed.Name = "Lurrus of the Dream-Den"
+	reprint := renamed
+	reprint.ID = "printing-a-reprint"
+	reprint.Set = "new"
+	reprint.ReleasedAt = "2024-05-02"
+	result, history := runTrackCards(t, dir, "2024-05-02", bulkTypeDefaultCards, []Card{renamed, reprint, testCard("b")})
+
+	if !result.Changed || result.NewCards != 0 {
+		t.Errorf("run = %+v, want a changed history without new cards", result)
+	}
+	if len(history.Days) != 2 {
+		t.Fatalf("history has %d days, want 2", len(history.Days))
+	}
+	today := history.Days[1]
+	if len(today.AddedOracles) != 0 {
+		t.Errorf("added %v, want nothing", today.AddedOracles)
+	}
+	want := []NameChange{{OracleID: "a", OldName: "Lurrus of the Dream Den", NewName: "Lurrus of the Dream-Den"}}
+	if !reflect.DeepEqual(today.Renamed, want) {
+		t.Errorf("renamed = %+v, want %+v", today.Renamed, want)
+	}
+
+	// The next run knows the new name
+	result, history = runTrackCards(t, dir, "2024-05-03", bulkTypeDefaultCards, []Card{renamed, reprint, testCard("b")})
+	if len(history.Days) > 2 && len(history.Days[2].Renamed) > 0 {
+		t.Errorf("the rename is reported again: %+v", history.Days[2].Renamed)
+	}
+	if result.NewCards != 0 {
+		t.Errorf("next run = %+v, want no new cards", result)
+	}
+}
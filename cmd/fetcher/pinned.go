@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+)
+
+// PinnedPrinting is the printing recorded for an added card in a day's card_mapping. Besides the id it
+// keeps what links to the printing and orders it among reprints without the card cache. Histories
+// before schema version 3 stored the bare printing id, which still decodes.
+type PinnedPrinting struct {
+	ID              string `json:"id"`
+	Set             string `json:"set,omitempty"`
+	CollectorNumber string `json:"collector_number,omitempty"`
+	ReleasedAt      string `json:"released_at,omitempty"`
+}
+
+func newPinnedPrinting(card Card) PinnedPrinting {
+	return PinnedPrinting{
+		ID:              card.ID,
+		Set:             card.Set,
+		CollectorNumber: card.CollectorNumber,
+		ReleasedAt:      card.ReleasedAt,
+	}
+}
+
+// UnmarshalJSON accepts both the object and the bare printing id of older histories
+func (pinned *PinnedPrinting) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var id string
+		if err := json.Unmarshal(data, &id); err != nil {
+			return err
+		}
+		*pinned = PinnedPrinting{ID: id}
+		return nil
+	}
+
+	// Without its methods the type decodes as a plain struct instead of recursing
+	type plain PinnedPrinting
+	return json.Unmarshal(data, (*plain)(pinned))
+}
+
+// complete reports whether the printing has every detail, so older entries are only looked up until they do
+func (pinned PinnedPrinting) complete() bool {
+	return pinned.Set != "" && pinned.CollectorNumber != "" && pinned.ReleasedAt != ""
+}
+
+// fillPinnedPrintings completes printings pinned with missing details, e.g. bare ids of older histories,
+// from the cached printing with the same id. Printings no longer cached keep what they have.
+func fillPinnedPrintings(history HistoryData, cards []Card) int {
+	incomplete := false
+	for _, day := range history.Days {
+		for _, pinned := range day.CardMapping {
+			if !pinned.complete() {
+				incomplete = true
+			}
+		}
+	}
+	if !incomplete {
+		return 0
+	}
+
+	printings := make(map[string]Card)
+	for _, card := range cards {
+		printings[card.ID] = card
+	}
+
+	filled := 0
+	for _, day := range history.Days {
+		for oracleID, pinned := range day.CardMapping {
+			card, found := printings[pinned.ID]
+			if pinned.complete() || !found {
+				continue
+			}
+			updated := newPinnedPrinting(card)
+			if updated != pinned {
+				day.CardMapping[oracleID] = updated
+				filled++
+			}
+		}
+	}
+	return filled
+}
+
+// scryfallCardURL links to a printing's page by set and collector number, falling back to the id for
+// printings from caches written before collector numbers were kept
+func scryfallCardURL(id string, set string, collectorNumber string) string {
+	if set == "" || collectorNumber == "" {
+		return "https://scryfall.com/card/" + id
+	}
+	return "https://scryfall.com/card/" + url.PathEscape(set) + "/" + url.PathEscape(collectorNumber)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// defaultPreviewQuery finds printings that release after the run date, {date} is replaced with it
+const defaultPreviewQuery = "date>{date}"
+
+// PreviewCard is a card previewed on Scryfall before it became legal. The renderer has no cache
+// entry for it yet, so the entry keeps what is needed to show it.
+type PreviewCard struct {
+	OracleID   string `json:"oracle_id"`
+	ID         string `json:"id"` // previewed printing
+	Name       string `json:"name"`
+	Set        string `json:"set,omitempty"`
+	SetName    string `json:"set_name,omitempty"`
+	ReleasedAt string `json:"released_at,omitempty"`
+	ImageURL   string `json:"image_url,omitempty"`
+
+	CollectorNumber string `json:"collector_number,omitempty"`
+}
+
+// previewSearch fills the run date into a -preview-query
+func previewSearch(query string, date string) string {
+	return strings.ReplaceAll(query, "{date}", date)
+}
+
+// newPreviewCard keeps the display fields of a previewed printing
+func newPreviewCard(card Card) PreviewCard {
+	imageURL := card.ImageURIs["normal"]
+	if imageURL == "" && len(card.CardFaces) > 0 {
+		imageURL = card.CardFaces[0].ImageURIs["normal"]
+	}
+	return PreviewCard{
+		OracleID:   card.OracleID,
+		ID:         card.ID,
+		Name:       card.Name,
+		Set:        card.Set,
+		SetName:    card.SetName,
+		ReleasedAt: card.ReleasedAt,
+		ImageURL:   imageURL,
+
+		CollectorNumber: card.CollectorNumber,
+	}
+}
+
+// buildKnownPreviews collects the oracle_ids previewed on any day of the history
+func buildKnownPreviews(history HistoryData) map[string]bool {
+	known := make(map[string]bool)
+	for _, day := range history.Days {
+		for _, preview := range day.Previews {
+			known[preview.OracleID] = true
+		}
+	}
+	return known
+}
+
+// findNewPreviews returns the previewed cards that are neither legal, known nor previewed before,
+// limited to printings in games when it is set, sorted by name
+func findNewPreviews(previews []Card, games string, knownOracles map[string]bool, knownPreviews map[string]bool, oracleToCard map[string]Card) []PreviewCard {
+	if games != "" {
+		previews = filterGameCards(previews, games)
+	}
+
+	seen := make(map[string]bool)
+	var found []PreviewCard
+	for _, card := range previews {
+		oracleID := card.OracleID
+		if oracleID == "" || seen[oracleID] || knownOracles[oracleID] || knownPreviews[oracleID] {
+			continue
+		}
+		if _, legal := oracleToCard[oracleID]; legal {
+			continue
+		}
+		seen[oracleID] = true
+		found = append(found, newPreviewCard(card))
+	}
+	sortPreviews(found)
+	return found
+}
+
+// mergePreviews adds a run's previews to those recorded earlier in the day, dropping cards that are legal by now
+func mergePreviews(earlier, later []PreviewCard, oracleToCard map[string]Card) []PreviewCard {
+	seen := make(map[string]bool)
+	var merged []PreviewCard
+	for _, preview := range append(append([]PreviewCard(nil), earlier...), later...) {
+		if seen[preview.OracleID] {
+			continue
+		}
+		seen[preview.OracleID] = true
+		if _, legal := oracleToCard[preview.OracleID]; legal {
+			continue
+		}
+		merged = append(merged, preview)
+	}
+	sortPreviews(merged)
+	return merged
+}
+
+func sortPreviews(previews []PreviewCard) {
+	sort.Slice(previews, func(i, j int) bool {
+		if previews[i].Name != previews[j].Name {
+			return previews[i].Name < previews[j].Name
+		}
+		return previews[i].OracleID < previews[j].OracleID
+	})
+}
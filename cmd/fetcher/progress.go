@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// progressInterval is how many downloaded bytes pass between progress lines
+const progressInterval = 25 << 20
+
+// progressReader counts the bytes read from a download and logs progress every progressInterval
+type progressReader struct {
+	reader     io.Reader
+	total      int64 // Content-Length, -1 when unknown
+	read       int64
+	nextReport int64
+	start      time.Time
+}
+
+func newProgressReader(reader io.Reader, total int64) *progressReader {
+	return &progressReader{
+		reader:     reader,
+		total:      total,
+		nextReport: progressInterval,
+		start:      time.Now(),
+	}
+}
+
+func (p *progressReader) Read(buf []byte) (int, error) {
+	n, err := p.reader.Read(buf)
+	p.read += int64(n)
+	if p.read >= p.nextReport {
+		p.report()
+		for p.nextReport <= p.read {
+			p.nextReport += progressInterval
+		}
+	}
+	return n, err
+}
+
+// report logs the bytes downloaded so far, with the percentage when the size is known
+func (p *progressReader) report() {
+	elapsed := time.Since(p.start)
+	args := []any{"downloaded", formatBytes(p.read), "elapsed", elapsed.Round(time.Second), "rate", formatBytes(p.rate(elapsed)) + "/s"}
+	if p.total > 0 {
+		args = append(args, "size", formatBytes(p.total), "percent", p.read*100/p.total)
+	}
+	slog.Info("Download progress", args...)
+}
+
+// summary logs the final size and duration of the download, also with -quiet
+func (p *progressReader) summary() {
+	elapsed := time.Since(p.start)
+	summaryLogger.Info("Download finished", "downloaded", formatBytes(p.read), "bytes", p.read,
+		"elapsed", elapsed.Round(100*time.Millisecond), "rate", formatBytes(p.rate(elapsed))+"/s")
+}
+
+// rate returns the average bytes per second
+func (p *progressReader) rate(elapsed time.Duration) int64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return int64(float64(p.read) / elapsed.Seconds())
+}
+
+// formatBytes renders a byte count in megabytes, or kilobytes for small amounts
+func formatBytes(n int64) string {
+	if n < 1<<20 {
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"runtime/debug"
+)
+
+// BulkSource identifies the Scryfall dump and the fetcher build that produced a day's entry
+type BulkSource struct {
+	BulkType       string `json:"bulk_type,omitempty"`
+	UpdatedAt      string `json:"updated_at,omitempty"` // updated_at of the bulk-data entry
+	DownloadURI    string `json:"download_uri,omitempty"`
+	Printings      int    `json:"printings,omitempty"` // cards in the whole bulk file, not just the legal ones
+	FetcherVersion string `json:"fetcher_version,omitempty"`
+}
+
+// newBulkSource describes the dump the cache metadata was recorded for. Caches from before the
+// metadata existed only yield the fetcher version.
+func newBulkSource(meta CacheMeta) *BulkSource {
+	source := BulkSource{
+		UpdatedAt:      meta.UpdatedAt,
+		DownloadURI:    meta.DownloadURI,
+		Printings:      meta.CardCount,
+		FetcherVersion: fetcherVersion(),
+	}
+	if meta.DownloadURI != "" {
+		source.BulkType = meta.bulkType()
+	}
+	if source == (BulkSource{}) {
+		return nil
+	}
+	return &source
+}
+
+// fetcherVersion returns the module version or VCS revision the binary was built from, empty when
+// unknown (e.g. with go run, which doesn't stamp VCS information)
+func fetcherVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+
+	var revision string
+	dirty := false
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			dirty = setting.Value == "true"
+		}
+	}
+	if revision != "" {
+		if len(revision) > 12 {
+			revision = revision[:12]
+		}
+		if dirty {
+			revision += "-dirty"
+		}
+		return revision
+	}
+
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return ""
+}
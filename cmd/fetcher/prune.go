@@ -0,0 +1,121 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// runPrune implements "fetcher prune": it moves the days before a date out of a history into an
+// archive, which the renderer includes with -archive. The oracle_ids known from the moved days are
+// kept in the history's baseline_oracles, so the next run doesn't report them as new again.
+func runPrune(args []string) error {
+	flags := flag.NewFlagSet("prune", flag.ExitOnError)
+	dataDir := flags.String("data-dir", envDefault("BRAWL_CHRONICLE_DATA_DIR", "data"), "Directory of the history and backups (env BRAWL_CHRONICLE_DATA_DIR)")
+	historyFlag := flags.String("history", "", "History to prune (default <data-dir>/history.json)")
+	before := flags.String("before", "", "Move the days before this date (YYYY-MM-DD) to the archive")
+	archiveFlag := flags.String("archive", "", "Archive the days are added to (default <history>.archive.json)")
+	backups := flags.Int("backups", 14, "Number of history backups kept in <data-dir>/backups")
+	logging := addLogFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: go run ./cmd/fetcher prune -before <YYYY-MM-DD> [flags]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if err := logging.setup(); err != nil {
+		return err
+	}
+	if *before == "" || flags.NArg() != 0 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	if _, err := time.Parse("2006-01-02", *before); err != nil {
+		return fmt.Errorf("invalid -before %q, expected YYYY-MM-DD", *before)
+	}
+
+	historyFile := *historyFlag
+	if historyFile == "" {
+		historyFile = filepath.Join(*dataDir, "history.json")
+	}
+	archiveFile := *archiveFlag
+	if archiveFile == "" {
+		archiveFile = strings.TrimSuffix(historyFile, ".json") + ".archive.json"
+	}
+
+	if err := acquireLock(filepath.Join(*dataDir, lockFileName), 0); err != nil {
+		return fmt.Errorf("acquiring lock: %w", err)
+	}
+	defer releaseLock()
+
+	history, err := loadHistory(historyFile)
+	if err != nil {
+		return fmt.Errorf("loading history: %w", err)
+	}
+	sortDays(history)
+	var pruned, kept []DayResult
+	for _, day := range history.Days {
+		if day.Date < *before {
+			pruned = append(pruned, day)
+		} else {
+			kept = append(kept, day)
+		}
+	}
+	if len(pruned) == 0 {
+		slog.Info("No days before the date, nothing to prune", "history", historyFile, "before", *before)
+		return nil
+	}
+	if len(kept) == 0 {
+		// Without a day the next run would take the history for a first run
+		return fmt.Errorf("every day of %s is before %s, the latest day must stay", historyFile, *before)
+	}
+	prunedHistory := HistoryData{SchemaVersion: history.SchemaVersion, BaselineOracles: history.BaselineOracles, Days: pruned}
+	if ids := legacyPrintingIDs(prunedHistory); len(ids) > 0 {
+		return fmt.Errorf("the days to prune still have %d legacy printing ids, run migrate first", len(ids))
+	}
+
+	archive, err := loadHistory(archiveFile)
+	if err != nil {
+		return fmt.Errorf("loading archive: %w", err)
+	}
+	if len(archive.Days) > 0 && archive.Meta.Format != history.Meta.Format {
+		return fmt.Errorf("%s archives %s, not %s", archiveFile, archive.Meta.Format, history.Meta.Format)
+	}
+	if len(archive.Days) == 0 {
+		// The archive starts where the history did
+		archive.BaselineOracles = history.BaselineOracles
+	}
+	archive.SchemaVersion = historySchemaVersion
+	archive.Meta = history.Meta
+	archive.Days = append(archive.Days, pruned...)
+
+	var baseline []string
+	for oracleID := range buildKnownOraclesFromHistory(prunedHistory) {
+		baseline = append(baseline, oracleID)
+	}
+	sort.Strings(baseline)
+	history.SchemaVersion = historySchemaVersion
+	history.BaselineOracles = baseline
+	history.Days = kept
+
+	// The archive is written first: if saving the history fails, the days are in both files
+	// instead of neither, and the duplicate dates are refused until one copy is removed
+	if err := saveHistory(archive, archiveFile); err != nil {
+		return fmt.Errorf("saving archive: %w", err)
+	}
+	if err := backupHistory(historyFile, filepath.Join(*dataDir, "backups"), *backups, time.Now()); err != nil {
+		return fmt.Errorf("backing up history: %w", err)
+	}
+	if err := saveHistory(history, historyFile); err != nil {
+		return fmt.Errorf("saving history: %w", err)
+	}
+
+	slog.Info("Pruned history", "history", historyFile, "archive", archiveFile, "moved", len(pruned),
+		"kept", len(kept), "baseline_oracles", len(baseline))
+	return nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// isRebalanced reports whether a card is an Alchemy rebalanced version of another card,
+// e.g. "A-Lier, Disciple of the Drowned"
+func isRebalanced(card Card) bool {
+	if strings.HasPrefix(card.Name, "A-") {
+		return true
+	}
+	for _, promoType := range card.PromoTypes {
+		if promoType == "rebalanced" {
+			return true
+		}
+	}
+	return false
+}
+
+// excludeRebalanced drops rebalanced cards and returns the remaining cards and the dropped oracle_ids
+func excludeRebalanced(cards []Card) ([]Card, map[string]bool) {
+	var kept []Card
+	excluded := make(map[string]bool)
+	for _, card := range cards {
+		if isRebalanced(card) {
+			slog.Debug("Skipping rebalanced card", "name", card.Name, "card_id", card.ID)
+			excluded[card.OracleID] = true
+			continue
+		}
+		kept = append(kept, card)
+	}
+	return kept, excluded
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// rebalancedFixture mixes regular cards with Alchemy rebalances, by name and by promo type
+func rebalancedFixture() []Card {
+	lier := testCard("lier")
+	lier.Name = "Lier, Disciple of the Drowned"
+	rebalancedLier := testCard("a-lier")
+	rebalancedLier.Name = "A-Lier, Disciple of the Drowned"
+	rebalancedLier.Games = []string{"arena"}
+	promo := testCard("promo-rebalanced")
+	promo.Name = "Town-razer Tyrant"
+	promo.PromoTypes = []string{"rebalanced"}
+	promo.Games = []string{"arena"}
+	// Names that merely contain "A-" are not rebalances
+	dash := testCard("dash")
+	dash.Name = "Ghired's Belligerence // A-Side"
+	alpha := testCard("alpha")
+	alpha.Name = "Akroma, Angel of Wrath"
+	return []Card{lier, rebalancedLier, promo, dash, alpha}
+}
+
+func TestExcludeRebalanced(t *testing.T) {
+	kept, excluded := excludeRebalanced(rebalancedFixture())
+
+	var keptOracles []string
+	for _, card := range kept {
+		keptOracles = append(keptOracles, card.OracleID)
+	}
+	if want := []string{"lier", "dash", "alpha"}; !reflect.DeepEqual(keptOracles, want) {
+		t.Errorf("kept %v, want %v", keptOracles, want)
+	}
+	if want := map[string]bool{"a-lier": true, "promo-rebalanced": true}; !reflect.DeepEqual(excluded, want) {
+		t.Errorf("excluded %v, want %v", excluded, want)
+	}
+}
+
+func TestTrackExcludesRebalanced(t *testing.T) {
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+	dir := t.TempDir()
+	files := newFormatFiles(dir, "brawl", "", "")
+	track := func(date string, cards []Card, exclude bool) HistoryData {
+		history, err := loadHistory(files.History)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = trackFormat(context.Background(), "brawl", cards, history, files, trackOptions{
+			BulkType:          bulkTypeDefaultCards,
+			ResultsDir:        filepath.Join(dir, "results"),
+			Date:              date,
+			LegalStatuses:     defaultLegalStatuses,
+			ExcludeRebalanced: exclude,
+		})
+		if err != nil {
+			t.Fatalf("tracking %s: %v", date, err)
+		}
+		saved, err := loadHistory(files.History)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return saved
+	}
+
+	// The baseline has the original cards only
+	history := track("2024-05-01", rebalancedFixture(), true)
+	baseline := append([]string{}, history.Days[0].AddedOracles...)
+	sort.Strings(baseline)
+	if want := []string{"alpha", "dash", "lier"}; !reflect.DeepEqual(baseline, want) {
+		t.Errorf("baseline = %v, want %v", baseline, want)
+	}
+
+	// A new rebalance batch adds nothing, a new regular card is added
+	cards := append(rebalancedFixture(), testCard("new"))
+	batch := testCard("a-new")
+	batch.Name = "A-New Card"
+	cards = append(cards, batch)
+	history = track("2024-05-02", cards, true)
+	today := history.Days[len(history.Days)-1]
+	if want := []string{"new"}; !reflect.DeepEqual(today.AddedOracles, want) {
+		t.Errorf("added %v, want %v", today.AddedOracles, want)
+	}
+	if len(today.RemovedOracles) > 0 {
+		t.Errorf("rebalanced cards reported as removed: %v", today.RemovedOracles)
+	}
+
+	// Turning the filter off lets the rebalances in as additions
+	history = track("2024-05-03", cards, false)
+	added := append([]string{}, history.Days[len(history.Days)-1].AddedOracles...)
+	sort.Strings(added)
+	if want := []string{"a-lier", "a-new", "promo-rebalanced"}; !reflect.DeepEqual(added, want) {
+		t.Errorf("added without the filter %v, want %v", added, want)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// First retry waits about this long, doubling with each attempt
+	retryBaseDelay = 2 * time.Second
+
+	// Upper bound of the computed backoff between attempts
+	retryMaxDelay = time.Minute
+
+	// Upper bound of a wait asked for by Retry-After, so a bogus header can't stall the run
+	retryAfterMaxDelay = 5 * time.Minute
+)
+
+// doWithRetry sends a bodyless request, retrying connection errors, 5xx and 429 responses with
+// exponential backoff and jitter, or after the Retry-After delay when the server gives one (capped).
+// Any other response is returned to the caller as is.
+func doWithRetry(client *http.Client, req *http.Request, attempts int) (*http.Response, error) {
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		resp, err := client.Do(req)
+		var wait time.Duration
+		if err != nil {
+			lastErr = err
+			// An interrupted run should stop, not retry
+			if req.Context().Err() != nil {
+				return nil, err
+			}
+		} else if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			lastErr = fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+			wait = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+			if wait > retryAfterMaxDelay {
+				wait = retryAfterMaxDelay
+			}
+			resp.Body.Close()
+		} else {
+			return resp, nil
+		}
+
+		if attempt >= attempts {
+			return nil, fmt.Errorf("giving up after %d attempts: %w", attempt, lastErr)
+		}
+
+		if wait <= 0 {
+			wait = backoffDelay(attempt)
+		}
+		if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+			slog.Warn("Rate limited by Scryfall, backing off", "attempt", attempt, "attempts", attempts, "url", req.URL.String(), "wait", wait.Round(100*time.Millisecond))
+		} else {
+			slog.Warn("Request "+requestFailure(lastErr)+", retrying", "attempt", attempt, "attempts", attempts, "url", req.URL.String(), "err", lastErr, "wait", wait.Round(100*time.Millisecond))
+		}
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// backoffDelay doubles the base delay per attempt and keeps a random half of it as jitter
+func backoffDelay(attempt int) time.Duration {
+	delay := retryBaseDelay << (attempt - 1)
+	if delay <= 0 || delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date; zero or less means
+// absent, invalid or already past
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return date.Sub(now)
+	}
+	return 0
+}
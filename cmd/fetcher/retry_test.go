@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{"seconds", "120", 2 * time.Minute},
+		{"zero seconds", "0", 0},
+		{"HTTP date", now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{"past HTTP date", now.Add(-time.Minute).Format(http.TimeFormat), -time.Minute},
+		{"absent", "", 0},
+		{"invalid", "soon", 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// The header goes through a real response, as Scryfall sends it
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if test.value != "" {
+					w.Header().Set("Retry-After", test.value)
+				}
+				w.WriteHeader(http.StatusTooManyRequests)
+			}))
+			defer server.Close()
+
+			resp, err := http.Get(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if got := parseRetryAfter(resp.Header.Get("Retry-After"), now); got != test.want {
+				t.Errorf("parseRetryAfter(%q) = %v, want %v", test.value, got, test.want)
+			}
+		})
+	}
+}
+
+func TestDoWithRetryWaitsForRetryAfter(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest("GET", server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	resp, err := doWithRetry(server.Client(), req, 3)
+	if err != nil {
+		t.Fatalf("doWithRetry() = %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || requests.Load() != 2 {
+		t.Errorf("got HTTP %d after %d requests, want 200 after 2", resp.StatusCode, requests.Load())
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want the second of Retry-After", elapsed)
+	}
+}
+
+func TestDoWithRetryGivesUp(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest("GET", server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := doWithRetry(server.Client(), req, 1); err == nil {
+		t.Fatal("doWithRetry() succeeded against a server that always rate limits")
+	}
+	if requests.Load() != 1 {
+		t.Errorf("sent %d requests, want 1", requests.Load())
+	}
+}
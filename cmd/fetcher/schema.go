@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// historySchemaVersion is the version of the history format this fetcher writes as schema_version:
+//
+//	0 (absent) days may list printing ids in added_cards instead of oracle ids in added_oracles
+//	1          every day lists oracle ids in added_oracles
+//	2          days may pin the printing shown per card in card_mapping and keep legacy printing
+//	           ids that couldn't be translated in unresolved_cards
+//	3          card_mapping pins an object with the printing's id, set, collector_number and
+//	           released_at instead of the bare id
+//	4          baseline_oracles lists the oracle ids known from days moved to an archive
+const historySchemaVersion = 4
+
+// upgradeHistory brings a history to historySchemaVersion one version at a time. cards translate the
+// printing ids of version 0; ids still unresolved from an earlier upgrade are retried on every call,
+// like the details of pinned printings recorded before version 3.
+func upgradeHistory(history HistoryData, cards []Card) (HistoryData, legacyMigration) {
+	var migration legacyMigration
+	if history.SchemaVersion < 1 {
+		history, migration = migrateLegacyDays(history, cards)
+	} else {
+		history, migration = retryUnresolvedCards(history, cards)
+	}
+	// Version 2 only added optional fields, days without them are still valid. Version 3 bare ids
+	// decode as printings without details, filled in while the printing is cached.
+	migration.Pinned = fillPinnedPrintings(history, cards)
+	history.SchemaVersion = historySchemaVersion
+	return history, migration
+}
+
+// Validate checks what the fetcher relies on beyond the JSON itself: the history isn't written by a
+// newer fetcher, whose changes this one would silently drop, every day's date is a YYYY-MM-DD date and
+// no date has two entries. The order of the days doesn't matter, saving sorts them.
+func (history HistoryData) Validate() error {
+	if history.SchemaVersion > historySchemaVersion {
+		return fmt.Errorf("schema_version %d is newer than the %d this fetcher understands, update it", history.SchemaVersion, historySchemaVersion)
+	}
+	seen := make(map[string]int)
+	for i, day := range history.Days {
+		if _, err := time.Parse("2006-01-02", day.Date); err != nil {
+			return fmt.Errorf("day %d: date %q is not YYYY-MM-DD", i+1, day.Date)
+		}
+		if first, found := seen[day.Date]; found {
+			return fmt.Errorf("date %s has two entries, days %d and %d", day.Date, first, i+1)
+		}
+		seen[day.Date] = i + 1
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// schemaCards are the cached printings the schema fixtures name
+var schemaCards = []Card{
+	{ID: "p-a", OracleID: "a", Name: "Alpha", Set: "dom", CollectorNumber: "1", ReleasedAt: "2018-04-27"},
+	{ID: "p-a2", OracleID: "a", Name: "Alpha", Set: "m19", CollectorNumber: "7", ReleasedAt: "2018-07-13"},
+	{ID: "p-b", OracleID: "b", Name: "Beta", Set: "dom", CollectorNumber: "2", ReleasedAt: "2018-04-27"},
+	{ID: "p-c", OracleID: "c", Name: "Gamma", Set: "m19", CollectorNumber: "3", ReleasedAt: "2018-07-13"},
+}
+
+// decodeHistory reads a history as loadHistory does
+func decodeHistory(t *testing.T, data string) HistoryData {
+	t.Helper()
+	var history HistoryData
+	if err := json.Unmarshal([]byte(data), &history); err != nil {
+		t.Fatal(err)
+	}
+	if err := history.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	return history
+}
+
+// checkUpgrade upgrades a history and compares its days with want
+func checkUpgrade(t *testing.T, data string, want []DayResult) (HistoryData, legacyMigration) {
+	t.Helper()
+	history, migration := upgradeHistory(decodeHistory(t, data), schemaCards)
+	if history.SchemaVersion != historySchemaVersion {
+		t.Errorf("schema_version = %d after the upgrade, want %d", history.SchemaVersion, historySchemaVersion)
+	}
+	if !reflect.DeepEqual(history.Days, want) {
+		t.Errorf("upgraded days = %+v\nwant %+v", history.Days, want)
+	}
+	return history, migration
+}
+
+// Version 0 days list printing ids, which become oracle ids with the printing pinned
+func TestUpgradeHistoryFromV0(t *testing.T) {
+	_, migration := checkUpgrade(t, `{"days": [
+		{"date": "2024-05-01", "first_run": true, "total_cards": 2, "added_cards": ["p-a", "p-b"]},
+		{"date": "2024-05-02", "total_cards": 3, "added_cards": ["p-a2", "p-c", "p-gone"]}
+	]}`, []DayResult{
+		{Date: "2024-05-01", FirstRun: true, TotalCards: 2, AddedOracles: []string{"a", "b"}},
+		{
+			Date: "2024-05-02", TotalCards: 3, AddedOracles: []string{"c"},
+			CardMapping:     map[string]PinnedPrinting{"c": {ID: "p-c", Set: "m19", CollectorNumber: "3", ReleasedAt: "2018-07-13"}},
+			UnresolvedCards: []string{"p-gone"},
+		},
+	})
+	want := legacyMigration{Days: 2, Resolved: 4, Unknown: 1}
+	if migration != want {
+		t.Errorf("migration = %+v, want %+v", migration, want)
+	}
+}
+
+// Version 1 days already list oracle ids, only the version changes
+func TestUpgradeHistoryFromV1(t *testing.T) {
+	_, migration := checkUpgrade(t, `{"schema_version": 1, "days": [
+		{"date": "2024-05-01", "first_run": true, "total_cards": 1, "added_oracles": ["a"]},
+		{"date": "2024-05-02", "total_cards": 2, "added_oracles": ["b"]}
+	]}`, []DayResult{
+		{Date: "2024-05-01", FirstRun: true, TotalCards: 1, AddedOracles: []string{"a"}},
+		{Date: "2024-05-02", TotalCards: 2, AddedOracles: []string{"b"}},
+	})
+	if migration != (legacyMigration{}) {
+		t.Errorf("migration = %+v, want nothing done", migration)
+	}
+}
+
+// Version 2 pinned bare printing ids, which get their details, and kept printing ids it couldn't
+// translate, which are retried
+func TestUpgradeHistoryFromV2(t *testing.T) {
+	_, migration := checkUpgrade(t, `{"schema_version": 2, "days": [
+		{"date": "2024-05-01", "first_run": true, "total_cards": 1, "added_oracles": ["a"]},
+		{"date": "2024-05-02", "total_cards": 2, "added_oracles": ["b"], "card_mapping": {"b": "p-b"}},
+		{"date": "2024-05-03", "total_cards": 3, "added_oracles": [], "unresolved_cards": ["p-c", "p-gone"]}
+	]}`, []DayResult{
+		{Date: "2024-05-01", FirstRun: true, TotalCards: 1, AddedOracles: []string{"a"}},
+		{
+			Date: "2024-05-02", TotalCards: 2, AddedOracles: []string{"b"},
+			CardMapping: map[string]PinnedPrinting{"b": {ID: "p-b", Set: "dom", CollectorNumber: "2", ReleasedAt: "2018-04-27"}},
+		},
+		{
+			Date: "2024-05-03", TotalCards: 3, AddedOracles: []string{"c"},
+			CardMapping:     map[string]PinnedPrinting{"c": {ID: "p-c", Set: "m19", CollectorNumber: "3", ReleasedAt: "2018-07-13"}},
+			UnresolvedCards: []string{"p-gone"},
+		},
+	})
+	want := legacyMigration{Resolved: 1, Unknown: 1, Pinned: 1}
+	if migration != want {
+		t.Errorf("migration = %+v, want %+v", migration, want)
+	}
+}
+
+// Version 3 pins printings with their details; those of printings no longer cached stay as they are
+func TestUpgradeHistoryFromV3(t *testing.T) {
+	_, migration := checkUpgrade(t, `{"schema_version": 3, "days": [
+		{"date": "2024-05-01", "first_run": true, "total_cards": 1, "added_oracles": ["a"]},
+		{"date": "2024-05-02", "total_cards": 3, "added_oracles": ["b", "d"], "card_mapping": {
+			"b": {"id": "p-b", "set": "dom", "collector_number": "2", "released_at": "2018-04-27"},
+			"d": {"id": "p-gone"}
+		}}
+	]}`, []DayResult{
+		{Date: "2024-05-01", FirstRun: true, TotalCards: 1, AddedOracles: []string{"a"}},
+		{
+			Date: "2024-05-02", TotalCards: 3, AddedOracles: []string{"b", "d"},
+			CardMapping: map[string]PinnedPrinting{
+				"b": {ID: "p-b", Set: "dom", CollectorNumber: "2", ReleasedAt: "2018-04-27"},
+				"d": {ID: "p-gone"},
+			},
+		},
+	})
+	if migration != (legacyMigration{}) {
+		t.Errorf("migration = %+v, want nothing done", migration)
+	}
+}
+
+// Version 4 keeps the oracle ids of archived days in baseline_oracles
+func TestUpgradeHistoryFromV4(t *testing.T) {
+	history, _ := checkUpgrade(t, `{"schema_version": 4, "baseline_oracles": ["a", "b"], "days": [
+		{"date": "2024-06-01", "total_cards": 3, "added_oracles": ["c"]}
+	]}`, []DayResult{
+		{Date: "2024-06-01", TotalCards: 3, AddedOracles: []string{"c"}},
+	})
+	if want := []string{"a", "b"}; !reflect.DeepEqual(history.BaselineOracles, want) {
+		t.Errorf("baseline_oracles = %v, want %v", history.BaselineOracles, want)
+	}
+}
+
+func TestValidateRejectsNewerSchema(t *testing.T) {
+	history := HistoryData{SchemaVersion: historySchemaVersion + 1, Days: []DayResult{{Date: "2024-05-01"}}}
+	err := history.Validate()
+	if err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("Validate() = %v, want the newer schema_version refused", err)
+	}
+
+	history.SchemaVersion = historySchemaVersion
+	if err := history.Validate(); err != nil {
+		t.Errorf("Validate() = %v for the current schema_version", err)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+// setCalendarTTL is how long the cached set release calendar is considered fresh
+const setCalendarTTL = 24 * time.Hour
+
+// SetInfo is a set in the release calendar
+type SetInfo struct {
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	ReleasedAt string `json:"released_at"`
+	SetType    string `json:"set_type"`
+}
+
+// SetCalendar is the cached content of data/sets.json
+type SetCalendar struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Sets      []SetInfo `json:"sets"`
+}
+
+// refreshSetCalendar downloads Scryfall's set list when the cached calendar is missing or stale.
+// A failed refresh keeps the previous calendar, which is only used for display.
+func refreshSetCalendar(ctx context.Context, client *scryfallClient, filename string) {
+	if data, err := os.ReadFile(filename); err == nil {
+		var cached SetCalendar
+		if err := json.Unmarshal(data, &cached); err == nil && time.Since(cached.FetchedAt) < setCalendarTTL {
+			slog.Info("Using cached set calendar", "age", time.Since(cached.FetchedAt).Round(time.Minute))
+			return
+		}
+	}
+
+	slog.Info("Fetching Scryfall set calendar")
+	sets, err := fetchSets(ctx, client)
+	if err != nil {
+		slog.Warn("Could not refresh set calendar", "err", err)
+		return
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		slog.Warn("Could not save set calendar", "err", err)
+		return
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(SetCalendar{FetchedAt: time.Now().UTC(), Sets: sets}); err != nil {
+		slog.Warn("Could not save set calendar", "err", err)
+		return
+	}
+	slog.Info("Saved set calendar", "sets", len(sets), "file", filename)
+}
+
+func fetchSets(ctx context.Context, client *scryfallClient) ([]SetInfo, error) {
+	req, err := client.newRequest(ctx, client.apiURL("/sets"))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.httpClient(apiTimeout).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	var list struct {
+		Data []SetInfo `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, err
+	}
+	return list.Data, nil
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// historyIndexName is the file of a sharded history that holds everything but the days
+const historyIndexName = "index.json"
+
+// shardNamePattern matches the yearly shards of a sharded history, e.g. 2025.json
+var shardNamePattern = regexp.MustCompile(`^\d{4}\.json$`)
+
+// HistoryIndex is the index of a sharded history: data/history.json becomes data/history/index.json
+// next to one shard per year, so a daily run only rewrites the current year
+type HistoryIndex struct {
+	SchemaVersion   int         `json:"schema_version"`
+	Meta            HistoryMeta `json:"meta"`
+	BaselineOracles []string    `json:"baseline_oracles,omitempty"`
+	Shards          []string    `json:"shards"` // Shard file names in date order
+}
+
+// HistoryShard holds the days of one year of a sharded history
+type HistoryShard struct {
+	Year string      `json:"year"`
+	Days []DayResult `json:"days"`
+}
+
+// shardDir is the directory a history file is sharded into, its name without .json
+func shardDir(historyFile string) string {
+	return strings.TrimSuffix(historyFile, ".json")
+}
+
+// isSharded reports whether the history is kept as shards; once it is, the monolithic file is not read
+func isSharded(historyFile string) bool {
+	_, err := os.Stat(filepath.Join(shardDir(historyFile), historyIndexName))
+	return err == nil
+}
+
+// loadShardedHistory reads the index and every shard it lists into one history
+func loadShardedHistory(dir string) (HistoryData, error) {
+	indexFile := filepath.Join(dir, historyIndexName)
+	data, err := os.ReadFile(indexFile)
+	if err != nil {
+		return HistoryData{}, err
+	}
+	var index HistoryIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return HistoryData{}, fmt.Errorf("%s: %w", indexFile, describeJSONError(data, err))
+	}
+
+	history := HistoryData{SchemaVersion: index.SchemaVersion, Meta: index.Meta, BaselineOracles: index.BaselineOracles, Days: []DayResult{}}
+	for _, name := range index.Shards {
+		if !shardNamePattern.MatchString(name) {
+			return HistoryData{}, fmt.Errorf("%s lists invalid shard %q", indexFile, name)
+		}
+		shardFile := filepath.Join(dir, name)
+		data, err := os.ReadFile(shardFile)
+		if err != nil {
+			return HistoryData{}, err
+		}
+		var shard HistoryShard
+		if err := json.Unmarshal(data, &shard); err != nil {
+			return HistoryData{}, fmt.Errorf("%s: %w", shardFile, describeJSONError(data, err))
+		}
+		for _, day := range shard.Days {
+			if !strings.HasPrefix(day.Date, shard.Year+"-") {
+				return HistoryData{}, fmt.Errorf("%s holds %s, which is not in %s", shardFile, day.Date, shard.Year)
+			}
+		}
+		history.Days = append(history.Days, shard.Days...)
+	}
+	return history, nil
+}
+
+// encodeShards returns the content of every file of the sharded history by name, the index included,
+// encoded like encodeHistory so unchanged years compare equal to what is on disk
+func encodeShards(history HistoryData) (map[string][]byte, error) {
+	sortDays(history)
+	years := make(map[string][]DayResult)
+	index := HistoryIndex{SchemaVersion: history.SchemaVersion, Meta: history.Meta, BaselineOracles: history.BaselineOracles, Shards: []string{}}
+	for _, day := range history.Days {
+		year := day.Date[:4]
+		if _, found := years[year]; !found {
+			index.Shards = append(index.Shards, year+".json")
+		}
+		years[year] = append(years[year], day)
+	}
+
+	files := make(map[string][]byte)
+	encode := func(name string, value any) error {
+		var buffer bytes.Buffer
+		encoder := json.NewEncoder(&buffer)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(value); err != nil {
+			return err
+		}
+		files[name] = buffer.Bytes()
+		return nil
+	}
+	for year, days := range years {
+		if err := encode(year+".json", HistoryShard{Year: year, Days: days}); err != nil {
+			return nil, err
+		}
+	}
+	if err := encode(historyIndexName, index); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// changedShards returns the names of the files whose content differs from what is in dir, and the
+// shards in dir that no longer hold any day
+func changedShards(files map[string][]byte, dir string) (changed []string, stale []string, err error) {
+	for name, content := range files {
+		existing, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, nil, err
+		}
+		if err != nil || !bytes.Equal(existing, content) {
+			changed = append(changed, name)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+	for _, entry := range entries {
+		if _, found := files[entry.Name()]; !found && !entry.IsDir() && shardNamePattern.MatchString(entry.Name()) {
+			stale = append(stale, entry.Name())
+		}
+	}
+	return changed, stale, nil
+}
+
+// saveShardedHistory rewrites only the shards that changed, usually just the current year. The index
+// is written last, so an interrupted save leaves the previous index pointing at complete shards.
+func saveShardedHistory(history HistoryData, dir string) error {
+	files, err := encodeShards(history)
+	if err != nil {
+		return err
+	}
+	changed, stale, err := changedShards(files, dir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	writeIndex := false
+	for _, name := range changed {
+		if name == historyIndexName {
+			writeIndex = true
+			continue
+		}
+		if err := writeShardFile(filepath.Join(dir, name), files[name]); err != nil {
+			return err
+		}
+	}
+	if writeIndex {
+		if err := writeShardFile(filepath.Join(dir, historyIndexName), files[historyIndexName]); err != nil {
+			return err
+		}
+	}
+	for _, name := range stale {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeShardFile(filename string, content []byte) error {
+	return writeFileAtomic(filename, func(w io.Writer) error {
+		_, err := w.Write(content)
+		return err
+	})
+}
+
+// shardedHistoryChanged reports whether saving the history would change any file of the sharded history
+func shardedHistoryChanged(history HistoryData, dir string) (bool, error) {
+	files, err := encodeShards(history)
+	if err != nil {
+		return false, err
+	}
+	changed, stale, err := changedShards(files, dir)
+	if err != nil {
+		return false, err
+	}
+	return len(changed) > 0 || len(stale) > 0, nil
+}
+
+// shardHistory moves a monolithic history into yearly shards for -shard. The monolithic file is
+// backed up and removed, so the two layouts never disagree.
+func shardHistory(historyFile string, backupDir string, keep int) error {
+	if isSharded(historyFile) {
+		slog.Info("History is already sharded", "history", shardDir(historyFile))
+		return nil
+	}
+	if _, err := os.Stat(historyFile); os.IsNotExist(err) {
+		slog.Info("No history to shard", "history", historyFile)
+		return nil
+	}
+
+	history, err := loadHistory(historyFile)
+	if err != nil {
+		return err
+	}
+	if err := backupHistory(historyFile, backupDir, keep, time.Now()); err != nil {
+		return fmt.Errorf("backing up history: %w", err)
+	}
+	if err := saveShardedHistory(history, shardDir(historyFile)); err != nil {
+		return err
+	}
+	if err := os.Remove(historyFile); err != nil {
+		return err
+	}
+
+	years := make(map[string]bool)
+	for _, day := range history.Days {
+		years[day.Date[:4]] = true
+	}
+	slog.Info("Sharded history", "history", historyFile, "into", shardDir(historyFile),
+		"days", len(history.Days), "shards", len(years))
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DaySnapshot is the result file of a day: the full cards added that day, so later tools don't
+// depend on the card still being in the ever-changing bulk data
+type DaySnapshot struct {
+	Date   string `json:"date"`
+	Format string `json:"format"`
+	Cards  []Card `json:"cards"`
+}
+
+// snapshotFile returns the result file of a day: <results>/<date>.json for Brawl and
+// <results>/<track name>/<date>.json for other formats and single-game modes, see trackName
+func snapshotFile(resultsDir string, format string, games string, date string) string {
+	return filepath.Join(resultsDir, trackName(format, games), date+".json")
+}
+
+// saveDaySnapshot writes the printings recorded for a day's added cards. It is only called for
+// today's entry, so a later run replaces today's file and never touches earlier ones.
+func saveDaySnapshot(resultsDir string, format string, games string, day DayResult, oracleToCard map[string]Card) error {
+	snapshot := DaySnapshot{Date: day.Date, Format: format, Cards: []Card{}}
+	for _, oracleID := range day.AddedOracles {
+		if card, found := oracleToCard[oracleID]; found {
+			snapshot.Cards = append(snapshot.Cards, card)
+		}
+	}
+	sort.Slice(snapshot.Cards, func(i, j int) bool {
+		return snapshot.Cards[i].Name < snapshot.Cards[j].Name
+	})
+
+	filename := snapshotFile(resultsDir, format, games, day.Date)
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(snapshot)
+	})
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// stepSummaryLimit is the size GitHub Actions allows a step summary to grow to
+const stepSummaryLimit = 1 << 20
+
+// summaryTruncationReserve leaves room for the "... and N more" line of a truncated summary
+const summaryTruncationReserve = 64
+
+// writeRunSummary appends a Markdown summary of a format's run to the file named by $GITHUB_STEP_SUMMARY,
+// where GitHub Actions shows it on the run page, or prints it when the variable is unset, e.g. locally
+func writeRunSummary(format string, games string, entry *DayResult, date string, total int, newOracles []string, oracleToCard map[string]Card) error {
+	filename := os.Getenv("GITHUB_STEP_SUMMARY")
+	budget := stepSummaryLimit
+	if filename != "" {
+		// Earlier steps and formats share the limit
+		if stat, err := os.Stat(filename); err == nil {
+			budget -= int(stat.Size())
+		}
+	}
+
+	firstRun := entry != nil && entry.FirstRun
+	summary := formatRunSummary(format, games, date, total, firstRun, newOracles, oracleToCard, budget)
+	if filename == "" {
+		fmt.Print(summary)
+		return nil
+	}
+	if len(summary) > budget {
+		return fmt.Errorf("step summary is full")
+	}
+
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(summary); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// formatRunSummary renders the summary, listing the new cards by name until budget bytes are used up
+func formatRunSummary(format string, games string, date string, total int, firstRun bool, newOracles []string, oracleToCard map[string]Card, budget int) string {
+	var b strings.Builder
+	title := format
+	if games != "" {
+		title += " (" + games + " only)"
+	}
+	fmt.Fprintf(&b, "## %s - %s\n\n", title, date)
+	fmt.Fprintf(&b, "- Legal oracle cards: %d\n", total)
+	if firstRun {
+		b.WriteString("- First run: recorded a baseline, its cards are not new\n\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "- New cards: %d\n\n", len(newOracles))
+
+	cards := make([]Card, 0, len(newOracles))
+	for _, oracleID := range newOracles {
+		cards = append(cards, oracleToCard[oracleID])
+	}
+	sort.Slice(cards, func(i, j int) bool {
+		return cards[i].Name < cards[j].Name
+	})
+
+	for i, card := range cards {
+		line := fmt.Sprintf("- [%s](%s)\n", escapeMarkdownLinkText(card.Name), scryfallCardURL(card.ID, card.Set, card.CollectorNumber))
+		if b.Len()+len(line)+summaryTruncationReserve > budget {
+			fmt.Fprintf(&b, "- ... and %d more\n", len(cards)-i)
+			break
+		}
+		b.WriteString(line)
+	}
+	if len(cards) > 0 {
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// escapeMarkdownLinkText keeps brackets in card names from ending the link text
+func escapeMarkdownLinkText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`).Replace(text)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+// symbologyTTL is how long the cached card symbols are considered fresh; new symbols are rare
+const symbologyTTL = 7 * 24 * time.Hour
+
+// CardSymbol is a mana or card symbol such as "{W/U}" with the address of its image
+type CardSymbol struct {
+	Symbol  string `json:"symbol"`
+	SVGURI  string `json:"svg_uri"`
+	English string `json:"english"`
+}
+
+// Symbology is the cached content of data/symbology.json, read by the renderer to draw mana costs
+type Symbology struct {
+	FetchedAt time.Time    `json:"fetched_at"`
+	Symbols   []CardSymbol `json:"symbols"`
+}
+
+// refreshSymbology downloads Scryfall's card symbols when the cache is missing or stale. A failed
+// refresh keeps the previous cache, which is only used for display.
+func refreshSymbology(ctx context.Context, client *scryfallClient, filename string) {
+	if data, err := os.ReadFile(filename); err == nil {
+		var cached Symbology
+		if err := json.Unmarshal(data, &cached); err == nil && time.Since(cached.FetchedAt) < symbologyTTL {
+			slog.Info("Using cached card symbols", "age", time.Since(cached.FetchedAt).Round(time.Minute))
+			return
+		}
+	}
+
+	slog.Info("Fetching Scryfall card symbols")
+	symbols, err := fetchSymbology(ctx, client)
+	if err != nil {
+		slog.Warn("Could not refresh card symbols", "err", err)
+		return
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		slog.Warn("Could not save card symbols", "err", err)
+		return
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(Symbology{FetchedAt: time.Now().UTC(), Symbols: symbols}); err != nil {
+		slog.Warn("Could not save card symbols", "err", err)
+		return
+	}
+	slog.Info("Saved card symbols", "symbols", len(symbols), "file", filename)
+}
+
+func fetchSymbology(ctx context.Context, client *scryfallClient) ([]CardSymbol, error) {
+	req, err := client.newRequest(ctx, client.apiURL("/symbology"))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.httpClient(apiTimeout).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	var list struct {
+		Data []CardSymbol `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, err
+	}
+	return list.Data, nil
+}
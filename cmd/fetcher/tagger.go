@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// Scryfall asks for 50-100ms between API requests
+	scryfallRequestDelay = 100 * time.Millisecond
+
+	// Number of oracle ids combined into a single search query
+	tagQueryBatchSize = 20
+
+	// Attempts per search page before the tag is skipped
+	tagSearchAttempts = 3
+)
+
+// tagNamePattern matches valid Scryfall Tagger tag names such as "removal" or "card-advantage"
+var tagNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// lastScryfallRequest is used to space out search API requests
+var lastScryfallRequest time.Time
+
+// rateLimitWait waits out the rest of scryfallRequestDelay before a search request; tests replace it
+var rateLimitWait = time.Sleep
+
+// SearchPage is a single page of Scryfall search results
+type SearchPage struct {
+	Object   string `json:"object"`
+	HasMore  bool   `json:"has_more"`
+	NextPage string `json:"next_page"`
+	Data     []Card `json:"data"`
+}
+
+// cachedTagResult is the on-disk cache entry of one tag query
+type cachedTagResult struct {
+	Query     string    `json:"query"`
+	FetchedAt time.Time `json:"fetched_at"`
+	OracleIDs []string  `json:"oracle_ids"`
+}
+
+// parseTagList splits and validates the -tags flag
+func parseTagList(value string) ([]string, error) {
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+		if !tagNamePattern.MatchString(tag) {
+			return nil, fmt.Errorf("invalid tag %q", tag)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// fetchOracleTags looks up which of the given oracle ids carry each tag.
+// Failures only skip the affected tag so an unavailable tagger never breaks the run.
+func fetchOracleTags(ctx context.Context, client *scryfallClient, tags []string, oracleIDs []string, cacheDir string, ttl time.Duration) map[string][]string {
+	oracleTags := make(map[string][]string)
+	if len(tags) == 0 || len(oracleIDs) == 0 {
+		return oracleTags
+	}
+
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		slog.Warn("Skipping tags, cannot create tag cache", "err", err)
+		return oracleTags
+	}
+
+	sorted := make([]string, len(oracleIDs))
+	copy(sorted, oracleIDs)
+	sort.Strings(sorted)
+
+	for _, tag := range tags {
+		matched, err := fetchTagMatches(ctx, client, tag, sorted, cacheDir, ttl)
+		if err != nil {
+			slog.Warn("Skipping tag", "tag", tag, "err", err)
+			continue
+		}
+		for _, oracleID := range matched {
+			oracleTags[oracleID] = append(oracleTags[oracleID], tag)
+		}
+		slog.Info("Tag matched new cards", "tag", tag, "count", len(matched))
+	}
+
+	return oracleTags
+}
+
+// fetchTagMatches queries "otag:<tag>" restricted to the given oracle ids in batches
+func fetchTagMatches(ctx context.Context, client *scryfallClient, tag string, oracleIDs []string, cacheDir string, ttl time.Duration) ([]string, error) {
+	var matched []string
+	for start := 0; start < len(oracleIDs); start += tagQueryBatchSize {
+		end := start + tagQueryBatchSize
+		if end > len(oracleIDs) {
+			end = len(oracleIDs)
+		}
+
+		var terms []string
+		for _, oracleID := range oracleIDs[start:end] {
+			terms = append(terms, "oracleid:"+oracleID)
+		}
+		query := fmt.Sprintf("otag:%s (%s)", tag, strings.Join(terms, " or "))
+
+		ids, err := searchOracleIDsCached(ctx, client, query, cacheDir, ttl)
+		if err != nil {
+			return nil, err
+		}
+		matched = append(matched, ids...)
+	}
+	return matched, nil
+}
+
+// searchOracleIDsCached returns the oracle ids matching a search query, using the disk cache when fresh
+func searchOracleIDsCached(ctx context.Context, client *scryfallClient, query string, cacheDir string, ttl time.Duration) ([]string, error) {
+	sum := sha1.Sum([]byte(query))
+	cacheFile := filepath.Join(cacheDir, hex.EncodeToString(sum[:])+".json")
+
+	if data, err := os.ReadFile(cacheFile); err == nil {
+		var cached cachedTagResult
+		if err := json.Unmarshal(data, &cached); err == nil && cached.Query == query && time.Since(cached.FetchedAt) < ttl {
+			return cached.OracleIDs, nil
+		}
+	}
+
+	oracleIDs, err := searchOracleIDs(ctx, client, query)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(cachedTagResult{Query: query, FetchedAt: time.Now().UTC(), OracleIDs: oracleIDs})
+	if err == nil {
+		if err := os.WriteFile(cacheFile, data, 0644); err != nil {
+			slog.Warn("Could not cache tag query", "err", err)
+		}
+	}
+
+	return oracleIDs, nil
+}
+
+// searchOracleIDs runs a Scryfall search and returns the oracle ids of the matching cards
+func searchOracleIDs(ctx context.Context, client *scryfallClient, query string) ([]string, error) {
+	cards, err := searchCards(ctx, client, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var oracleIDs []string
+	for _, card := range cards {
+		oracleIDs = append(oracleIDs, card.OracleID)
+	}
+	return oracleIDs, nil
+}
+
+// searchCards runs a Scryfall search, one printing per card, and follows pagination
+func searchCards(ctx context.Context, client *scryfallClient, query string) ([]Card, error) {
+	next := client.apiURL("/cards/search?unique=cards&q=" + url.QueryEscape(query))
+	var cards []Card
+
+	for next != "" {
+		page, err := getSearchPage(ctx, client, next)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, page.Data...)
+
+		next = ""
+		if page.HasMore {
+			next = page.NextPage
+		}
+	}
+
+	return cards, nil
+}
+
+func getSearchPage(ctx context.Context, client *scryfallClient, pageURL string) (SearchPage, error) {
+	// Rate limit: keep the requested delay between consecutive API calls
+	if wait := scryfallRequestDelay - time.Since(lastScryfallRequest); wait > 0 {
+		rateLimitWait(wait)
+	}
+	lastScryfallRequest = time.Now()
+
+	req, err := client.newRequest(ctx, pageURL)
+	if err != nil {
+		return SearchPage{}, err
+	}
+
+	// Searches are the requests most likely to be rate limited, back off on 429 like the bulk requests
+	resp, err := doWithRetry(client.httpClient(apiTimeout), req, tagSearchAttempts)
+	if err != nil {
+		return SearchPage{}, err
+	}
+	defer resp.Body.Close()
+
+	// Scryfall answers 404 when a search has no results
+	if resp.StatusCode == http.StatusNotFound {
+		return SearchPage{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return SearchPage{}, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	var page SearchPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return SearchPage{}, err
+	}
+	return page, nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSearchCardsFollowsPages(t *testing.T) {
+	var server *httptest.Server
+	var requested []string
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Query().Get("page"))
+		if r.URL.Path != "/cards/search" || r.URL.Query().Get("q") != "otag:removal" || r.URL.Query().Get("unique") != "cards" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		var page SearchPage
+		switch r.URL.Query().Get("page") {
+		case "":
+			page = SearchPage{HasMore: true, NextPage: server.URL + "/cards/search?unique=cards&q=otag%3Aremoval&page=2", Data: []Card{testCard("a"), testCard("b")}}
+		case "2":
+			page = SearchPage{HasMore: true, NextPage: server.URL + "/cards/search?unique=cards&q=otag%3Aremoval&page=3", Data: []Card{testCard("c")}}
+		case "3":
+			// The last page still names a next page, which has_more says not to follow
+			page = SearchPage{HasMore: false, NextPage: server.URL + "/cards/search?unique=cards&q=otag%3Aremoval&page=4", Data: []Card{testCard("d")}}
+		default:
+			t.Errorf("requested page %s after the last one", r.URL.Query().Get("page"))
+			http.NotFound(w, r)
+			return
+		}
+		page.Object = "list"
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer server.Close()
+
+	// Waits are recorded instead of slept, so consecutive pages ask for almost the whole delay
+	var waits []time.Duration
+	defaultWait, defaultLast := rateLimitWait, lastScryfallRequest
+	t.Cleanup(func() { rateLimitWait, lastScryfallRequest = defaultWait, defaultLast })
+	rateLimitWait = func(wait time.Duration) { waits = append(waits, wait) }
+	lastScryfallRequest = time.Time{}
+
+	client, err := newScryfallClient(server.URL, "brawl-chronicle-test", http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cards, err := searchCards(context.Background(), client, "otag:removal")
+	if err != nil {
+		t.Fatalf("searchCards() = %v", err)
+	}
+
+	var oracleIDs []string
+	for _, card := range cards {
+		oracleIDs = append(oracleIDs, card.OracleID)
+	}
+	if want := []string{"a", "b", "c", "d"}; !reflect.DeepEqual(oracleIDs, want) {
+		t.Errorf("merged cards %v, want %v", oracleIDs, want)
+	}
+	if want := []string{"", "2", "3"}; !reflect.DeepEqual(requested, want) {
+		t.Errorf("requested pages %q, want %q", requested, want)
+	}
+	// The first request doesn't wait, the two that follow it right away do
+	if len(waits) != 2 {
+		t.Fatalf("waited %d times, want 2: %v", len(waits), waits)
+	}
+	for _, wait := range waits {
+		if wait <= 0 || wait > scryfallRequestDelay {
+			t.Errorf("waited %v, want up to %v", wait, scryfallRequestDelay)
+		}
+	}
+}
+
+// Scryfall answers a search without results with 404
+func TestSearchCardsNoResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"object":"error","code":"not_found","status":404}`))
+	}))
+	defer server.Close()
+	defaultWait := rateLimitWait
+	t.Cleanup(func() { rateLimitWait = defaultWait })
+	rateLimitWait = func(time.Duration) {}
+
+	client, err := newScryfallClient(server.URL, "brawl-chronicle-test", http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cards, err := searchCards(context.Background(), client, "otag:nothing")
+	if err != nil || len(cards) != 0 {
+		t.Errorf("searchCards() = %v, %v; want no cards", cards, err)
+	}
+}
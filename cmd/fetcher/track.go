@@ -0,0 +1,526 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// formatNamePattern matches Scryfall legality keys such as "brawl" or "paupercommander"
+var formatNamePattern = regexp.MustCompile(`^[a-z]+$`)
+
+// trackOptions are the settings shared by every tracked format
+type trackOptions struct {
+	BulkType    string
+	Watched     map[string]string // oracle_id -> watchlist entry
+	Tags        []string
+	TagCacheDir string
+	TagCacheTTL time.Duration
+	BackupDir   string
+	ResultsDir  string // Daily snapshots of the added cards
+	DryRun      bool   // Report the entry instead of saving anything
+	Backups     int    // history backups to keep, 0 disables them
+
+	ExcludeRebalanced bool        // Leave Alchemy rebalanced cards out of the diff
+	Games             string      // Only count printings available in this game, all when empty
+	Date              string      // Date the run is recorded under, today unless backfilling
+	AllowOutOfOrder   bool        // Allow a Date before the latest entry
+	Source            *BulkSource // Dump the cards come from, recorded in new entries
+	Previews          []Card      // Cards found by the -previews search, nil when not tracked
+	LegalStatuses     []string    // Legality statuses a card is tracked with, sorted
+	Exclusions        cardExclusions
+	Reconcile         bool // Report known cards the exclusions now leave out as removed
+	Client            *scryfallClient
+}
+
+// trackResult is what tracking one format did
+type trackResult struct {
+	NewCards    int
+	Changed     bool // The history file was modified
+	LegalCards  int  // Printings legal in the format after filtering
+	OracleCards int  // Unique oracle_ids among them
+}
+
+// trackedGames are the values of -games, which limits tracking to the printings available in one game
+var trackedGames = []string{"arena", "paper", "mtgo"}
+
+// trackName identifies what a history tracks in file and directory names: empty for Brawl in any
+// game, which keeps the original names, otherwise the format and game, e.g. "standard" or "brawl-arena"
+func trackName(format string, games string) string {
+	if games != "" {
+		return format + "-" + games
+	}
+	if format == "brawl" {
+		return ""
+	}
+	return format
+}
+
+// formatFiles are the state files kept separately for each tracked format
+type formatFiles struct {
+	History       string
+	GamesState    string
+	LegalityState string
+	NamesState    string
+}
+
+// newFormatFiles returns the state files of a format, see trackName. Brawl keeps the original
+// file names so existing timelines continue; brawlHistory may point elsewhere with -history.
+func newFormatFiles(dataDir string, format string, games string, brawlHistory string) formatFiles {
+	suffix := ""
+	if name := trackName(format, games); name != "" {
+		suffix = "-" + name
+	}
+	files := formatFiles{
+		History:       filepath.Join(dataDir, "history"+suffix+".json"),
+		GamesState:    filepath.Join(dataDir, "games-state"+suffix+".json"),
+		LegalityState: filepath.Join(dataDir, "legality-state"+suffix+".json"),
+		NamesState:    filepath.Join(dataDir, "names-state"+suffix+".json"),
+	}
+	if format == "brawl" && brawlHistory != "" {
+		files.History = brawlHistory
+	}
+	return files
+}
+
+// parseFormatList splits and validates the -formats flag
+func parseFormatList(value string) ([]string, error) {
+	var formats []string
+	seen := make(map[string]bool)
+	for _, format := range strings.Split(value, ",") {
+		format = strings.ToLower(strings.TrimSpace(format))
+		if format == "" || seen[format] {
+			continue
+		}
+		if !formatNamePattern.MatchString(format) {
+			return nil, fmt.Errorf("invalid format %q", format)
+		}
+		seen[format] = true
+		formats = append(formats, format)
+	}
+	if len(formats) == 0 {
+		return nil, fmt.Errorf("no formats to track")
+	}
+	return formats, nil
+}
+
+// trackFormat diffs the cards legal in a format against the format's history and saves the updated history.
+// cachedCards also holds cards that aren't legal in the format, which only matter for legality changes.
+func trackFormat(ctx context.Context, format string, cachedCards []Card, history HistoryData, files formatFiles, options trackOptions) (trackResult, error) {
+	logger := slog.With("format", format)
+
+	// Rebalanced cards are left out before diffing, so they are neither added nor reported as removed
+	trackedCards := cachedCards
+	var rebalanced map[string]bool
+	if options.ExcludeRebalanced {
+		trackedCards, rebalanced = excludeRebalanced(cachedCards)
+	}
+
+	// Tokens, art cards and the like sometimes carry legal markers by mistake
+	trackedCards, excluded := excludeCards(trackedCards, options.Exclusions)
+	if len(excluded) > 0 {
+		logger.Info("Skipped cards with an excluded layout, set type or set", "count", len(excluded))
+	}
+
+	// In a single-game mode a card only becomes new once it has a printing in that game,
+	// e.g. weeks after its paper release for Arena
+	if options.Games != "" {
+		trackedCards = filterGameCards(trackedCards, options.Games)
+	}
+
+	cards := filterLegalCards(trackedCards, format, options.LegalStatuses)
+	logger.Info("Found legal cards", "count", len(cards))
+	if len(cards) == 0 {
+		return trackResult{}, fmt.Errorf("no cards are legal in %q, is it a Scryfall format name?", format)
+	}
+
+	// Build oracle_id to best card mapping (prefer Arena)
+	oracleToCard := buildOracleMapping(cards)
+	logger.Info("Unique oracle cards", "count", len(oracleToCard))
+
+	// The diff is only meaningful against a history of the same game
+	if len(history.Days) > 0 && history.Meta.Games != options.Games {
+		return trackResult{}, fmt.Errorf("%s tracks %s, not %s; each -games mode keeps its own history", files.History,
+			describeGames(history.Meta.Games), describeGames(options.Games))
+	}
+
+	// Another set of statuses would turn every card whose status differs into an addition or removal
+	if len(history.Days) > 0 && !sameStatuses(history.Meta.LegalStatuses, options.LegalStatuses) {
+		return trackResult{}, fmt.Errorf("%s tracks cards that are %s, not %s; start a new history with -history to change -legal-statuses", files.History,
+			describeStatuses(history.Meta.LegalStatuses), describeStatuses(options.LegalStatuses))
+	}
+
+	// Translate days from the old printing-based format instead of starting over
+	history, migration := upgradeHistory(history, cachedCards)
+	if migration.Days > 0 {
+		logger.Info("Migrated legacy days to oracle ids", "days", migration.Days)
+	}
+	if migration.Pinned > 0 {
+		logger.Info("Recorded set and collector number of pinned printings", "count", migration.Pinned)
+	}
+
+	// A backfilled day before the latest entry is diffed against the days up to it, and takes the
+	// cards it finds from the later days that recorded them
+	outOfOrder := len(history.Days) > 0 && options.Date < latestEntryDate(history)
+	knownHistory := history
+	if outOfOrder {
+		if !options.AllowOutOfOrder {
+			return trackResult{}, fmt.Errorf("%s is before the latest entry of %s (%s), pass -allow-out-of-order to backfill it",
+				options.Date, files.History, latestEntryDate(history))
+		}
+		knownHistory = historyUntil(history, options.Date)
+		if len(knownHistory.Days) == 0 {
+			return trackResult{}, fmt.Errorf("%s is before the first entry of %s, there is nothing to diff it against", options.Date, files.History)
+		}
+		logger.Info("Backfilling before the latest entry", "date", options.Date, "latest", latestEntryDate(history))
+	}
+
+	// Build set of all known oracle_ids from history
+	knownOracles := buildKnownOraclesFromHistory(knownHistory)
+
+	// Detect known cards that gained Arena availability since the last run. With oracle_cards the games
+	// of the single representative printing say nothing about the other printings, so skip detection.
+	// Arena-only histories add cards when they reach Arena, so there is nothing more to detect.
+	trackArena := options.BulkType == bulkTypeDefaultCards && options.Games != "arena"
+	currentGames := buildOracleGames(cards)
+	var nowOnArena []string
+	if outOfOrder {
+		// The states describe the latest run, an older snapshot would report bogus events and roll them back
+		logger.Info("Arena availability and legality changes are not tracked when backfilling")
+		trackArena = false
+	} else if options.Games == "arena" {
+		logger.Info("Arena-only tracking, cards are added when they reach Arena")
+	} else if !trackArena {
+		logger.Info("Arena availability is not tracked with oracle_cards bulk data")
+	} else if previousGames, found := loadGamesState(files.GamesState); found {
+		nowOnArena = findNowOnArena(previousGames, currentGames, knownOracles)
+		logger.Info("Found known cards now on Arena", "count", len(nowOnArena))
+	} else {
+		logger.Info("No games state yet, recording baseline without Arena events")
+	}
+
+	// Detect status transitions such as bans; the first run only records a baseline
+	currentLegalities := buildOracleLegalities(trackedCards, format, options.LegalStatuses)
+	var legalityChanges []LegalityChange
+	previousLegalities, foundLegalities := loadLegalityState(files.LegalityState)
+	if outOfOrder {
+		foundLegalities = false
+	} else if !foundLegalities {
+		logger.Info("No legality state yet, recording baseline without legality changes")
+	}
+	if foundLegalities {
+		legalityChanges = findLegalityChanges(previousLegalities, currentLegalities)
+		logger.Info("Found legality changes", "count", len(legalityChanges))
+	}
+
+	// Detect Oracle renames of known cards, seeding the state without events the first time
+	currentNames := buildOracleNames(oracleToCard)
+	var renames []NameChange
+	previousNames, foundNames := loadNamesState(files.NamesState)
+	if outOfOrder {
+		foundNames = false
+	} else if !foundNames {
+		logger.Info("No names state yet, recording baseline without renames")
+	}
+	if foundNames {
+		renames = findRenames(previousNames, currentNames)
+		for _, rename := range renames {
+			logger.Info("Card renamed", "oracle_id", rename.OracleID, "old_name", rename.OldName, "new_name", rename.NewName)
+		}
+	}
+
+	// Today's entry when one is written; the first run's baseline is not a discovery
+	var snapshot *DayResult
+	var entry *DayResult
+	newCount := 0
+	var runOracles []string // This run's additions, without those of earlier runs of the day
+
+	// A history that only has unresolvable legacy days is not empty, starting over would lose it
+	if len(knownOracles) == 0 && len(legacyPrintingIDs(history)) > 0 {
+		return trackResult{}, fmt.Errorf("none of the legacy cards in %s could be resolved to oracle ids, refusing to start a new history", files.History)
+	}
+
+	// Check if this is first run (no history or transitioning from old format). A re-run on the day
+	// of the first run redoes the baseline, cards found since are part of it.
+	today, foundToday := findEntryForDate(history, options.Date)
+	if outOfOrder && foundToday && today.FirstRun {
+		return trackResult{}, fmt.Errorf("%s is the first run of %s, its baseline can't be redone once later days exist", options.Date, files.History)
+	}
+	if len(history.Days) == 0 || len(knownOracles) == 0 || (foundToday && today.FirstRun) {
+		logger.Info("First run, initializing with all current oracle cards", "count", len(oracleToCard))
+
+		// On first run, add all current oracle_ids
+		var addedOracles []string
+
+		for oracleID := range oracleToCard {
+			addedOracles = append(addedOracles, oracleID)
+		}
+		// Sorted, so redoing an unchanged baseline leaves the history as it is
+		sort.Strings(addedOracles)
+
+		result := DayResult{
+			Date:         options.Date,
+			AddedOracles: addedOracles,
+			TotalCards:   len(oracleToCard),
+			FirstRun:     true,
+			Source:       options.Source,
+		}
+
+		// Clear history for fresh start with oracle-based format
+		history.Days = []DayResult{result}
+		entry = &result
+	} else {
+		// Find new oracle_ids (in current but not in our known set)
+		logger.Info("Comparing with known oracle cards", "known", len(knownOracles))
+		newOracles := findNewOracles(knownOracles, oracleToCard)
+
+		logger.Info("Found new oracle cards", "count", len(newOracles))
+		for _, oracleID := range newOracles {
+			card := oracleToCard[oracleID]
+			logger.Debug("New card, chose printing", "oracle_id", oracleID, "name", card.Name, "card_id", card.ID, "set", card.Set, "games", card.Games)
+		}
+		if outOfOrder {
+			if moved := unrecordAfter(history, options.Date, newOracles, oracleToCard); moved > 0 {
+				logger.Info("Moved cards recorded on later days", "count", moved, "date", options.Date)
+			}
+		}
+
+		// Known cards that lost legality (bans, corrected data)
+		var removedOracles []string
+		for _, oracleID := range findRemovedOracles(knownOracles, oracleToCard) {
+			if rebalanced[oracleID] {
+				logger.Debug("Not reporting rebalanced card as removed", "oracle_id", oracleID)
+				continue
+			}
+			// Changing the exclusions doesn't rewrite what was recorded, unless asked to
+			if excluded[oracleID] && !options.Reconcile {
+				logger.Debug("Not reporting excluded card as removed", "oracle_id", oracleID)
+				continue
+			}
+			logger.Debug("Card no longer legal", "oracle_id", oracleID)
+			removedOracles = append(removedOracles, oracleID)
+		}
+		logger.Info("Found cards no longer legal", "count", len(removedOracles))
+
+		// Previews describe the present, like the states
+		var previews []PreviewCard
+		if len(options.Previews) > 0 && !outOfOrder {
+			previews = findNewPreviews(options.Previews, options.Games, knownOracles, buildKnownPreviews(history), oracleToCard)
+			logger.Info("Found new previewed cards", "count", len(previews))
+		}
+
+		// Only add entry if there are new cards or if it's been more than a day since last entry
+		shouldAddEntry := len(newOracles) > 0 || len(nowOnArena) > 0 || len(removedOracles) > 0 || len(legalityChanges) > 0 || len(renames) > 0 || len(previews) > 0
+
+		// Also add entry if the day has none yet (to track total count changes)
+		if !foundToday {
+			shouldAddEntry = true
+		}
+
+		if shouldAddEntry {
+			var addedOracles []string
+
+			for _, oracleID := range newOracles {
+				addedOracles = append(addedOracles, oracleID)
+			}
+			cardMapping := make(map[string]PinnedPrinting)
+			for oracleID, pinned := range buildCardMapping(newOracles, oracleToCard) {
+				cardMapping[oracleID] = pinned
+			}
+			tags := make(map[string][]string)
+
+			// Keep everything recorded by an earlier run today: its cards are known by now and
+			// its Arena events are no longer reported by the games state
+			if foundToday {
+				addedEarlier := make(map[string]bool)
+				for _, oracleID := range today.AddedOracles {
+					addedEarlier[oracleID] = true
+
+					// A card added earlier today that is no longer legal was never really added
+					if _, found := oracleToCard[oracleID]; !found {
+						logger.Debug("Dropping card added earlier in the day, it is no longer legal", "oracle_id", oracleID)
+						continue
+					}
+					addedOracles = append(addedOracles, oracleID)
+					if pinned, found := today.CardMapping[oracleID]; found {
+						cardMapping[oracleID] = pinned
+					}
+					if oracleTags, found := today.Tags[oracleID]; found {
+						tags[oracleID] = oracleTags
+					}
+				}
+				addedOracles = mergeOracleIDs(addedOracles, nil)
+
+				var removedBeforeToday []string
+				for _, oracleID := range removedOracles {
+					if !addedEarlier[oracleID] {
+						removedBeforeToday = append(removedBeforeToday, oracleID)
+					}
+				}
+				removedOracles = removedBeforeToday
+
+				nowOnArena = mergeOracleIDs(today.ArenaAdded, nowOnArena)
+				removedOracles = mergeOracleIDs(today.RemovedOracles, removedOracles)
+				legalityChanges = mergeLegalityChanges(today.LegalityChanges, legalityChanges)
+				renames = mergeRenames(today.Renamed, renames)
+			}
+			previews = mergePreviews(today.Previews, previews, oracleToCard)
+
+			// A card removed by an earlier run today that is legal again is simply not removed
+			var stillRemoved []string
+			for _, oracleID := range removedOracles {
+				if _, found := oracleToCard[oracleID]; !found {
+					stillRemoved = append(stillRemoved, oracleID)
+				}
+			}
+			removedOracles = stillRemoved
+
+			result := DayResult{
+				Date:         options.Date,
+				AddedOracles: addedOracles,
+				TotalCards:   len(oracleToCard),
+				FirstRun:     false,
+				ArenaAdded:   nowOnArena,
+
+				RemovedOracles:  removedOracles,
+				LegalityChanges: legalityChanges,
+				Renamed:         renames,
+				Previews:        previews,
+				Source:          options.Source,
+			}
+			if len(cardMapping) > 0 {
+				result.CardMapping = cardMapping
+			}
+			result.Sets = summarizeSets(addedOracles, oracleToCard)
+			result.Stats = summarizeStats(addedOracles, oracleToCard)
+
+			// Only this run's hits are announced, earlier ones today already were
+			result.WatchlistHits = findWatchlistHits(options.Watched, addedOracles)
+			reportWatchlistHits(findWatchlistHits(options.Watched, newOracles), oracleToCard, format)
+
+			// Optional enrichment with functional tags
+			if len(options.Tags) > 0 && !options.DryRun {
+				logger.Info("Looking up tags for new cards", "tags", options.Tags, "count", len(newOracles))
+				for oracleID, oracleTags := range fetchOracleTags(ctx, options.Client, options.Tags, newOracles, options.TagCacheDir, options.TagCacheTTL) {
+					tags[oracleID] = oracleTags
+				}
+				// An interrupted lookup skipped the remaining tags, don't save the entry without them
+				if err := ctx.Err(); err != nil {
+					return trackResult{}, err
+				}
+			}
+			if len(tags) > 0 {
+				result.Tags = tags
+			}
+
+			// Remove existing entry for today if it exists
+			history = removeEntryForDate(history, options.Date)
+			history.Days = append(history.Days, result)
+			snapshot = &result
+			entry = &result
+			newCount = len(newOracles)
+			runOracles = newOracles
+
+			logger.Info("Added entry", "date", result.Date, "new_cards", len(newOracles), "total_cards", result.TotalCards)
+		} else {
+			logger.Info("No new oracle cards and already have an entry for the day", "date", options.Date)
+		}
+	}
+
+	if options.DryRun {
+		if err := reportDryRun(format, entry, oracleToCard); err != nil {
+			return trackResult{}, err
+		}
+		return trackResult{NewCards: newCount, LegalCards: len(cards), OracleCards: len(oracleToCard)}, nil
+	}
+
+	// Let the renderer know the format and whether the card cache has every printing
+	history.Meta.Format = format
+	history.Meta.BulkType = options.BulkType
+	history.Meta.Games = options.Games
+	history.Meta.LegalStatuses = recordedStatuses(options.LegalStatuses)
+
+	// Save history, unless the run found nothing to record on a day that already has its entry
+	changed, err := historyChanged(history, files.History)
+	if err != nil {
+		return trackResult{}, fmt.Errorf("comparing history: %w", err)
+	}
+	if changed {
+		if err := backupHistory(files.History, options.BackupDir, options.Backups, time.Now()); err != nil {
+			return trackResult{}, fmt.Errorf("backing up history: %w", err)
+		}
+		if err := saveHistory(history, files.History); err != nil {
+			return trackResult{}, fmt.Errorf("saving history: %w", err)
+		}
+	}
+
+	if snapshot != nil {
+		if err := saveDaySnapshot(options.ResultsDir, format, options.Games, *snapshot, oracleToCard); err != nil {
+			return trackResult{}, fmt.Errorf("saving result snapshot: %w", err)
+		}
+	}
+
+	// Save games state only after history so a failed run re-detects its Arena events
+	if trackArena {
+		if err := saveGamesState(currentGames, files.GamesState); err != nil {
+			return trackResult{}, fmt.Errorf("saving games state: %w", err)
+		}
+	}
+
+	if !outOfOrder {
+		if err := saveLegalityState(currentLegalities, files.LegalityState); err != nil {
+			return trackResult{}, fmt.Errorf("saving legality state: %w", err)
+		}
+		if err := saveNamesState(currentNames, files.NamesState); err != nil {
+			return trackResult{}, fmt.Errorf("saving names state: %w", err)
+		}
+	}
+
+	if err := writeRunSummary(format, options.Games, entry, options.Date, len(oracleToCard), runOracles, oracleToCard); err != nil {
+		logger.Warn("Could not write the run summary", "err", err)
+	}
+
+	logger.Info("Data updated", "history", files.History, "changed", changed)
+	return trackResult{NewCards: newCount, Changed: changed, LegalCards: len(cards), OracleCards: len(oracleToCard)}, nil
+}
+
+// filterGameCards keeps the printings available in a game
+func filterGameCards(cards []Card, game string) []Card {
+	var kept []Card
+	for _, card := range cards {
+		for _, cardGame := range card.Games {
+			if cardGame == game {
+				kept = append(kept, card)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// describeGames names a -games value for messages
+func describeGames(games string) string {
+	if games == "" {
+		return "cards in all games"
+	}
+	return games + " cards only"
+}
+
+// parseGames validates the -games flag
+func parseGames(value string) (string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return "", nil
+	}
+	for _, game := range trackedGames {
+		if value == game {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("-games must be one of %s, got %q", strings.Join(trackedGames, ", "), value)
+}
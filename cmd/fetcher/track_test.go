@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFilterGameCardsPaper(t *testing.T) {
+	cards := []Card{
+		{ID: "1", Name: "Llanowar Elves", Games: []string{"arena", "paper", "mtgo"}},
+		{ID: "2", Name: "A-Llanowar Elves", Games: []string{"arena"}},
+		{ID: "3", Name: "Paper Only", Games: []string{"paper"}},
+		{ID: "4", Name: "No Games"},
+	}
+
+	var kept []string
+	for _, card := range filterGameCards(cards, "paper") {
+		kept = append(kept, card.ID)
+	}
+	if want := []string{"1", "3"}; !reflect.DeepEqual(kept, want) {
+		t.Errorf("filterGameCards(paper) kept %v, want %v", kept, want)
+	}
+}
+
+func TestNewFormatFilesPerGame(t *testing.T) {
+	all := newFormatFiles("data", "brawl", "", "")
+	paper := newFormatFiles("data", "brawl", "paper", "")
+
+	if all.History != filepath.Join("data", "history.json") {
+		t.Errorf("history of all games = %s", all.History)
+	}
+	if paper.History != filepath.Join("data", "history-brawl-paper.json") {
+		t.Errorf("history of paper = %s", paper.History)
+	}
+	// Each mode diffs against its own known cards and states
+	for name, files := range map[string][2]string{
+		"history":        {all.History, paper.History},
+		"games state":    {all.GamesState, paper.GamesState},
+		"legality state": {all.LegalityState, paper.LegalityState},
+		"names state":    {all.NamesState, paper.NamesState},
+	} {
+		if files[0] == files[1] {
+			t.Errorf("%s shared between modes: %s", name, files[0])
+		}
+	}
+}
+
+// testCard is a Brawl-legal printing on Arena and in paper
+func testCard(oracleID string) Card {
+	return Card{
+		ID:         "printing-" + oracleID,
+		OracleID:   oracleID,
+		Name:       "Card " + oracleID,
+		Set:        "tst",
+		ReleasedAt: "2024-01-01",
+		Legalities: map[string]string{"brawl": "legal"},
+		Games:      []string{"arena", "paper"},
+	}
+}
+
+// runTrack runs the fetcher's diff of brawl on date over default_cards with a printing of each
+// oracle id, with the state files in dir
+func runTrack(t *testing.T, dir string, date string, oracleIDs ...string) (trackResult, HistoryData) {
+	t.Helper()
+	var cards []Card
+	for _, oracleID := range oracleIDs {
+		cards = append(cards, testCard(oracleID))
+	}
+	return runTrackCards(t, dir, date, bulkTypeDefaultCards, cards)
+}
+
+// runTrackCards runs the fetcher's diff of brawl on date over cards from a bulk file of bulkType
+func runTrackCards(t *testing.T, dir string, date string, bulkType string, cards []Card) (trackResult, HistoryData) {
+	t.Helper()
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+	files := newFormatFiles(dir, "brawl", "", "")
+	history, err := loadHistory(files.History)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := trackFormat(context.Background(), "brawl", cards, history, files, trackOptions{
+		BulkType:      bulkType,
+		ResultsDir:    filepath.Join(dir, "results"),
+		Date:          date,
+		LegalStatuses: defaultLegalStatuses,
+	})
+	if err != nil {
+		t.Fatalf("tracking %s: %v", date, err)
+	}
+	saved, err := loadHistory(files.History)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result, saved
+}
+
+func TestSameDayRerunKeepsEarlierCards(t *testing.T) {
+	dir := t.TempDir()
+	runTrack(t, dir, "2024-05-01", "a", "b")
+	runTrack(t, dir, "2024-05-02", "a", "b", "c")
+
+	// A card appears between two runs of the same day
+	result, history := runTrack(t, dir, "2024-05-02", "a", "b", "c", "d")
+	if !result.Changed || result.NewCards != 1 {
+		t.Errorf("second run of the day = %+v, want one new card and a changed history", result)
+	}
+	if len(history.Days) != 2 {
+		t.Fatalf("history has %d days, want 2", len(history.Days))
+	}
+	today := history.Days[1]
+	if want := []string{"c", "d"}; today.Date != "2024-05-02" || !reflect.DeepEqual(today.AddedOracles, want) {
+		t.Errorf("today's entry = %s %v, want 2024-05-02 %v", today.Date, today.AddedOracles, want)
+	}
+
+	// Nothing new on a third run
+	result, history = runTrack(t, dir, "2024-05-02", "a", "b", "c", "d")
+	if result.Changed || result.NewCards != 0 {
+		t.Errorf("third run of the day = %+v, want no change", result)
+	}
+	if want := []string{"c", "d"}; !reflect.DeepEqual(history.Days[1].AddedOracles, want) {
+		t.Errorf("today's entry after the third run = %v, want %v", history.Days[1].AddedOracles, want)
+	}
+}
+
+// oracle_cards has one printing per card, whose games say nothing about the others: new cards are
+// tracked as with default_cards, known cards reaching Arena are not
+func TestTrackBulkTypes(t *testing.T) {
+	tests := []struct {
+		bulkType   string
+		arenaAdded []string
+	}{
+		{bulkTypeDefaultCards, []string{"a"}},
+		{bulkTypeOracleCards, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.bulkType, func(t *testing.T) {
+			dir := t.TempDir()
+			paperOnly := testCard("a")
+			paperOnly.Games = []string{"paper"}
+			runTrackCards(t, dir, "2024-05-01", test.bulkType, []Card{paperOnly, testCard("b")})
+
+			result, history := runTrackCards(t, dir, "2024-05-02", test.bulkType, []Card{testCard("a"), testCard("b"), testCard("c")})
+			if !result.Changed || result.NewCards != 1 {
+				t.Errorf("second run = %+v, want one new card and a changed history", result)
+			}
+			if history.Meta.BulkType != test.bulkType {
+				t.Errorf("history records bulk type %q, want %q", history.Meta.BulkType, test.bulkType)
+			}
+			if len(history.Days) != 2 {
+				t.Fatalf("history has %d days, want 2", len(history.Days))
+			}
+			today := history.Days[1]
+			if want := []string{"c"}; !reflect.DeepEqual(today.AddedOracles, want) {
+				t.Errorf("added %v, want %v", today.AddedOracles, want)
+			}
+			if pinned := today.CardMapping["c"]; pinned.ID != "printing-c" {
+				t.Errorf("card_mapping pins %+v for c, want printing-c", pinned)
+			}
+			if !reflect.DeepEqual(today.ArenaAdded, test.arenaAdded) {
+				t.Errorf("arena_added = %v, want %v", today.ArenaAdded, test.arenaAdded)
+			}
+		})
+	}
+}
+
+func TestFirstRunRerunIsUnchanged(t *testing.T) {
+	dir := t.TempDir()
+	oracleIDs := []string{"e", "d", "c", "b", "a", "f", "g", "h"}
+	result, history := runTrack(t, dir, "2024-05-01", oracleIDs...)
+	if !result.Changed {
+		t.Error("first run did not change the history")
+	}
+	if want := []string{"a", "b", "c", "d", "e", "f", "g", "h"}; !reflect.DeepEqual(history.Days[0].AddedOracles, want) {
+		t.Errorf("baseline = %v, want %v", history.Days[0].AddedOracles, want)
+	}
+
+	if result, _ := runTrack(t, dir, "2024-05-01", oracleIDs...); result.Changed {
+		t.Error("re-running the first run with the same cards changed the history")
+	}
+}
+
+func TestRunExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	exitCode := func(result trackResult, err error) int {
+		return runExitCode(err, result.Changed, false, result.NewCards)
+	}
+
+	result, _ := runTrack(t, dir, "2024-05-01", "a", "b")
+	if code := exitCode(result, nil); code != 0 {
+		t.Errorf("first run exits %d, want 0", code)
+	}
+	result, _ = runTrack(t, dir, "2024-05-02", "a", "b", "c")
+	if code := exitCode(result, nil); code != 0 {
+		t.Errorf("run with a new card exits %d, want 0", code)
+	}
+	result, _ = runTrack(t, dir, "2024-05-02", "a", "b", "c")
+	if code := exitCode(result, nil); code != exitNoChanges {
+		t.Errorf("re-run with nothing new exits %d, want %d", code, exitNoChanges)
+	}
+
+	// A day without new cards still gets an entry with its total, which modifies the history
+	result, history := runTrack(t, dir, "2024-05-03", "a", "b", "c")
+	if latest := history.Days[len(history.Days)-1]; latest.Date != "2024-05-03" || len(latest.AddedOracles) != 0 {
+		t.Fatalf("latest entry = %s %v, want 2024-05-03 without cards", latest.Date, latest.AddedOracles)
+	}
+	if code := exitCode(result, nil); code != 0 {
+		t.Errorf("run recording only the day's total exits %d, want 0", code)
+	}
+
+	// A day before the latest entry fails without -allow-out-of-order
+	files := newFormatFiles(dir, "brawl", "", "")
+	result, err := trackFormat(context.Background(), "brawl", []Card{testCard("a")}, history, files, trackOptions{
+		BulkType:      bulkTypeDefaultCards,
+		ResultsDir:    filepath.Join(dir, "results"),
+		Date:          "2024-04-30",
+		LegalStatuses: defaultLegalStatuses,
+	})
+	if err == nil {
+		t.Fatal("tracking a day before the latest entry succeeded")
+	}
+	if code := exitCode(result, err); code != 1 {
+		t.Errorf("failed run exits %d, want 1", code)
+	}
+
+	// Dry runs write nothing and exit on whether they found new cards
+	if code := runExitCode(nil, false, true, 2); code != 0 {
+		t.Errorf("dry run with new cards exits %d, want 0", code)
+	}
+	if code := runExitCode(nil, false, true, 0); code != exitNoChanges {
+		t.Errorf("dry run without new cards exits %d, want %d", code, exitNoChanges)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"log/slog"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// oracleIDPattern matches a Scryfall oracle id
+var oracleIDPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// loadWatchlist reads card names or oracle ids, one per line, see readListFile
+func loadWatchlist(filename string) ([]string, error) {
+	return readListFile(filename)
+}
+
+// readListFile reads a hand-edited list with one entry per line; blank lines and # comments are
+// ignored. A missing file means an empty list.
+func readListFile(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, line)
+	}
+	return entries, scanner.Err()
+}
+
+// watchlistResolver maps watchlist entries to oracle ids while the full bulk data streams past.
+// Names match case-insensitively against full names and individual faces ("Fire", "Fire // Ice").
+type watchlistResolver struct {
+	entries  []string
+	byName   map[string]string // lowercased name -> entry
+	byOracle map[string]string // oracle_id -> entry
+	watched  map[string]string // oracle_id -> entry
+	resolved map[string]bool   // lowercased entries that matched a card
+}
+
+// newWatchlistResolver indexes the entries by name and by oracle id
+func newWatchlistResolver(entries []string) *watchlistResolver {
+	resolver := &watchlistResolver{
+		entries:  entries,
+		byName:   make(map[string]string),
+		byOracle: make(map[string]string),
+		watched:  make(map[string]string),
+		resolved: make(map[string]bool),
+	}
+	for _, entry := range entries {
+		key := strings.ToLower(entry)
+		if oracleIDPattern.MatchString(key) {
+			resolver.byOracle[key] = entry
+		} else {
+			resolver.byName[key] = entry
+		}
+	}
+	return resolver
+}
+
+// observe checks a single card against the watchlist
+func (r *watchlistResolver) observe(card Card) {
+	if len(r.entries) == 0 || card.OracleID == "" {
+		return
+	}
+
+	if entry, found := r.byOracle[card.OracleID]; found {
+		r.match(card.OracleID, entry)
+	}
+	if entry, found := r.byName[strings.ToLower(card.Name)]; found {
+		r.match(card.OracleID, entry)
+	}
+	for _, face := range card.CardFaces {
+		if entry, found := r.byName[strings.ToLower(face.Name)]; face.Name != "" && found {
+			r.match(card.OracleID, entry)
+		}
+	}
+}
+
+func (r *watchlistResolver) match(oracleID string, entry string) {
+	r.watched[oracleID] = entry
+	r.resolved[strings.ToLower(entry)] = true
+}
+
+// result returns the watched oracle ids and, separately, entries that didn't resolve so they can be reported once
+func (r *watchlistResolver) result() (map[string]string, []string) {
+	if len(r.entries) == 0 {
+		return nil, nil
+	}
+
+	var unresolved []string
+	for _, entry := range r.entries {
+		if !r.resolved[strings.ToLower(entry)] {
+			unresolved = append(unresolved, entry)
+		}
+	}
+	return r.watched, unresolved
+}
+
+// findWatchlistHits returns the watched oracle ids among the day's additions
+func findWatchlistHits(watched map[string]string, addedOracles []string) []string {
+	var hits []string
+	for _, oracleID := range addedOracles {
+		if _, found := watched[oracleID]; found {
+			hits = append(hits, oracleID)
+		}
+	}
+	sort.Strings(hits)
+	return hits
+}
+
+// reportWatchlistHits logs a distinct message per hit so it stands out in the run log
+func reportWatchlistHits(hits []string, oracleToCard map[string]Card, format string) {
+	for _, oracleID := range hits {
+		slog.Info("*** Watchlist hit ***", "name", oracleToCard[oracleID].Name, "oracle_id", oracleID, "format", format)
+	}
+}
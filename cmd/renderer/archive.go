@@ -0,0 +1,22 @@
+package main
+
+import "fmt"
+
+// includeArchive puts the days "fetcher prune" moved to an archive back in front of the live history,
+// for a build of the full site. The archive must be of the same format and end before the history starts.
+func includeArchive(history HistoryData, archiveFile string) (HistoryData, error) {
+	archive, err := loadHistory(archiveFile)
+	if err != nil {
+		return HistoryData{}, fmt.Errorf("loading archive: %w", err)
+	}
+	if archive.Meta.Format != history.Meta.Format {
+		return HistoryData{}, fmt.Errorf("%s archives %s, not %s", archiveFile, archive.Meta.Format, history.Meta.Format)
+	}
+
+	merged := history
+	merged.Days = append(append([]DayResult{}, archive.Days...), history.Days...)
+	if err := merged.Validate(); err != nil {
+		return HistoryData{}, fmt.Errorf("%s overlaps the history: %w", archiveFile, err)
+	}
+	return merged, nil
+}
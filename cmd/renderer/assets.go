@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"os"
+	"path/filepath"
+)
+
+// stylesheet is style.css, shared by the pages of every site at the root of the output directory
+//
+//go:embed style.css
+var stylesheet []byte
+
+// themeScript is theme.js, loaded in the <head> of every page so a theme picked with the header
+// toggle applies before the page is drawn. The choice is kept in localStorage; without one the
+// stylesheet follows the system's dark mode.
+const themeScript = `(function () {
+    var root = document.documentElement;
+    try {
+        var stored = localStorage.getItem("theme");
+        if (stored === "dark" || stored === "light") {
+            root.setAttribute("data-theme", stored);
+        }
+    } catch (e) {}
+
+    function current() {
+        var theme = root.getAttribute("data-theme");
+        if (theme) {
+            return theme;
+        }
+        return window.matchMedia && window.matchMedia("(prefers-color-scheme: dark)").matches ? "dark" : "light";
+    }
+
+    document.addEventListener("DOMContentLoaded", function () {
+        var links = document.querySelector(".header .links");
+        if (!links) {
+            return;
+        }
+        var button = document.createElement("button");
+        button.type = "button";
+        button.className = "header-link theme-toggle";
+        function label() {
+            button.textContent = current() === "dark" ? "Light mode" : "Dark mode";
+        }
+        button.addEventListener("click", function () {
+            var theme = current() === "dark" ? "light" : "dark";
+            root.setAttribute("data-theme", theme);
+            try {
+                localStorage.setItem("theme", theme);
+            } catch (e) {}
+            label();
+        });
+        label();
+        links.appendChild(button);
+    });
+})();
+`
+
+// writeSharedAssets writes style.css and theme.js to the root of the output directory
+func writeSharedAssets(outputRoot string) error {
+	if err := writeFileIfChanged(filepath.Join(outputRoot, "style.css"), stylesheet); err != nil {
+		return err
+	}
+	return writeFileIfChanged(filepath.Join(outputRoot, "theme.js"), []byte(themeScript))
+}
+
+// writeFileIfChanged leaves a file alone when it already has the content, so builds don't touch it
+func writeFileIfChanged(filename string, content []byte) error {
+	existing, err := os.ReadFile(filename)
+	if err == nil && bytes.Equal(existing, content) {
+		return nil
+	}
+	return os.WriteFile(filename, content, 0644)
+}
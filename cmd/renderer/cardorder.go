@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CardOrder compares two cards of a day, reporting whether a goes first
+type CardOrder func(a, b DisplayCard) bool
+
+// cardOrders are the orders -sort picks from. Each breaks ties with the Wizards order, so cards
+// missing the compared field, e.g. previews without a set, still have a stable place.
+var cardOrders = map[string]CardOrder{
+	"color":  compareCardsWizardsStyle,
+	"name":   compareCardsByName,
+	"set":    compareCardsBySet,
+	"rarity": compareCardsByRarity,
+	"cmc":    compareCardsByCMC,
+}
+
+// defaultCardOrder is the Wizards order: color, then CMC, then name
+const defaultCardOrder = "color"
+
+// cardOrderNames lists the values of -sort for its help
+func cardOrderNames() string {
+	var names []string
+	for name := range cardOrders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, "|")
+}
+
+// parseCardOrder returns the order a -sort value names
+func parseCardOrder(name string) (CardOrder, error) {
+	order, found := cardOrders[name]
+	if !found {
+		return nil, fmt.Errorf("unknown order %q, expected one of %s", name, cardOrderNames())
+	}
+	return order, nil
+}
+
+// compareCardsByName sorts by name, then the Wizards order for cards of the same name
+func compareCardsByName(a, b DisplayCard) bool {
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	return compareCardsWizardsStyle(a, b)
+}
+
+// compareCardsBySet sorts the newest sets first, then by set code for sets released together; cards
+// without a release date go last
+func compareCardsBySet(a, b DisplayCard) bool {
+	if (a.ReleasedAt == "") != (b.ReleasedAt == "") {
+		return b.ReleasedAt == ""
+	}
+	if a.ReleasedAt != b.ReleasedAt {
+		return a.ReleasedAt > b.ReleasedAt
+	}
+	if a.Set != b.Set {
+		return a.Set < b.Set
+	}
+	return compareCardsWizardsStyle(a, b)
+}
+
+// compareCardsByRarity sorts mythics first, then rares, uncommons and commons in rarityOrder, and
+// cards of unknown rarity last
+func compareCardsByRarity(a, b DisplayCard) bool {
+	rankA, rankB := rarityRank(a.Rarity), rarityRank(b.Rarity)
+	if rankA != rankB {
+		return rankA < rankB
+	}
+	return compareCardsWizardsStyle(a, b)
+}
+
+func rarityRank(rarity string) int {
+	for rank, known := range rarityOrder {
+		if rarity == known {
+			return rank
+		}
+	}
+	return len(rarityOrder)
+}
+
+// compareCardsByCMC sorts by mana value, then the Wizards order
+func compareCardsByCMC(a, b DisplayCard) bool {
+	if a.CMC != b.CMC {
+		return a.CMC < b.CMC
+	}
+	return compareCardsWizardsStyle(a, b)
+}
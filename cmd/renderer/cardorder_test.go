@@ -0,0 +1,112 @@
+package main
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestCardOrders(t *testing.T) {
+	white := DisplayCard{Name: "Serra Angel", Colors: []string{"W"}, CMC: 5, Set: "dmu", Rarity: "uncommon", ReleasedAt: "2022-09-09"}
+	blue := DisplayCard{Name: "Opt", Colors: []string{"U"}, CMC: 1, Set: "dmu", Rarity: "common", ReleasedAt: "2022-09-09"}
+	gold := DisplayCard{Name: "Niv-Mizzet", Colors: []string{"U", "R"}, CMC: 6, Set: "war", Rarity: "rare", ReleasedAt: "2019-05-03"}
+	colorless := DisplayCard{Name: "Sol Ring", CMC: 1, Set: "cmm", Rarity: "mythic", ReleasedAt: "2023-08-04"}
+	land := DisplayCard{Name: "Forest", CMC: 0, Rarity: "common"} // Empty colors, zero CMC, no set
+	unknown := DisplayCard{Name: "Mystery", Colors: []string{"W"}, CMC: 2}
+
+	tests := []struct {
+		order string
+		a, b  DisplayCard
+		want  bool // a goes before b
+	}{
+		// Color order: WUBRG, multicolor, colorless; then CMC, then name
+		{"color", white, blue, true},
+		{"color", blue, white, false},
+		{"color", gold, colorless, true},
+		{"color", colorless, gold, false},
+		{"color", colorless, land, false}, // Both colorless, the land costs less
+		{"color", land, colorless, true},
+		{"color", white, unknown, false}, // Same color, the cheaper first
+		{"color", blue, blue, false},     // Ties are not before each other
+		{"color", DisplayCard{Name: "A", Colors: []string{"X"}}, DisplayCard{Name: "B"}, true},
+
+		// Name, then the color order
+		{"name", land, blue, true},
+		{"name", blue, land, false},
+		{"name", DisplayCard{Name: "Twin", Colors: []string{"W"}}, DisplayCard{Name: "Twin", Colors: []string{"U"}}, true},
+		{"name", blue, blue, false},
+
+		// Newest set first, then set code, then the color order; no release date last
+		{"set", colorless, white, true},
+		{"set", white, gold, true},
+		{"set", gold, land, true},
+		{"set", land, gold, false},
+		{"set", blue, white, false}, // Same set: blue after white
+		{"set", white, blue, true},
+		{"set", DisplayCard{Name: "A", Set: "aaa", ReleasedAt: "2024-01-01"}, DisplayCard{Name: "B", Set: "bbb", ReleasedAt: "2024-01-01"}, true},
+		{"set", land, land, false},
+
+		// Mythic, rare, uncommon, common, then unknown rarities
+		{"rarity", colorless, gold, true},
+		{"rarity", gold, white, true},
+		{"rarity", white, blue, true},
+		{"rarity", blue, unknown, true},
+		{"rarity", unknown, blue, false},
+		{"rarity", land, blue, false}, // Both common: colorless after blue
+		{"rarity", blue, land, true},
+		{"rarity", unknown, unknown, false},
+
+		// Mana value, then the color order
+		{"cmc", land, blue, true},
+		{"cmc", blue, land, false},
+		{"cmc", blue, colorless, true}, // Both cost 1: blue before colorless
+		{"cmc", colorless, blue, false},
+		{"cmc", gold, white, false},
+		{"cmc", land, land, false},
+	}
+
+	for _, test := range tests {
+		order, err := parseCardOrder(test.order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := order(test.a, test.b); got != test.want {
+			t.Errorf("%s: %s before %s = %v, want %v", test.order, test.a.Name, test.b.Name, got, test.want)
+		}
+	}
+}
+
+func TestCardOrdersSortDay(t *testing.T) {
+	cards := []DisplayCard{
+		{Name: "Forest"},
+		{Name: "Opt", Colors: []string{"U"}, CMC: 1, Rarity: "common", Set: "dmu", ReleasedAt: "2022-09-09"},
+		{Name: "Sol Ring", CMC: 1, Rarity: "mythic", Set: "cmm", ReleasedAt: "2023-08-04"},
+		{Name: "Serra Angel", Colors: []string{"W"}, CMC: 5, Rarity: "uncommon", Set: "dmu", ReleasedAt: "2022-09-09"},
+	}
+	want := map[string][]string{
+		"color":  {"Serra Angel", "Opt", "Forest", "Sol Ring"},
+		"name":   {"Forest", "Opt", "Serra Angel", "Sol Ring"},
+		"set":    {"Sol Ring", "Serra Angel", "Opt", "Forest"},
+		"rarity": {"Sol Ring", "Serra Angel", "Opt", "Forest"},
+		"cmc":    {"Forest", "Opt", "Sol Ring", "Serra Angel"},
+	}
+	if len(want) != len(cardOrders) {
+		t.Fatalf("testing %d orders of %d", len(want), len(cardOrders))
+	}
+	for name, names := range want {
+		order, _ := parseCardOrder(name)
+		sorted := append([]DisplayCard{}, cards...)
+		sort.SliceStable(sorted, func(i, j int) bool { return order(sorted[i], sorted[j]) })
+		for i, card := range sorted {
+			if card.Name != names[i] {
+				t.Errorf("%s: position %d is %s, want %v", name, i, card.Name, names)
+				break
+			}
+		}
+	}
+}
+
+func TestParseCardOrderUnknown(t *testing.T) {
+	if _, err := parseCardOrder("price"); err == nil {
+		t.Error("parseCardOrder(price) succeeded")
+	}
+}
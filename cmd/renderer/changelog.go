@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// markdownEscaper escapes characters that would break a markdown link label
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`, `*`, `\*`, `_`, `\_`)
+
+// generateChangelog writes docs/CHANGELOG.md with one section per day that added or removed cards.
+// The output only depends on history and the card cache, so unchanged days produce no diff.
+func generateChangelog(data DisplayData, outputDir string, options RenderOptions) error {
+	// Sort days in reverse chronological order (newest first)
+	displayData := DisplayData{Days: newestFirst(data.Days)}
+
+	var b strings.Builder
+	formatName := options.Site.FormatName
+	fmt.Fprintf(&b, "# %s Chronicle Changelog\n\n", formatName)
+	fmt.Fprintf(&b, "New Magic: The Gathering cards legal in %s format, newest first.\n", formatName)
+
+	for _, day := range displayData.Days {
+		if day.FirstRun {
+			fmt.Fprintf(&b, "\n## %s\n\nInitial data collection - %s %s-legal cards in database.\n", day.Date, addThousandsSeparator(day.TotalCards), formatName)
+			continue
+		}
+		if len(day.Cards)+len(day.Banned)+len(day.Unbanned)+len(day.Removed) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\n## %s\n", day.Date)
+		sections := []struct {
+			label string
+			cards []DisplayCard
+		}{
+			{"new cards", day.Cards},
+			{"banned", day.Banned},
+			{"unbanned", day.Unbanned},
+			{"no longer legal", day.Removed},
+		}
+		for _, section := range sections {
+			if len(section.cards) == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "\n%s %s\n\n", addThousandsSeparator(len(section.cards)), section.label)
+			for _, card := range section.cards {
+				fmt.Fprintf(&b, "- %s\n", changelogCardLine(card))
+			}
+		}
+	}
+
+	return os.WriteFile(filepath.Join(outputDir, "CHANGELOG.md"), []byte(b.String()), 0644)
+}
+
+// changelogCardLine formats a card as a markdown list item body
+func changelogCardLine(card DisplayCard) string {
+	name := markdownEscaper.Replace(card.Name)
+	line := name
+	if card.ScryfallURL != "" {
+		line = fmt.Sprintf("[%s](%s)", name, card.ScryfallURL)
+	}
+	if card.SetName != "" {
+		line += " (" + markdownEscaper.Replace(card.SetName) + ")"
+	}
+	return line
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+)
+
+// gzipFile closes both the decompressor and the file under it
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (f gzipFile) Close() error {
+	f.Reader.Close()
+	return f.file.Close()
+}
+
+// checkCardFile makes sure a card file can be read the way openCardFile reads it
+func checkCardFile(filename string) error {
+	file, err := openCardFile(filename)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// openCardFile opens a card file, preferring the gzip-compressed <filename>.gz the fetcher writes
+// over the plain file of older runs
+func openCardFile(filename string) (io.ReadCloser, error) {
+	path := filename
+	if _, err := os.Stat(filename + ".gz"); err == nil {
+		path = filename + ".gz"
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return file, nil
+	}
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return gzipFile{Reader: reader, file: file}, nil
+}
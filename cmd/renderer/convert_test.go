@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+)
+
+// benchmarkFixture is a scaled-down Brawl history on default_cards: thousands of printings, and a
+// few dozen cards added on each of twenty days
+func benchmarkFixture() (HistoryData, map[string]Card) {
+	const oracles = 5000
+	const printings = 3
+	const days = 20
+	const addedPerDay = 25
+
+	cardLookup := make(map[string]Card, oracles*printings)
+	for i := 0; i < oracles; i++ {
+		for j := 0; j < printings; j++ {
+			id := fmt.Sprintf("card-%05d-%d", i, j)
+			games := []string{"paper"}
+			if j == 0 {
+				games = append(games, "arena")
+			}
+			cardLookup[id] = Card{
+				ID:              id,
+				OracleID:        fmt.Sprintf("oracle-%05d", i),
+				Name:            fmt.Sprintf("Card %d", i),
+				Colors:          []string{"WUBRG"[i%5 : i%5+1]},
+				Rarity:          "common",
+				Set:             fmt.Sprintf("s%02d", j),
+				SetName:         fmt.Sprintf("Set %d", j),
+				ReleasedAt:      fmt.Sprintf("20%02d-01-01", 10+j),
+				CollectorNumber: fmt.Sprint(i),
+				ImageURIs:       map[string]string{"normal": "https://cards.scryfall.io/normal/" + id + ".jpg"},
+				Games:           games,
+			}
+		}
+	}
+
+	history := HistoryData{Days: []DayResult{{Date: "2024-01-01", FirstRun: true, TotalCards: oracles}}}
+	for day := 0; day < days; day++ {
+		result := DayResult{Date: fmt.Sprintf("2024-02-%02d", day%28+1)}
+		for i := 0; i < addedPerDay; i++ {
+			result.AddedOracles = append(result.AddedOracles, fmt.Sprintf("oracle-%05d", day*addedPerDay+i))
+		}
+		history.Days = append(history.Days, result)
+	}
+	return history, cardLookup
+}
+
+// scanCardsByOracle is how printings were found before groupCardsByOracle: a pass over every card
+func scanCardsByOracle(cardLookup map[string]Card, oracleID string) []Card {
+	var candidates []Card
+	for _, card := range cardLookup {
+		if card.OracleID == oracleID {
+			candidates = append(candidates, card)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].ID < candidates[j].ID
+	})
+	return candidates
+}
+
+func TestGroupCardsByOracleMatchesScan(t *testing.T) {
+	history, cardLookup := benchmarkFixture()
+	cardsByOracle := groupCardsByOracle(cardLookup)
+	for _, day := range history.Days[1:4] {
+		for _, oracleID := range day.AddedOracles {
+			scanned, _ := selectBestCard(scanCardsByOracle(cardLookup, oracleID))
+			grouped, _ := selectBestCard(cardsByOracle[oracleID])
+			if scanned.ID != grouped.ID {
+				t.Errorf("%s: grouped chose %s, scanning chose %s", oracleID, grouped.ID, scanned.ID)
+			}
+		}
+	}
+}
+
+// BenchmarkSelectPrintings chooses the printing of every added card by scanning all cards per oracle,
+// and from the index built once
+func BenchmarkSelectPrintings(b *testing.B) {
+	history, cardLookup := benchmarkFixture()
+
+	b.Run("scan", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			for _, day := range history.Days {
+				for _, oracleID := range day.AddedOracles {
+					selectBestCard(scanCardsByOracle(cardLookup, oracleID))
+				}
+			}
+		}
+	})
+	b.Run("grouped", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			cardsByOracle := groupCardsByOracle(cardLookup)
+			for _, day := range history.Days {
+				for _, oracleID := range day.AddedOracles {
+					selectBestCard(cardsByOracle[oracleID])
+				}
+			}
+		}
+	})
+}
+
+// BenchmarkRenderConversions resolves the cards of the pages, search index and set API, grouping the
+// printings for each of them as they used to, and once for all of them
+func BenchmarkRenderConversions(b *testing.B) {
+	history, cardLookup := benchmarkFixture()
+	order, err := parseCardOrder(defaultCardOrder)
+	if err != nil {
+		b.Fatal(err)
+	}
+	var options RenderOptions
+
+	b.Run("per-generator", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			convertToDisplayData(history, cardLookup, groupCardsByOracle(cardLookup), order, nil)
+			buildSearchIndex(history, cardLookup, groupCardsByOracle(cardLookup), options)
+			buildSetAPI(history, cardLookup, groupCardsByOracle(cardLookup), options)
+		}
+	})
+	b.Run("shared", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			cardsByOracle := groupCardsByOracle(cardLookup)
+			convertToDisplayData(history, cardLookup, cardsByOracle, order, nil)
+			buildSearchIndex(history, cardLookup, cardsByOracle, options)
+			buildSetAPI(history, cardLookup, cardsByOracle, options)
+		}
+	})
+}
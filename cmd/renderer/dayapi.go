@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// dayAPISchemaVersion is the schema_version of the day API files. Adding fields keeps it, renaming or
+// removing any, or changing what one means, raises it.
+const dayAPISchemaVersion = 1
+
+// DayAPICard is a card in docs/api/days/<date>.json
+type DayAPICard struct {
+	ScryfallID  string   `json:"scryfall_id"`
+	OracleID    string   `json:"oracle_id"`
+	Name        string   `json:"name"`
+	ManaCost    string   `json:"mana_cost,omitempty"`
+	CMC         float64  `json:"cmc"`
+	TypeLine    string   `json:"type_line,omitempty"`
+	Colors      []string `json:"colors"`
+	Rarity      string   `json:"rarity,omitempty"`
+	Set         string   `json:"set,omitempty"`
+	SetName     string   `json:"set_name,omitempty"`
+	ReleasedAt  string   `json:"released_at,omitempty"`
+	Image       string   `json:"image,omitempty"`
+	ArtCrop     string   `json:"art_crop,omitempty"`
+	ScryfallURL string   `json:"scryfall_url"`
+	Tags        []string `json:"tags,omitempty"`
+	Watched     bool     `json:"watched,omitempty"`
+}
+
+// DayAPIFile is the content of docs/api/days/<date>.json, and of docs/api/latest.json for the newest
+// day with changes. Every list is present, empty when the day has none of it.
+type DayAPIFile struct {
+	SchemaVersion int          `json:"schema_version"`
+	Date          string       `json:"date"`
+	URL           string       `json:"url"` // The day's page
+	FirstRun      bool         `json:"first_run,omitempty"`
+	TotalCards    int          `json:"total_cards,omitempty"` // Legal cards, only known for the first run
+	Sets          []SetCount   `json:"sets"`                  // Sets of the added cards, largest first
+	Added         []DayAPICard `json:"added"`
+	NowOnArena    []DayAPICard `json:"now_on_arena"`
+	Removed       []DayAPICard `json:"removed"`
+	Banned        []DayAPICard `json:"banned"`
+	Unbanned      []DayAPICard `json:"unbanned"`
+	Previews      []DayAPICard `json:"previews"`
+	Renamed       []NameChange `json:"renamed"`
+}
+
+// DayAPIIndexEntry counts the changes of one day in docs/api/index.json
+type DayAPIIndexEntry struct {
+	Date       string `json:"date"`
+	URL        string `json:"url"`  // The day's API file
+	Page       string `json:"page"` // The day's page
+	FirstRun   bool   `json:"first_run,omitempty"`
+	Added      int    `json:"added"`
+	NowOnArena int    `json:"now_on_arena"`
+	Removed    int    `json:"removed"`
+	Banned     int    `json:"banned"`
+	Unbanned   int    `json:"unbanned"`
+	Previews   int    `json:"previews"`
+	Renamed    int    `json:"renamed"`
+}
+
+// DayAPIIndex is the content of docs/api/index.json, newest day first
+type DayAPIIndex struct {
+	SchemaVersion int                `json:"schema_version"`
+	Latest        string             `json:"latest,omitempty"` // URL of latest.json, unset before any day has changes
+	Days          []DayAPIIndexEntry `json:"days"`
+}
+
+// generateDayAPI writes docs/api/days/<date>.json for every day with a page, docs/api/index.json and
+// docs/api/latest.json. Files whose content didn't change are left alone, so the output's history only
+// changes for new and changed days.
+func generateDayAPI(data DisplayData, outputDir string, options RenderOptions) error {
+	apiDir := filepath.Join(outputDir, "api")
+	daysDir := filepath.Join(apiDir, "days")
+	if err := os.MkdirAll(daysDir, 0755); err != nil {
+		return err
+	}
+
+	index := DayAPIIndex{SchemaVersion: dayAPISchemaVersion, Days: []DayAPIIndexEntry{}}
+	var latest *DayAPIFile
+	for _, day := range newestFirst(data.Days) {
+		if !day.FirstRun && !day.HasChanges() {
+			continue
+		}
+		file := newDayAPIFile(day, options)
+		if err := writeJSONFileIfChanged(filepath.Join(daysDir, day.Date+".json"), file); err != nil {
+			return err
+		}
+		if latest == nil && day.HasChanges() {
+			latest = &file
+		}
+		index.Days = append(index.Days, DayAPIIndexEntry{
+			Date:       day.Date,
+			URL:        options.Site.URL + "api/days/" + day.Date + ".json",
+			Page:       options.Site.dayURL(day.Date),
+			FirstRun:   day.FirstRun,
+			Added:      len(day.Cards),
+			NowOnArena: len(day.NowOnArena),
+			Removed:    len(day.Removed),
+			Banned:     len(day.Banned),
+			Unbanned:   len(day.Unbanned),
+			Previews:   len(day.Previews),
+			Renamed:    len(day.Renamed),
+		})
+	}
+
+	latestFile := filepath.Join(apiDir, "latest.json")
+	if latest != nil {
+		index.Latest = options.Site.URL + "api/latest.json"
+		if err := writeJSONFileIfChanged(latestFile, latest); err != nil {
+			return err
+		}
+	} else if err := os.Remove(latestFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	slog.Info("Day API generated", "days", len(index.Days))
+	return writeJSONFileIfChanged(filepath.Join(apiDir, "index.json"), index)
+}
+
+func newDayAPIFile(day DisplayDay, options RenderOptions) DayAPIFile {
+	file := DayAPIFile{
+		SchemaVersion: dayAPISchemaVersion,
+		Date:          day.Date,
+		URL:           options.Site.dayURL(day.Date),
+		FirstRun:      day.FirstRun,
+		Sets:          append([]SetCount{}, day.Sets...),
+		Added:         dayAPICards(day.Cards, options),
+		NowOnArena:    dayAPICards(day.NowOnArena, options),
+		Removed:       dayAPICards(day.Removed, options),
+		Banned:        dayAPICards(day.Banned, options),
+		Unbanned:      dayAPICards(day.Unbanned, options),
+		Previews:      dayAPICards(day.Previews, options),
+		Renamed:       append([]NameChange{}, day.Renamed...),
+	}
+	if day.FirstRun {
+		file.TotalCards = day.TotalCards
+	}
+	return file
+}
+
+// dayAPICards converts cards in the day's order; release countdowns are left out as they change daily
+func dayAPICards(cards []DisplayCard, options RenderOptions) []DayAPICard {
+	converted := make([]DayAPICard, 0, len(cards))
+	for _, card := range cards {
+		converted = append(converted, DayAPICard{
+			ScryfallID:  card.ID,
+			OracleID:    card.OracleID,
+			Name:        card.Name,
+			ManaCost:    card.ManaCost,
+			CMC:         card.CMC,
+			TypeLine:    card.TypeLine,
+			Colors:      append([]string{}, card.Colors...),
+			Rarity:      card.Rarity,
+			Set:         card.Set,
+			SetName:     card.SetName,
+			ReleasedAt:  card.ReleasedAt,
+			Image:       options.ImageProxy.Rewrite(card.ImageURL, searchImageWidth),
+			ArtCrop:     options.ImageProxy.Rewrite(card.ArtCropURL, galleryImageWidth),
+			ScryfallURL: card.ScryfallURL,
+			Tags:        card.Tags,
+			Watched:     card.Watched,
+		})
+	}
+	return converted
+}
+
+// writeJSONFileIfChanged writes v as indented JSON like writeJSONFile, leaving the file alone when it
+// already has the content
+func writeJSONFileIfChanged(filename string, v interface{}) error {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileIfChanged(filename, append(content, '\n'))
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"html/template"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// dayPageTemplates are the files of the day pages: the page, the "day" and "card" templates of
+// day.html.tmpl, shared with the index, and the "social" template of social.html.tmpl
+var dayPageTemplates = []string{"day-page.html.tmpl", "day.html.tmpl", "social.html.tmpl"}
+
+// DayPage is the template data of docs/day/<date>.html
+type DayPage struct {
+	Day    DisplayDay
+	Social SocialMeta
+}
+
+// dayTemplateFuncs are the functions day.html.tmpl needs besides the site's; permalink links a date to
+// its day page relative to the page the template is executed for
+func dayTemplateFuncs(options RenderOptions, permalink func(date string) string) template.FuncMap {
+	return template.FuncMap{
+		"thousands": addThousandsSeparator,
+		"join":      strings.Join,
+		"days":      daysLabel,
+		"image": func(url string) string {
+			return options.ImageProxy.Rewrite(url, htmlImageWidth)
+		},
+		"srcset":      options.ImageProxy.Srcset,
+		"permalink":   permalink,
+		"colorFilter": colorFilter,
+		"captions":    func() bool { return options.Captions },
+		"mana": func(cost string) template.HTML {
+			return renderManaCost(cost, options.Symbology)
+		},
+	}
+}
+
+// generateDayPages writes docs/day/<date>.html for every day with something to show, the pages the
+// feed items link to
+func generateDayPages(data DisplayData, outputDir string, options RenderOptions, cache *RenderCache) error {
+	dayDir := filepath.Join(outputDir, "day")
+	if err := os.MkdirAll(dayDir, 0755); err != nil {
+		return err
+	}
+
+	permalink := func(date string) string { return date + ".html" }
+	t := template.New("day-page").Funcs(options.Site.funcMap()).Funcs(dayTemplateFuncs(options, permalink))
+	for _, name := range dayPageTemplates {
+		if err := options.Templates.parseHTML(t, name); err != nil {
+			return err
+		}
+	}
+
+	// Countdowns are relative to the render, like on the index
+	days := DisplayData{Days: data.Days}
+	applyReleaseCountdowns(&days, options.SetCalendar, options.ReferenceDate)
+	written := 0
+	for _, day := range days.Days {
+		if !day.FirstRun && !day.HasChanges() {
+			continue
+		}
+		filename := filepath.Join(dayDir, day.Date+".html")
+		if !cache.pageChanged(day, options.ImageProxy, filename) {
+			continue
+		}
+		page := DayPage{Day: day, Social: newDaySocialMeta(day, options)}
+		if err := writeTemplateFile(filename, t, page, options.Minify); err != nil {
+			return err
+		}
+		written++
+	}
+	slog.Info("Day pages written", "changed", written)
+	return nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// Share of a day's new cards one set must have to name the day after it
+	dominantSetShare = 0.8
+
+	// Sets listed under a day header when no set dominates
+	maxListedSets = 3
+)
+
+// SetCount is the number of a day's new cards from one set, as recorded by the fetcher
+type SetCount struct {
+	Code  string `json:"code"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// label names the set with its card count, e.g. "Bloomburrow (274)"
+func (set SetCount) label() string {
+	name := set.Name
+	if name == "" {
+		name = strings.ToUpper(set.Code)
+	}
+	return fmt.Sprintf("%s (%s)", name, addThousandsSeparator(set.Count))
+}
+
+// DominantSet returns the set more than dominantSetShare of the day's new cards are from, or nil
+func (day DisplayDay) DominantSet() *SetCount {
+	total := 0
+	for _, set := range day.Sets {
+		total += set.Count
+	}
+	if total == 0 {
+		return nil
+	}
+	// Sets are recorded largest first
+	if top := day.Sets[0]; float64(top.Count) > dominantSetShare*float64(total) {
+		return &top
+	}
+	return nil
+}
+
+// SetsSubtitle describes where the day's new cards come from, e.g. "Mostly from: Bloomburrow (274)"
+// or, on days with a trickle from several sets, the largest few of them
+func (day DisplayDay) SetsSubtitle() string {
+	if len(day.Sets) == 0 {
+		return ""
+	}
+	if set := day.DominantSet(); set != nil {
+		return "Mostly from: " + set.label()
+	}
+
+	var labels []string
+	for i, set := range day.Sets {
+		if i == maxListedSets {
+			break
+		}
+		labels = append(labels, set.label())
+	}
+	subtitle := "From: " + strings.Join(labels, ", ")
+	if more := len(day.Sets) - maxListedSets; more == 1 {
+		subtitle += " and 1 other set"
+	} else if more > 1 {
+		subtitle += fmt.Sprintf(" and %d other sets", more)
+	}
+	return subtitle
+}
+
+// CardGroup is the new cards of one set on a day with cards from several sets
+type CardGroup struct {
+	Set   string
+	Name  string
+	Cards []DisplayCard
+}
+
+// groupCardsBySet reorders a day's cards by set, the sets with the most cards first, keeping their
+// order within each set, and returns the groups as parts of cards, so annotating cards annotates the
+// groups too. Days with cards from a single set return nil and keep their order.
+func groupCardsBySet(cards []DisplayCard) []CardGroup {
+	counts := make(map[string]int)
+	names := make(map[string]string)
+	for _, card := range cards {
+		counts[card.Set]++
+		if names[card.Set] == "" {
+			names[card.Set] = card.SetName
+		}
+	}
+	if len(counts) < 2 {
+		return nil
+	}
+
+	var sets []string
+	for set := range counts {
+		sets = append(sets, set)
+	}
+	sort.Slice(sets, func(i, j int) bool {
+		if counts[sets[i]] != counts[sets[j]] {
+			return counts[sets[i]] > counts[sets[j]]
+		}
+		return sets[i] < sets[j]
+	})
+	rank := make(map[string]int)
+	for i, set := range sets {
+		rank[set] = i
+	}
+	sort.SliceStable(cards, func(i, j int) bool {
+		return rank[cards[i].Set] < rank[cards[j].Set]
+	})
+
+	var groups []CardGroup
+	start := 0
+	for _, set := range sets {
+		end := start + counts[set]
+		name := names[set]
+		if name == "" && set != "" {
+			name = strings.ToUpper(set)
+		} else if name == "" {
+			name = "Unknown set"
+		}
+		groups = append(groups, CardGroup{Set: set, Name: name, Cards: cards[start:end:end]})
+		start = end
+	}
+	return groups
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DayStats counts a day's new cards by color bucket and rarity, as recorded by the fetcher
+type DayStats struct {
+	Colors   map[string]int `json:"colors"`
+	Rarities map[string]int `json:"rarities"`
+}
+
+// Orders and labels of the buckets in summary lines
+var (
+	rarityOrder = []string{"mythic", "rare", "uncommon", "common", "special", "bonus"}
+	colorOrder  = []string{"W", "U", "B", "R", "G", "multicolor", "colorless"}
+	colorLabels = map[string]string{"multicolor": "Multicolor", "colorless": "Colorless"}
+)
+
+// StatsLine summarizes the day's new cards, e.g. "2 mythic, 5 rare · W 3, U 2, Multicolor 2"
+func (day DisplayDay) StatsLine() string {
+	if day.Stats == nil {
+		return ""
+	}
+
+	var rarities []string
+	for _, rarity := range rarityOrder {
+		if count := day.Stats.Rarities[rarity]; count > 0 {
+			rarities = append(rarities, fmt.Sprintf("%s %s", addThousandsSeparator(count), rarity))
+		}
+	}
+	var colors []string
+	for _, color := range colorOrder {
+		if count := day.Stats.Colors[color]; count > 0 {
+			label := colorLabels[color]
+			if label == "" {
+				label = color
+			}
+			colors = append(colors, fmt.Sprintf("%s %s", label, addThousandsSeparator(count)))
+		}
+	}
+
+	var parts []string
+	if len(rarities) > 0 {
+		parts = append(parts, strings.Join(rarities, ", "))
+	}
+	if len(colors) > 0 {
+		parts = append(parts, strings.Join(colors, ", "))
+	}
+	return strings.Join(parts, " · ")
+}
@@ -0,0 +1,54 @@
+package main
+
+import "sort"
+
+// DuplicateAddition is a card a day adds again while it is still legal since an earlier day
+type DuplicateAddition struct {
+	OracleID string
+	Date     string // Day the repeated addition is on
+	First    string // Day the card was added on
+}
+
+// dropDuplicateAdditions removes repeated additions of a card, keeping the earliest, which past fetcher
+// bugs and edits by hand left in some histories and the pages and feeds would show twice. A card that
+// left the format, or was banned, is legal again when it's added back and not a duplicate. The days
+// are walked by date; the history given is left as it is.
+func dropDuplicateAdditions(history HistoryData) (HistoryData, []DuplicateAddition) {
+	order := make([]int, len(history.Days))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return history.Days[order[i]].Date < history.Days[order[j]].Date
+	})
+
+	days := append([]DayResult{}, history.Days...)
+	addedOn := make(map[string]string) // Legal cards by the day they were added on
+	var duplicates []DuplicateAddition
+	for _, i := range order {
+		day := days[i]
+		if day.FirstRun {
+			addedOn = make(map[string]string)
+		}
+		for _, oracleID := range day.RemovedOracles {
+			delete(addedOn, oracleID)
+		}
+
+		added := make([]string, 0, len(day.AddedOracles))
+		for _, oracleID := range day.AddedOracles {
+			if first, found := addedOn[oracleID]; found {
+				duplicates = append(duplicates, DuplicateAddition{OracleID: oracleID, Date: day.Date, First: first})
+				continue
+			}
+			addedOn[oracleID] = day.Date
+			added = append(added, oracleID)
+		}
+		if len(added) < len(day.AddedOracles) {
+			day.AddedOracles = added
+			days[i] = day
+		}
+	}
+
+	history.Days = days
+	return history, duplicates
+}
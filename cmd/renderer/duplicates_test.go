@@ -0,0 +1,82 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDropDuplicateAdditions(t *testing.T) {
+	// Days out of order, as a hand-edited history might have them
+	history := HistoryData{Days: []DayResult{
+		{Date: "2024-05-01", FirstRun: true, AddedOracles: []string{"base"}},
+		{Date: "2024-05-09", AddedOracles: []string{"twice", "other"}},
+		{Date: "2024-05-03", AddedOracles: []string{"twice", "left"}},
+		{Date: "2024-05-05", RemovedOracles: []string{"left"}},
+		{Date: "2024-05-07", AddedOracles: []string{"left", "base"}},
+	}}
+
+	deduplicated, duplicates := dropDuplicateAdditions(history)
+
+	want := []DuplicateAddition{
+		{OracleID: "base", Date: "2024-05-07", First: "2024-05-01"},
+		{OracleID: "twice", Date: "2024-05-09", First: "2024-05-03"},
+	}
+	if !reflect.DeepEqual(duplicates, want) {
+		t.Errorf("duplicates = %+v, want %+v", duplicates, want)
+	}
+
+	added := make(map[string][]string)
+	for _, day := range deduplicated.Days {
+		added[day.Date] = day.AddedOracles
+	}
+	wantAdded := map[string][]string{
+		"2024-05-01": {"base"},
+		"2024-05-03": {"twice", "left"},
+		"2024-05-05": nil,
+		"2024-05-07": {"left"}, // Legal again after leaving the format
+		"2024-05-09": {"other"},
+	}
+	if !reflect.DeepEqual(added, wantAdded) {
+		t.Errorf("additions = %v, want %v", added, wantAdded)
+	}
+
+	// The history given is left as it is
+	if got := history.Days[1].AddedOracles; !reflect.DeepEqual(got, []string{"twice", "other"}) {
+		t.Errorf("input history changed to %v", got)
+	}
+}
+
+func TestDropDuplicateAdditionsRestartsAtFirstRun(t *testing.T) {
+	history := HistoryData{Days: []DayResult{
+		{Date: "2024-05-01", FirstRun: true, AddedOracles: []string{"a"}},
+		{Date: "2024-05-02", AddedOracles: []string{"b"}},
+		{Date: "2024-05-03", FirstRun: true, AddedOracles: []string{"a", "b"}},
+	}}
+	if _, duplicates := dropDuplicateAdditions(history); len(duplicates) != 0 {
+		t.Errorf("a new baseline's cards reported as duplicates: %+v", duplicates)
+	}
+}
+
+func TestDuplicateAdditionsRenderOnce(t *testing.T) {
+	dir := t.TempDir()
+	options := testRenderOptions(t, dir)
+	cardLookup := map[string]Card{"p1": testCardPrinting("p1", "twice", "Shown Once", "tst", "1")}
+	history, _ := dropDuplicateAdditions(HistoryData{Days: []DayResult{
+		{Date: "2024-05-01", FirstRun: true, TotalCards: 100},
+		{Date: "2024-05-02", AddedOracles: []string{"twice"}},
+		{Date: "2024-05-03", AddedOracles: []string{"twice"}},
+	}})
+	data := convertToDisplayData(history, cardLookup, groupCardsByOracle(cardLookup), options.CardOrder, nil)
+
+	var dates []string
+	for _, day := range data.Days {
+		for _, card := range day.Cards {
+			if card.OracleID == "twice" {
+				dates = append(dates, day.Date)
+			}
+		}
+	}
+	if want := []string{"2024-05-02"}; !reflect.DeepEqual(dates, want) {
+		t.Errorf("card shown on %v, want %v", dates, want)
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// exportSchemaVersion is bumped whenever a column is added, removed or changes meaning
+const exportSchemaVersion = 1
+
+// Event types of rows in cards.csv
+const (
+	exportEventAdded = "added"
+)
+
+// Columns of the exported tables, in file order
+var (
+	exportCardColumns = []string{
+		"event", "date", "oracle_id", "card_id", "name", "mana_cost", "cmc", "type_line",
+		"colors", "rarity", "set", "set_name", "released_at", "first_run",
+	}
+	exportDayColumns = []string{
+		"date", "added_count", "total_cards", "first_run",
+	}
+)
+
+// ExportManifest describes the exported files in manifest.json
+type ExportManifest struct {
+	SchemaVersion int                 `json:"schema_version"`
+	Tables        map[string][]string `json:"tables"`
+}
+
+// exportTable writes rows to a CSV file and optionally to a newline-delimited JSON file
+type exportTable struct {
+	columns []string
+	files   []*os.File
+	csv     *csv.Writer
+	ndjson  *json.Encoder
+}
+
+func newExportTable(outputDir, name string, columns []string, ndjson bool) (*exportTable, error) {
+	table := &exportTable{columns: columns}
+
+	csvFile, err := os.Create(filepath.Join(outputDir, name+".csv"))
+	if err != nil {
+		return nil, err
+	}
+	table.files = append(table.files, csvFile)
+	table.csv = csv.NewWriter(csvFile)
+	if err := table.csv.Write(columns); err != nil {
+		table.Close()
+		return nil, err
+	}
+
+	if ndjson {
+		jsonFile, err := os.Create(filepath.Join(outputDir, name+".ndjson"))
+		if err != nil {
+			table.Close()
+			return nil, err
+		}
+		table.files = append(table.files, jsonFile)
+		table.ndjson = json.NewEncoder(jsonFile)
+	}
+
+	return table, nil
+}
+
+// Write appends a row; values must be in column order
+func (t *exportTable) Write(values []string) error {
+	if err := t.csv.Write(values); err != nil {
+		return err
+	}
+	if t.ndjson == nil {
+		return nil
+	}
+
+	row := make(map[string]string, len(t.columns))
+	for i, column := range t.columns {
+		row[column] = values[i]
+	}
+	return t.ndjson.Encode(row)
+}
+
+// Close flushes buffered rows and closes the files
+func (t *exportTable) Close() error {
+	var firstErr error
+	if t.csv != nil {
+		t.csv.Flush()
+		firstErr = t.csv.Error()
+	}
+	for _, file := range t.files {
+		if err := file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// runExport implements the "export" subcommand: flat cards/days tables for analysis tools
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	outputDir := flags.String("out", "export", "Directory to write cards.csv, days.csv and manifest.json to")
+	ndjson := flags.Bool("ndjson", false, "Also write cards.ndjson and days.ndjson")
+	cardsFile := flags.String("cards", defaultCardsFile, "Card cache used to resolve card attributes")
+	logging := addLogFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: go run ./cmd/renderer export [flags] <history.json>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if err := logging.setup(); err != nil {
+		return err
+	}
+	if flags.NArg() < 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	history, err := loadHistory(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("loading history: %w", err)
+	}
+
+	slog.Info("Loading default cards from cache")
+	cardLookup, err := loadCardLookup(*cardsFile)
+	if err != nil {
+		return fmt.Errorf("loading default cards: %w", err)
+	}
+
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		return err
+	}
+
+	if err := exportHistory(history, cardLookup, groupCardsByOracle(cardLookup), *outputDir, *ndjson); err != nil {
+		return err
+	}
+
+	slog.Info("Exported history", "days", len(history.Days), "dir", *outputDir)
+	return nil
+}
+
+// exportHistory streams one row per (date, oracle_id) event and one row per day
+func exportHistory(history HistoryData, cardLookup map[string]Card, cardsByOracle map[string][]Card, outputDir string, ndjson bool) error {
+	cardsTable, err := newExportTable(outputDir, "cards", exportCardColumns, ndjson)
+	if err != nil {
+		return err
+	}
+	defer cardsTable.Close()
+
+	daysTable, err := newExportTable(outputDir, "days", exportDayColumns, ndjson)
+	if err != nil {
+		return err
+	}
+	defer daysTable.Close()
+
+	for _, day := range history.Days {
+		firstRun := strconv.FormatBool(day.FirstRun)
+		added := 0
+
+		for _, oracleID := range day.AddedOracles {
+			card, _ := selectDayCard(day, oracleID, cardLookup, cardsByOracle[oracleID])
+			if err := cardsTable.Write(exportCardRow(exportEventAdded, day.Date, oracleID, card, firstRun)); err != nil {
+				return err
+			}
+			added++
+		}
+
+		// Legacy printing ids that couldn't be translated to oracle ids
+		for _, cardID := range day.UnresolvedCards {
+			card, found := cardLookup[cardID]
+			if !found {
+				card = Card{ID: cardID}
+			}
+			if err := cardsTable.Write(exportCardRow(exportEventAdded, day.Date, card.OracleID, card, firstRun)); err != nil {
+				return err
+			}
+			added++
+		}
+
+		if err := daysTable.Write([]string{day.Date, strconv.Itoa(added), strconv.Itoa(day.TotalCards), firstRun}); err != nil {
+			return err
+		}
+	}
+
+	if err := cardsTable.Close(); err != nil {
+		return err
+	}
+	if err := daysTable.Close(); err != nil {
+		return err
+	}
+
+	tables := map[string][]string{
+		"cards": exportCardColumns,
+		"days":  exportDayColumns,
+	}
+	return writeJSONFile(filepath.Join(outputDir, "manifest.json"), ExportManifest{
+		SchemaVersion: exportSchemaVersion,
+		Tables:        tables,
+	})
+}
+
+// exportCardRow flattens a card into exportCardColumns order; unresolved cards leave attributes empty
+func exportCardRow(event, date, oracleID string, card Card, firstRun string) []string {
+	cmc := ""
+	if card.Name != "" {
+		cmc = strconv.FormatFloat(card.CMC, 'f', -1, 64)
+	}
+
+	return []string{
+		event,
+		date,
+		oracleID,
+		card.ID,
+		card.Name,
+		card.ManaCost,
+		cmc,
+		card.TypeLine,
+		strings.Join(cardColors(card), ""),
+		card.Rarity,
+		card.Set,
+		card.SetName,
+		card.ReleasedAt,
+		firstRun,
+	}
+}
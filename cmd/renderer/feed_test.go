@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+// hostileNames break a feed that inserts them without escaping, inside or outside CDATA
+var hostileNames = []string{
+	"Ends ]]> the Section",
+	"<script>alert(1)</script>",
+	"Fish & Chips",
+	`"Quoted" 'Name'`,
+	"]]]]><![CDATA[>",
+	"Nested <![CDATA[ Start",
+}
+
+func TestFeedWithHostileNamesIsWellFormed(t *testing.T) {
+	for _, minify := range []bool{false, true} {
+		dir := t.TempDir()
+		options := testRenderOptions(t, dir)
+		options.Minify = minify
+
+		cardLookup := make(map[string]Card)
+		var added []string
+		for i, name := range hostileNames {
+			card := testCardPrinting(string(rune('a'+i)), "oracle-"+name, name, "h&x", "1]]>")
+			card.SetName = "Set ]]> & <Name>"
+			card.TypeLine = "Creature — <Horror> & ]]>"
+			card.ImageURIs["normal"] = "https://cards.scryfall.io/normal/front/x.jpg?a=1&b=]]>"
+			cardLookup[card.ID] = card
+			added = append(added, card.OracleID)
+		}
+		history := HistoryData{Days: []DayResult{
+			{Date: "2024-05-01", FirstRun: true, TotalCards: 100},
+			{Date: "2024-05-02", AddedOracles: added, Sets: []SetCount{{Code: "h&x", Name: "Set ]]> & <Name>", Count: len(added)}}, Renamed: []NameChange{{OracleID: "x", OldName: "Old ]]> & <b>", NewName: "New ]]>"}}},
+		}}
+		data := convertToDisplayData(history, cardLookup, groupCardsByOracle(cardLookup), options.CardOrder, nil)
+		if err := generateRSS(data, dir, options); err != nil {
+			t.Fatal(err)
+		}
+
+		feed := readOutput(t, dir, "feed.xml")
+		text := decodeXMLText(t, feed)
+		for _, name := range hostileNames {
+			if !strings.Contains(text, name) {
+				t.Errorf("minify %v: feed text is missing %q", minify, name)
+			}
+		}
+		if !strings.Contains(text, "Set ]]> & <Name>") {
+			t.Errorf("minify %v: item title lost the set name", minify)
+		}
+	}
+}
+
+// decodeXMLText checks that content is well-formed XML and returns all of its character data
+func decodeXMLText(t *testing.T, content string) string {
+	t.Helper()
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	decoder.Strict = true
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			line, _ := decoder.InputPos()
+			lines := strings.Split(content, "\n")
+			context := ""
+			if line > 0 && line <= len(lines) {
+				context = lines[line-1]
+			}
+			t.Fatalf("feed is not well-formed: %v\nline %d: %s", err, line, context)
+		}
+		if data, ok := token.(xml.CharData); ok {
+			text.Write(data)
+		}
+	}
+	return text.String()
+}
+
+func TestEscapeCDATA(t *testing.T) {
+	tests := map[string]string{
+		"plain":        "plain",
+		"a]]>b":        "a]]]]><![CDATA[>b",
+		"]]>]]>":       "]]]]><![CDATA[>]]]]><![CDATA[>",
+		"]]":           "]]",
+		"<![CDATA[ x ": "<![CDATA[ x ",
+	}
+	for text, want := range tests {
+		if got := escapeCDATA(text); got != want {
+			t.Errorf("escapeCDATA(%q) = %q, want %q", text, got, want)
+		}
+		// Wrapped in a section, the text reads back as it was
+		var decoded struct {
+			Text string `xml:",chardata"`
+		}
+		if err := xml.Unmarshal([]byte("<d><![CDATA["+escapeCDATA(text)+"]]></d>"), &decoded); err != nil || decoded.Text != text {
+			t.Errorf("escapeCDATA(%q) reads back as %q, %v", text, decoded.Text, err)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// colorFilterNames are the data-color values of the card elements, by getColorOrder bucket
+var colorFilterNames = [...]string{"w", "u", "b", "r", "g", "multi", "colorless"}
+
+// colorFilter returns the color a card is filtered by on the pages: one of w/u/b/r/g for mono-colored
+// cards, "multi" or "colorless"
+func colorFilter(colors []string) string {
+	return colorFilterNames[getColorOrder(colors)]
+}
+
+// filterScript is docs/filter.js. It adds the color filter bar above the days, which hides the cards
+// of the colors that are not selected and can move each day's mythics and rares first. Without it
+// the pages show every card in the usual order.
+const filterScript = `(function () {
+    var colors = [["w", "White"], ["u", "Blue"], ["b", "Black"], ["r", "Red"], ["g", "Green"], ["multi", "Multicolor"], ["colorless", "Colorless"]];
+    var cards = document.querySelectorAll(".card[data-color]");
+    var header = document.querySelector(".header");
+    if (cards.length === 0 || !header) {
+        return;
+    }
+    var selected = {};
+
+    var bar = document.createElement("div");
+    bar.className = "color-filter";
+    colors.forEach(function (color) {
+        var button = document.createElement("button");
+        button.type = "button";
+        button.className = "color-filter-button color-" + color[0];
+        button.textContent = color[1];
+        button.setAttribute("aria-pressed", "false");
+        button.addEventListener("click", function () {
+            selected[color[0]] = !selected[color[0]];
+            button.setAttribute("aria-pressed", selected[color[0]] ? "true" : "false");
+            apply();
+        });
+        bar.appendChild(button);
+    });
+
+    // Mythics, then rares, then the rest, each in the usual order, which is restored when toggled off
+    var rarityRank = {"mythic": 0, "rare": 1};
+    var grids = document.querySelectorAll(".cards");
+    grids.forEach(function (grid) {
+        Array.prototype.forEach.call(grid.children, function (card, i) {
+            card.setAttribute("data-order", i);
+        });
+    });
+    var rareFirst = document.createElement("button");
+    rareFirst.type = "button";
+    rareFirst.className = "color-filter-button rarity-sort";
+    rareFirst.textContent = "Mythics & rares first";
+    rareFirst.setAttribute("aria-pressed", "false");
+    rareFirst.addEventListener("click", function () {
+        var on = rareFirst.getAttribute("aria-pressed") !== "true";
+        rareFirst.setAttribute("aria-pressed", on ? "true" : "false");
+        grids.forEach(function (grid) {
+            var children = Array.prototype.slice.call(grid.children);
+            children.sort(function (a, b) {
+                if (on) {
+                    var rankA = rarityRank[a.getAttribute("data-rarity")], rankB = rarityRank[b.getAttribute("data-rarity")];
+                    rankA = rankA === undefined ? 2 : rankA;
+                    rankB = rankB === undefined ? 2 : rankB;
+                    if (rankA !== rankB) {
+                        return rankA - rankB;
+                    }
+                }
+                return a.getAttribute("data-order") - b.getAttribute("data-order");
+            });
+            children.forEach(function (card) {
+                grid.appendChild(card);
+            });
+        });
+    });
+    bar.appendChild(rareFirst);
+    header.parentNode.insertBefore(bar, header.nextSibling);
+
+    // Shows the cards of the selected colors, or all of them when none is selected, and notes how
+    // many cards of each day are shown
+    function apply() {
+        var any = Object.keys(selected).some(function (color) { return selected[color]; });
+        cards.forEach(function (card) {
+            card.style.display = !any || selected[card.getAttribute("data-color")] ? "" : "none";
+        });
+
+        document.querySelectorAll(".day").forEach(function (day) {
+            var count = day.querySelector(".day-header .count");
+            var dayCards = day.querySelectorAll(".card[data-color]");
+            if (!count || dayCards.length === 0) {
+                return;
+            }
+            var visible = 0;
+            dayCards.forEach(function (card) {
+                if (card.style.display !== "none") {
+                    visible++;
+                }
+            });
+
+            var note = count.querySelector(".visible-count");
+            if (!note) {
+                note = document.createElement("span");
+                note.className = "visible-count";
+                count.appendChild(note);
+            }
+            note.textContent = any ? " (" + visible + " of " + dayCards.length + " shown)" : "";
+        });
+    }
+})();
+`
+
+// writeFilterScript writes filter.js next to the pages that load it
+func writeFilterScript(outputDir string) error {
+	return os.WriteFile(filepath.Join(outputDir, "filter.js"), []byte(filterScript), 0644)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+// cardColorPattern matches the filter color of each rendered card and the name it links
+var cardColorPattern = regexp.MustCompile(`(?s)data-color="([^"]*)".*?<a [^>]*title="([^"]*)"`)
+
+func TestCardsHaveFilterColors(t *testing.T) {
+	dir := t.TempDir()
+	options := testRenderOptions(t, dir)
+
+	white := testCardPrinting("p-white", "o-white", "Serra Angel", "tst", "1")
+	white.Colors = []string{"W"}
+	multicolor := testCardPrinting("p-multi", "o-multi", "Azorius Charm", "tst", "2")
+	multicolor.Colors = []string{"W", "U"}
+	colorless := testCardPrinting("p-colorless", "o-colorless", "Sol Ring", "tst", "3")
+	colorless.Colors = []string{}
+	// Double-faced cards are filtered by the colors of their front face
+	doubleFaced := testCardPrinting("p-dfc", "o-dfc", "Tangled Florahedron // Tangled Vale", "tst", "4")
+	doubleFaced.Colors = nil
+	doubleFaced.ImageURIs = nil
+	doubleFaced.Layout = "modal_dfc"
+	doubleFaced.CardFaces = []CardFace{
+		{Name: "Tangled Florahedron", Colors: []string{"G"}, ImageURIs: map[string]string{"normal": "https://cards.scryfall.io/normal/front/dfc.jpg"}},
+		{Name: "Tangled Vale", Colors: []string{}, ImageURIs: map[string]string{"normal": "https://cards.scryfall.io/normal/back/dfc.jpg"}},
+	}
+
+	cardLookup := map[string]Card{}
+	for _, card := range []Card{white, multicolor, colorless, doubleFaced} {
+		cardLookup[card.ID] = card
+	}
+	history := HistoryData{Days: []DayResult{
+		{Date: "2024-05-01", FirstRun: true, TotalCards: 100},
+		{Date: "2024-05-02", AddedOracles: []string{"o-white", "o-multi", "o-colorless", "o-dfc"}},
+	}}
+	data := convertToDisplayData(history, cardLookup, groupCardsByOracle(cardLookup), options.CardOrder, nil)
+	if err := generateHTML(data, dir, options); err != nil {
+		t.Fatal(err)
+	}
+
+	colors := make(map[string]string)
+	for _, match := range cardColorPattern.FindAllStringSubmatch(readOutput(t, dir, "index.html"), -1) {
+		colors[match[2]] = match[1]
+	}
+	want := map[string]string{
+		"Serra Angel":                         "w",
+		"Azorius Charm":                       "multi",
+		"Sol Ring":                            "colorless",
+		"Tangled Florahedron // Tangled Vale": "g",
+	}
+	if !reflect.DeepEqual(colors, want) {
+		t.Errorf("card colors = %v, want %v", colors, want)
+	}
+}
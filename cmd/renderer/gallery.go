@@ -0,0 +1,47 @@
+package main
+
+import (
+	"html/template"
+	"path/filepath"
+)
+
+// selectArtCropURL returns the art-only crop of a card, using the front face for multi-faced cards
+func selectArtCropURL(card Card) string {
+	if url, ok := card.ImageURIs["art_crop"]; ok {
+		return url
+	}
+	for _, face := range card.CardFaces {
+		if url, ok := face.ImageURIs["art_crop"]; ok {
+			return url
+		}
+	}
+	return ""
+}
+
+// generateGallery writes docs/gallery.html, a wall of artworks from the newest days
+func generateGallery(displayData DisplayData, outputDir string, options RenderOptions) error {
+	// Keep the newest days that actually added cards
+	var days []DisplayDay
+	for _, day := range newestFirst(displayData.Days) {
+		if day.FirstRun || len(day.Cards) == 0 {
+			continue
+		}
+		days = append(days, day)
+		if len(days) == options.GalleryDays {
+			break
+		}
+	}
+
+	funcMap := template.FuncMap{
+		"art": func(url string) string {
+			return options.ImageProxy.Rewrite(url, galleryImageWidth)
+		},
+	}
+
+	t := template.New("gallery").Funcs(options.Site.funcMap()).Funcs(funcMap)
+	if err := options.Templates.parseHTML(t, "gallery.html.tmpl"); err != nil {
+		return err
+	}
+
+	return writeTemplateFile(filepath.Join(outputDir, "gallery.html"), t, days, options.Minify)
+}
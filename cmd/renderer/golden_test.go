@@ -0,0 +1,168 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "Rewrite the golden files of testdata/ from the rendered fixture site")
+
+// renderFixtureSite renders the pages and feeds of testdata/site with the built-in templates to dir
+// and returns the names of the files written from templates
+func renderFixtureSite(t *testing.T, dir string, minify bool) []string {
+	t.Helper()
+	history, err := loadHistory(filepath.Join("testdata", "site", "history.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cardLookup, err := loadCardLookup(filepath.Join("testdata", "site", "cards.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := testRenderOptions(t, dir)
+	options.Minify = minify
+	cardsByOracle := groupCardsByOracle(cardLookup)
+	data := convertToDisplayData(history, cardLookup, cardsByOracle, options.CardOrder, nil)
+
+	steps := []func() error{
+		func() error { return generateHTML(data, dir, options) },
+		func() error { return generateDayPages(data, dir, options, nil) },
+		func() error { return generateRSS(data, dir, options) },
+		func() error { return generateJSONFeed(data, dir, options) },
+		func() error { return generateSearchPage(dir, options) },
+		func() error { return generateGallery(data, dir, options) },
+		func() error { return generateStats(history, cardLookup, cardsByOracle, dir, options) },
+		func() error {
+			return generateSincePages(buildCheckpoints(history, cardLookup, options), data, dir, options)
+		},
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names := []string{"index.html", "feed.xml", "feed.json", "search.html", "gallery.html", "stats.html"}
+	for _, pattern := range []string{"day/*.html", "since/*.html"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, match := range matches {
+			name, _ := filepath.Rel(dir, match)
+			names = append(names, filepath.ToSlash(name))
+		}
+	}
+	return names
+}
+
+// checkGolden compares the named files of dir with those of golden, or rewrites golden with -update
+func checkGolden(t *testing.T, dir string, golden string, names []string) {
+	t.Helper()
+	if *update {
+		if err := os.RemoveAll(golden); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range names {
+			filename := filepath.Join(golden, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filename, []byte(readOutput(t, dir, name)), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return
+	}
+
+	var expected []string
+	err := filepath.Walk(golden, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		name, _ := filepath.Rel(golden, path)
+		expected = append(expected, filepath.ToSlash(name))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(expected)
+	rendered := append([]string{}, names...)
+	sort.Strings(rendered)
+	if len(expected) != len(rendered) {
+		t.Errorf("rendered %v, golden files are %v", rendered, expected)
+	}
+
+	for _, name := range names {
+		want, err := os.ReadFile(filepath.Join(golden, filepath.FromSlash(name)))
+		if err != nil {
+			t.Errorf("%s: no golden file, run go test -update: %v", name, err)
+			continue
+		}
+		if got := readOutput(t, dir, name); got != string(want) {
+			t.Errorf("%s differs from %s, run go test -update and review the diff", name, filepath.Join(golden, name))
+		}
+	}
+}
+
+// TestGoldenSite keeps the output of the built-in templates byte for byte as it was when they moved out
+// of main.go, apart from deliberate changes committed with their golden files
+func TestGoldenSite(t *testing.T) {
+	dir := t.TempDir()
+	names := renderFixtureSite(t, dir, false)
+	checkGolden(t, dir, filepath.Join("testdata", "golden"), names)
+}
+
+func TestTemplateOverrides(t *testing.T) {
+	overrides := t.TempDir()
+	custom := "<h1>{{format}} Chronicle</h1>\n<footer>{{len .Days}} days</footer>\n"
+	if err := os.WriteFile(filepath.Join(overrides, "index.html.tmpl"), []byte(custom), 0644); err != nil {
+		t.Fatal(err)
+	}
+	templates, err := loadTemplates(overrides)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	options := testRenderOptions(t, dir)
+	options.Templates = templates
+	history := HistoryData{Days: []DayResult{{Date: "2024-05-01", FirstRun: true, TotalCards: 1000}}}
+	data := convertToDisplayData(history, map[string]Card{}, map[string][]Card{}, options.CardOrder, nil)
+	if err := generateHTML(data, dir, options); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readOutput(t, dir, "index.html"), "<h1>Brawl Chronicle</h1>\n<footer>1 days</footer>\n"; got != want {
+		t.Errorf("index.html = %q, want %q", got, want)
+	}
+	if err := generateSearchPage(dir, options); err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(filepath.Join("testdata", "golden", "search.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if readOutput(t, dir, "search.html") != string(want) {
+		t.Error("search.html isn't overridden and should be the built-in page")
+	}
+}
+
+func TestTemplateSyntaxErrorNamesFileAndLine(t *testing.T) {
+	overrides := t.TempDir()
+	filename := filepath.Join(overrides, "stats.html.tmpl")
+	if err := os.WriteFile(filename, []byte("<h1>Stats</h1>\n{{end}}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := loadTemplates(overrides)
+	if err == nil {
+		t.Fatal("a stray {{end}} loaded")
+	}
+	if message := err.Error(); !strings.Contains(message, filename) || !strings.Contains(message, ":2:") {
+		t.Errorf("error %q should name %s and line 2", message, filename)
+	}
+}
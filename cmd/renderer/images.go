@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Image widths requested from the image proxy for each output
+const (
+	htmlImageWidth    = 488 // Card grid, matches Scryfall's "normal" size
+	rssImageWidth     = 400 // Feed items are displayed at 200px, doubled for high-DPI screens
+	searchImageWidth  = 488
+	galleryImageWidth = 400
+)
+
+// scryfallImageSizes are the sizes of Scryfall card images offered in srcset, smallest first
+var scryfallImageSizes = []struct {
+	Name   string
+	Width  int
+	Height int
+}{
+	{"small", 146, 204},
+	{"normal", 488, 680},
+	{"large", 672, 936},
+}
+
+// ImageCandidate is one size of a card image, a candidate of the srcset attribute
+type ImageCandidate struct {
+	URL   string
+	Width int
+}
+
+// imageCandidates lists the Scryfall sizes a card image is available in, smallest first
+func imageCandidates(imageURIs map[string]string) []ImageCandidate {
+	var candidates []ImageCandidate
+	for _, size := range scryfallImageSizes {
+		if url, ok := imageURIs[size.Name]; ok && url != "" {
+			candidates = append(candidates, ImageCandidate{URL: url, Width: size.Width})
+		}
+	}
+	return candidates
+}
+
+// Srcset returns the srcset attribute of the candidates, each through the proxy at its own width.
+// A single size makes no choice, so it returns "" and the src alone is used.
+func (p ImageProxy) Srcset(candidates []ImageCandidate) string {
+	if len(candidates) < 2 {
+		return ""
+	}
+	var parts []string
+	for _, candidate := range candidates {
+		parts = append(parts, p.Rewrite(candidate.URL, candidate.Width)+" "+strconv.Itoa(candidate.Width)+"w")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// cardImageURIs returns the images of the card, or of its front face for cards whose images only
+// the faces have: transforming and modal double-faced cards
+func cardImageURIs(card Card) map[string]string {
+	if card.ImageURIs == nil && len(card.CardFaces) > 0 {
+		return card.CardFaces[0].ImageURIs
+	}
+	return card.ImageURIs
+}
+
+// ImageProxy rewrites image URLs through a resizing proxy such as
+// "https://images.weserv.nl/?url={url}&w={width}". The zero value leaves URLs unchanged.
+type ImageProxy struct {
+	Template string
+	Mirrored map[string]string // Public addresses of the images copied by -mirror-images, by source URL
+}
+
+// NewImageProxy validates the proxy template; an empty template disables rewriting
+func NewImageProxy(tmpl string) (ImageProxy, error) {
+	if tmpl != "" && !strings.Contains(tmpl, "{url}") {
+		return ImageProxy{}, fmt.Errorf("image proxy template %q must contain {url}", tmpl)
+	}
+	return ImageProxy{Template: tmpl}, nil
+}
+
+// Rewrite returns the proxied URL for an image displayed at the given width
+// unless the image is mirrored, which is served as it is
+func (p ImageProxy) Rewrite(imageURL string, width int) string {
+	if mirrored, found := p.Mirrored[imageURL]; found {
+		return mirrored
+	}
+	if p.Template == "" || imageURL == "" {
+		return imageURL
+	}
+
+	replacer := strings.NewReplacer(
+		"{url}", url.QueryEscape(imageURL),
+		"{width}", strconv.Itoa(width),
+	)
+	return replacer.Replace(p.Template)
+}
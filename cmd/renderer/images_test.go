@@ -0,0 +1,170 @@
+package main
+
+import "testing"
+
+const weservTemplate = "https://images.weserv.nl/?url={url}&w={width}"
+
+func TestNewImageProxy(t *testing.T) {
+	tests := []struct {
+		template string
+		valid    bool
+	}{
+		{"", true},
+		{weservTemplate, true},
+		{"https://proxy.example/{width}/{url}", true},
+		{"https://proxy.example/?w={width}", false},
+		{"https://images.weserv.nl/?url=", false},
+	}
+	for _, test := range tests {
+		proxy, err := NewImageProxy(test.template)
+		if test.valid && (err != nil || proxy.Template != test.template) {
+			t.Errorf("NewImageProxy(%q) = %+v, %v; want the template", test.template, proxy, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("NewImageProxy(%q) accepted a template without {url}", test.template)
+		}
+	}
+}
+
+func TestImageProxyRewriteCardImages(t *testing.T) {
+	const (
+		small  = "https://cards.scryfall.io/small/front/a/1.jpg?1562"
+		normal = "https://cards.scryfall.io/normal/front/a/1.jpg?1562"
+		large  = "https://cards.scryfall.io/large/front/a/1.jpg?1562"
+		face   = "https://cards.scryfall.io/normal/front/b/2.jpg?1700"
+	)
+	tests := []struct {
+		name    string
+		card    Card
+		direct  string // The image without a proxy
+		proxied string // The image through weservTemplate at htmlImageWidth
+	}{
+		{
+			name:    "normal",
+			card:    Card{ImageURIs: map[string]string{"small": small, "normal": normal, "large": large}},
+			direct:  normal,
+			proxied: "https://images.weserv.nl/?url=https%3A%2F%2Fcards.scryfall.io%2Fnormal%2Ffront%2Fa%2F1.jpg%3F1562&w=488",
+		},
+		{
+			name:    "large without normal",
+			card:    Card{ImageURIs: map[string]string{"small": small, "large": large}},
+			direct:  large,
+			proxied: "https://images.weserv.nl/?url=https%3A%2F%2Fcards.scryfall.io%2Flarge%2Ffront%2Fa%2F1.jpg%3F1562&w=488",
+		},
+		{
+			name:    "small only",
+			card:    Card{ImageURIs: map[string]string{"small": small}},
+			direct:  small,
+			proxied: "https://images.weserv.nl/?url=https%3A%2F%2Fcards.scryfall.io%2Fsmall%2Ffront%2Fa%2F1.jpg%3F1562&w=488",
+		},
+		{
+			name: "face images",
+			card: Card{Layout: "transform", CardFaces: []CardFace{
+				{Name: "Front", ImageURIs: map[string]string{"normal": face}},
+				{Name: "Back", ImageURIs: map[string]string{"normal": normal}},
+			}},
+			direct:  face,
+			proxied: "https://images.weserv.nl/?url=https%3A%2F%2Fcards.scryfall.io%2Fnormal%2Ffront%2Fb%2F2.jpg%3F1700&w=488",
+		},
+		{
+			name: "art crop only",
+			card: Card{ImageURIs: map[string]string{"art_crop": "https://cards.scryfall.io/art_crop/front/a/1.jpg"}},
+		},
+		{
+			name: "missing",
+			card: Card{Name: "Unknown Card"},
+		},
+		{
+			name: "faces without images",
+			card: Card{Layout: "adventure", CardFaces: []CardFace{{Name: "Giant"}, {Name: "Stomp"}}},
+		},
+	}
+	proxy, err := NewImageProxy(weservTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			image := selectImageURL(cardImageURIs(test.card))
+			if got := (ImageProxy{}).Rewrite(image, htmlImageWidth); got != test.direct {
+				t.Errorf("without a proxy = %q, want %q", got, test.direct)
+			}
+			// A missing image stays missing rather than becoming a proxy URL without a source
+			if got := proxy.Rewrite(image, htmlImageWidth); got != test.proxied {
+				t.Errorf("through the proxy = %q, want %q", got, test.proxied)
+			}
+		})
+	}
+}
+
+func TestImageProxyRewriteEscaping(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		image    string
+		width    int
+		want     string
+	}{
+		{
+			name:     "query string",
+			template: weservTemplate,
+			image:    "https://cards.scryfall.io/normal/front/a/1.jpg?1562&x=y",
+			width:    rssImageWidth,
+			want:     "https://images.weserv.nl/?url=https%3A%2F%2Fcards.scryfall.io%2Fnormal%2Ffront%2Fa%2F1.jpg%3F1562%26x%3Dy&w=400",
+		},
+		{
+			name:     "spaces and unicode",
+			template: weservTemplate,
+			image:    "https://example.com/cards/Jötun Grunt.jpg",
+			width:    galleryImageWidth,
+			want:     "https://images.weserv.nl/?url=https%3A%2F%2Fexample.com%2Fcards%2FJ%C3%B6tun+Grunt.jpg&w=400",
+		},
+		{
+			name:     "url in the path",
+			template: "https://proxy.example/{width}/{url}",
+			image:    "https://cards.scryfall.io/large/front/a/1.jpg",
+			width:    672,
+			want:     "https://proxy.example/672/https%3A%2F%2Fcards.scryfall.io%2Flarge%2Ffront%2Fa%2F1.jpg",
+		},
+		{
+			name:     "placeholders in the image",
+			template: weservTemplate,
+			image:    "https://example.com/{width}/{url}.jpg",
+			width:    488,
+			want:     "https://images.weserv.nl/?url=https%3A%2F%2Fexample.com%2F%7Bwidth%7D%2F%7Burl%7D.jpg&w=488",
+		},
+		{
+			name:  "no proxy",
+			image: "https://cards.scryfall.io/normal/front/a/1.jpg?1562&x=y",
+			width: 488,
+			want:  "https://cards.scryfall.io/normal/front/a/1.jpg?1562&x=y",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			proxy, err := NewImageProxy(test.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := proxy.Rewrite(test.image, test.width); got != test.want {
+				t.Errorf("Rewrite(%q, %d) = %q, want %q", test.image, test.width, got, test.want)
+			}
+		})
+	}
+}
+
+// Mirrored images are served as they are, whatever the proxy
+func TestImageProxyRewriteMirrored(t *testing.T) {
+	const image = "https://cards.scryfall.io/normal/front/a/1.jpg"
+	proxy, err := NewImageProxy(weservTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy.Mirrored = map[string]string{image: "https://example.com/img/1.jpg"}
+	if got := proxy.Rewrite(image, htmlImageWidth); got != "https://example.com/img/1.jpg" {
+		t.Errorf("mirrored image rewritten to %q", got)
+	}
+	if got := proxy.Rewrite(image+"?2", htmlImageWidth); got != "https://images.weserv.nl/?url=https%3A%2F%2Fcards.scryfall.io%2Fnormal%2Ffront%2Fa%2F1.jpg%3F2&w=488" {
+		t.Errorf("image that isn't mirrored rewritten to %q", got)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	text_template "text/template"
+	"time"
+)
+
+// jsonFeedVersion identifies the JSON Feed format, see https://www.jsonfeed.org/version/1.1/
+const jsonFeedVersion = "https://jsonfeed.org/version/1.1"
+
+// JSONFeed is docs/feed.json, the RSS feed for consumers that would rather not parse XML
+type JSONFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Description string         `json:"description"`
+	Language    string         `json:"language"`
+	Items       []JSONFeedItem `json:"items"`
+}
+
+// JSONFeedItem is one day, with the same id, title and content as its RSS item
+type JSONFeedItem struct {
+	ID            string            `json:"id"`
+	URL           string            `json:"url"`
+	Title         string            `json:"title"`
+	ContentHTML   string            `json:"content_html"`
+	Image         string            `json:"image,omitempty"` // Art of the first new card
+	DatePublished string            `json:"date_published"`
+	Chronicle     JSONFeedExtension `json:"_brawl_chronicle"`
+}
+
+// JSONFeedExtension lists all of the day's cards for bots and dashboards, so they don't have to scrape
+// content_html, whose images are capped like the RSS
+type JSONFeedExtension struct {
+	Date       string         `json:"date"`
+	FirstRun   bool           `json:"first_run,omitempty"`
+	Cards      []JSONFeedCard `json:"cards"`
+	NowOnArena []JSONFeedCard `json:"now_on_arena,omitempty"`
+	Banned     []JSONFeedCard `json:"banned,omitempty"`
+	Unbanned   []JSONFeedCard `json:"unbanned,omitempty"`
+	Removed    []JSONFeedCard `json:"removed,omitempty"`
+}
+
+// JSONFeedCard identifies a card in the extension object
+type JSONFeedCard struct {
+	Name        string `json:"name"`
+	OracleID    string `json:"oracle_id"`
+	ScryfallURL string `json:"scryfall_url"`
+}
+
+// generateJSONFeed writes docs/feed.json with the items of feed.xml
+func generateJSONFeed(data DisplayData, outputDir string, options RenderOptions) error {
+	content := text_template.New("content").Funcs(options.Site.funcMap()).Funcs(text_template.FuncMap{
+		"thousands": addThousandsSeparator,
+		"image": func(card DisplayCard) string {
+			return feedImageURL(card, options.ImageProxy)
+		},
+	})
+	if err := options.Templates.parseText(content, "feed-content.html.tmpl"); err != nil {
+		return err
+	}
+
+	feed := JSONFeed{
+		Version:     jsonFeedVersion,
+		Title:       options.Site.FormatName + " Chronicle",
+		HomePageURL: options.Site.URL,
+		FeedURL:     options.Site.URL + "feed.json",
+		Description: "Daily tracking of new Magic: The Gathering cards legal in " + options.Site.FormatName + " format",
+		Language:    "en-US",
+		Items:       []JSONFeedItem{},
+	}
+	for _, day := range feedDays(data.Days, options.FeedItems) {
+		var html strings.Builder
+		if err := content.Execute(&html, newFeedItemContent(day)); err != nil {
+			return err
+		}
+		contentHTML := strings.TrimSpace(html.String())
+		if options.Minify {
+			contentHTML = minifyMarkup(contentHTML)
+		}
+		published, err := time.Parse("2006-01-02", day.Date)
+		if err != nil {
+			return err
+		}
+
+		item := JSONFeedItem{
+			ID:            options.Site.feedItemID(day),
+			URL:           options.Site.dayURL(day.Date),
+			Title:         day.FeedTitle(),
+			ContentHTML:   contentHTML,
+			DatePublished: published.Format(time.RFC3339),
+			Chronicle: JSONFeedExtension{
+				Date:       day.Date,
+				FirstRun:   day.FirstRun,
+				Cards:      jsonFeedCards(day.Cards),
+				NowOnArena: jsonFeedCards(day.NowOnArena),
+				Banned:     jsonFeedCards(day.Banned),
+				Unbanned:   jsonFeedCards(day.Unbanned),
+				Removed:    jsonFeedCards(day.Removed),
+			},
+		}
+		if len(day.Cards) > 0 {
+			card := day.Cards[0]
+			art := card.ArtCropURL
+			if art == "" {
+				art = card.ImageURL
+			}
+			item.Image = options.ImageProxy.Rewrite(art, galleryImageWidth)
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	return writeJSONFile(filepath.Join(outputDir, "feed.json"), feed)
+}
+
+// jsonFeedCards lists cards by name, oracle id and Scryfall link
+func jsonFeedCards(cards []DisplayCard) []JSONFeedCard {
+	list := []JSONFeedCard{}
+	for _, card := range cards {
+		list = append(list, JSONFeedCard{Name: card.Name, OracleID: card.OracleID, ScryfallURL: card.ScryfallURL})
+	}
+	return list
+}
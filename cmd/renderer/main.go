@@ -1,9 +1,15 @@
 package main
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"html/template"
+	"log/slog"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
@@ -15,111 +21,562 @@ import (
 
 // Full card data structure for rendering
 type Card struct {
-	ID         string            `json:"id"`
-	OracleID   string            `json:"oracle_id"`
+	ID              string            `json:"id"`
+	OracleID        string            `json:"oracle_id"`
+	Name            string            `json:"name"`
+	ManaCost        string            `json:"mana_cost"`
+	CMC             float64           `json:"cmc"`
+	TypeLine        string            `json:"type_line"`
+	Colors          []string          `json:"colors"`
+	Rarity          string            `json:"rarity"`
+	Set             string            `json:"set"`
+	SetName         string            `json:"set_name"`
+	SetType         string            `json:"set_type"`
+	ReleasedAt      string            `json:"released_at"`
+	CollectorNumber string            `json:"collector_number"`
+	Legalities      map[string]string `json:"legalities"`
+	ImageURIs       map[string]string `json:"image_uris"`
+	Games           []string          `json:"games"`
+	OracleText      string            `json:"oracle_text"`
+	Layout          string            `json:"layout"`
+	CardFaces       []CardFace        `json:"card_faces"`
+}
+
+// CardFace holds the per-face data of multi-faced cards (transform, MDFC, split, adventure)
+type CardFace struct {
 	Name       string            `json:"name"`
 	ManaCost   string            `json:"mana_cost"`
-	CMC        float64           `json:"cmc"`
 	TypeLine   string            `json:"type_line"`
+	OracleText string            `json:"oracle_text"`
 	Colors     []string          `json:"colors"`
-	Rarity     string            `json:"rarity"`
-	SetName    string            `json:"set_name"`
-	Legalities map[string]string `json:"legalities"`
 	ImageURIs  map[string]string `json:"image_uris"`
-	Games      []string          `json:"games"`
 }
 
 // Updated data structure to match fetcher oracle format
 type DayResult struct {
-	Date         string            `json:"date"`
-	AddedOracles []string          `json:"added_oracles"`
-	CardMapping  map[string]string `json:"card_mapping"`
-	TotalCards   int               `json:"total_cards"`
-	FirstRun     bool              `json:"first_run"`
-	
+	Date         string                    `json:"date"`
+	AddedOracles []string                  `json:"added_oracles"`
+	CardMapping  map[string]PinnedPrinting `json:"card_mapping"`
+	TotalCards   int                       `json:"total_cards"`
+	FirstRun     bool                      `json:"first_run"`
+	ArenaAdded   []string                  `json:"arena_added"` // Known oracle_ids that became available on Arena
+
+	// Sets the added cards are from, largest first
+	Sets []SetCount `json:"sets"`
+
+	// Added cards by color bucket and rarity
+	Stats *DayStats `json:"stats"`
+
+	// Known oracle_ids that are no longer legal
+	RemovedOracles []string `json:"removed_oracles"`
+
+	// Legality status transitions of known cards, e.g. legal -> banned
+	LegalityChanges []LegalityChange `json:"legality_changes"`
+
+	// Known cards whose Oracle name changed
+	Renamed []NameChange `json:"renamed"`
+
+	// Scryfall Tagger tags per added oracle_id
+	Tags map[string][]string `json:"tags"`
+
+	// Added oracle_ids that were on the fetcher's watchlist
+	WatchlistHits []string `json:"watchlist_hits"`
+
+	// Cards previewed on Scryfall that were not legal yet
+	Previews []PreviewCard `json:"previews"`
+
 	// Legacy support for old format
 	AddedCards []string `json:"added_cards"`
+
+	// Legacy printing ids the fetcher couldn't translate to oracle_ids
+	UnresolvedCards []string `json:"unresolved_cards"`
+}
+
+// UnmarshalJSON also reads arena_added under now_on_arena, its name in histories written before it
+// was renamed
+func (day *DayResult) UnmarshalJSON(data []byte) error {
+	// Without its methods the type decodes as a plain struct instead of recursing
+	type plain DayResult
+	var decoded struct {
+		plain
+		NowOnArena []string `json:"now_on_arena"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*day = DayResult(decoded.plain)
+	if len(day.ArenaAdded) == 0 {
+		day.ArenaAdded = decoded.NowOnArena
+	}
+	return nil
+}
+
+// LegalityChange is a card's legality moving between two statuses
+type LegalityChange struct {
+	OracleID string `json:"oracle_id"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+}
+
+// NameChange is a known card renamed in the Oracle; cards are always shown with their current name
+type NameChange struct {
+	OracleID string `json:"oracle_id"`
+	OldName  string `json:"old_name"`
+	NewName  string `json:"new_name"`
 }
 
 type HistoryData struct {
-	Days []DayResult `json:"days"`
+	SchemaVersion int         `json:"schema_version"` // See historySchemaVersion
+	Meta          HistoryMeta `json:"meta"`
+	Days          []DayResult `json:"days"`
+}
+
+// HistoryMeta describes how the fetcher built the history and the card cache
+type HistoryMeta struct {
+	Format   string `json:"format"`
+	BulkType string `json:"bulk_type"`
+	Games    string `json:"games"` // Game the tracked printings are limited to, e.g. "arena"
 }
 
+// perPrinting reports whether the card cache has every printing, so selectBestCard can rely on
+// per-printing fields such as games. An oracle_cards cache has one representative printing per card.
+func (meta HistoryMeta) perPrinting() bool {
+	return meta.BulkType != "oracle_cards"
+}
+
+// siteURL is the default public address of the generated site, see -base-url
+const siteURL = "https://mikulas.github.io/brawl-chronicle/"
+
+// defaultCardsFile is the cache of cards legal in the tracked formats, written by the fetcher
+const defaultCardsFile = "data/brawl-cards.json"
+
 // Helper struct for template rendering
 type DisplayCard struct {
 	ID          string
+	OracleID    string
 	Name        string
 	ImageURL    string
+	ImageSizes  []ImageCandidate // Every size of ImageURL's image, for srcset; empty for previews
+	ArtCropURL  string
 	ScryfallURL string
+	Set         string
+	SetName     string
+	ReleasedAt  string
+	Tags        []string
+	Watched     bool
 	Colors      []string
 	CMC         float64
+	ManaCost    string // e.g. "{2}{W}{U}", the front face's for cards with faces
+	TypeLine    string
+	Rarity      string
+
+	// Set only for cards from sets releasing after the reference date
+	ReleaseDate      string
+	DaysUntilRelease int
 }
 
 type DisplayDay struct {
 	Date       string
 	Cards      []DisplayCard
+	NowOnArena []DisplayCard
+	Removed    []DisplayCard // Cards that are no longer legal, other than bans
+	Banned     []DisplayCard
+	Unbanned   []DisplayCard // Banned cards that became legal again, not repeated in Cards
+	Previews   []DisplayCard // Previewed cards that are not legal yet
+	Renamed    []NameChange
+	Sets       []SetCount    // Sets the new cards are from, largest first
+	CardGroups []CardGroup   `json:"-"` // Cards by set on days with cards from several sets, nil otherwise; subslices of Cards
+	Stats      *DayStats     // New cards by color and rarity, nil for days recorded without them
 	TotalCards int
 	FirstRun   bool
 }
 
+// Summary counts the day's events for feed titles, e.g. "3 new cards, 1 banned"
+func (day DisplayDay) Summary() string {
+	events := []struct {
+		count int
+		label string
+	}{
+		{len(day.Cards), "new cards"},
+		{len(day.NowOnArena), "now on Arena"},
+		{len(day.Banned), "banned"},
+		{len(day.Unbanned), "unbanned"},
+		{len(day.Removed), "no longer legal"},
+		{len(day.Renamed), "renamed"},
+		{len(day.Previews), "previewed"},
+	}
+
+	var parts []string
+	for _, event := range events {
+		if event.count == 0 {
+			continue
+		}
+		label := event.label
+		if len(parts) == 0 && label != "new cards" {
+			label = "cards " + label
+		}
+		parts = append(parts, addThousandsSeparator(event.count)+" "+label)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// HasChanges reports whether anything happened on a regular day
+func (day DisplayDay) HasChanges() bool {
+	return len(day.Cards) > 0 || len(day.NowOnArena) > 0 || len(day.Removed) > 0 ||
+		len(day.Banned) > 0 || len(day.Unbanned) > 0 || len(day.Renamed) > 0 || len(day.Previews) > 0
+}
+
 type DisplayData struct {
-	Days []DisplayDay
+	Days        []DisplayDay
+	NextRelease *ReleaseCountdown
+	Social      SocialMeta // Link preview of the page, only set for the index
+}
+
+// RenderOptions holds the command line settings shared by all outputs
+type RenderOptions struct {
+	ImageProxy    ImageProxy
+	GalleryDays   int
+	FeedItems     int // Newest days with an item kept in feed.xml and feed.json
+	Captions      bool // Show name, mana cost and type line under the card images of the HTML pages
+	RotationDate  string
+	ReferenceDate time.Time             // "Today" for release countdowns
+	SetCalendar   map[string]SetRelease // Set release dates by set code
+	Symbology     map[string]ManaSymbol // Images of mana symbols by their text, e.g. "{W}"
+	CardOrder     CardOrder             // Order of the cards of a day, and of the since pages, see -sort
+	Templates     Templates             // Templates of the pages and feeds, see -templates
+	Minify        bool                  // Drop the template's whitespace and comments from the pages and feeds
+	WebSubHub     string                // Hub feed.xml names for push updates, empty for none
+	Site          Site
 }
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: go run render.go <history.json>")
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		if err := runExport(os.Args[2:]); err != nil {
+			slog.Error("Exporting history failed", "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	imageProxy := flag.String("image-proxy", "", "Rewrite image URLs through a proxy, e.g. \"https://images.weserv.nl/?url={url}&w={width}\"")
+	galleryDays := flag.Int("gallery-days", 14, "Number of newest days with new cards shown in the art gallery")
+	feedItems := flag.Int("feed-items", defaultFeedItems, "Number of newest days with changes kept in the RSS and JSON feeds")
+	rotationDate := flag.String("rotation-date", "", "Date of the last rotation (YYYY-MM-DD) for the since/last-rotation.html page")
+	format := flag.String("format", "", "Scryfall format of the history; formats other than brawl render to <out>/<format>/ (default: recorded in the history, else brawl)")
+	outDir := flag.String("out", "docs", "Directory the site is written to")
+	baseURL := flag.String("base-url", siteURL, "Public address of the site, used in feeds, the sitemap and links")
+	cardsFile := flag.String("cards", defaultCardsFile, "Card cache written by the fetcher (<file>.gz is preferred)")
+	resultsDir := flag.String("results", defaultResultsDir, "Directory of the fetcher's daily snapshots, used for cards missing from the card cache")
+	archiveFile := flag.String("archive", "", "Also render the days \"fetcher prune\" moved to this archive, e.g. data/history.archive.json")
+	mirrorDir := flag.String("mirror-images", "", "Download the card images into this directory inside -out, e.g. docs/img/, and link them instead of Scryfall's")
+	strict := flag.Bool("strict", false, "Fail when the history adds a card on two days instead of dropping the later additions, e.g. in CI")
+	sortOrder := flag.String("sort", defaultCardOrder, "Order of each day's cards on the pages and in the feeds: "+cardOrderNames())
+	templatesDir := flag.String("templates", "", "Directory of templates replacing the built-in ones of the same name, e.g. index.html.tmpl")
+	force := flag.Bool("force", false, "Convert every day and write every day page, ignoring the render cache of earlier runs")
+	webSubHub := flag.String("websub-hub", defaultWebSubHub, "WebSub hub that feed.xml names for pushing updates to readers; empty leaves it out")
+	pingHub := flag.Bool("ping-hub", false, "Notify the WebSub hub after a build that changed feed.xml")
+	minify := flag.Bool("minify", false, "Collapse the whitespace the templates leave in the HTML pages and feeds and drop comments")
+	captions := flag.Bool("captions", false, "Show each card's name, mana cost and type line under its image on the HTML pages")
+	referenceDate := flag.String("reference-date", time.Now().UTC().Format("2006-01-02"), "Date (YYYY-MM-DD) release countdowns are computed from")
+	logging := addLogFlags(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: go run ./cmd/renderer [flags] <history.json>")
+		fmt.Fprintln(flag.CommandLine.Output(), "       go run ./cmd/renderer export [flags] <history.json>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := logging.setup(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	historyFile := flag.Arg(0)
 
-	historyFile := os.Args[1]
-	outputDir := "docs"
+	// Fail on bad paths before the slow part
+	if err := checkCardFile(*cardsFile); err != nil {
+		slog.Error("Invalid -cards", "err", err)
+		os.Exit(1)
+	}
+	if parsed, err := url.Parse(*baseURL); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		slog.Error("Invalid -base-url, expected an absolute URL", "base_url", *baseURL)
+		os.Exit(1)
+	}
+	if !strings.HasSuffix(*baseURL, "/") {
+		*baseURL += "/"
+	}
+	if *webSubHub != "" {
+		if parsed, err := url.Parse(*webSubHub); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			slog.Error("Invalid -websub-hub, expected an absolute URL", "websub_hub", *webSubHub)
+			os.Exit(1)
+		}
+	} else if *pingHub {
+		slog.Error("-ping-hub needs a -websub-hub")
+		os.Exit(1)
+	}
+	if err := checkOutputDir(*outDir); err != nil {
+		slog.Error("Invalid -out", "err", err)
+		os.Exit(1)
+	}
+
+	proxy, err := NewImageProxy(*imageProxy)
+	if err != nil {
+		slog.Error("Invalid -image-proxy", "err", err)
+		os.Exit(1)
+	}
+	if *galleryDays < 1 {
+		slog.Error("-gallery-days must be at least 1")
+		os.Exit(1)
+	}
+	if *feedItems < 1 {
+		slog.Error("-feed-items must be at least 1")
+		os.Exit(1)
+	}
+	if *rotationDate != "" {
+		if _, err := time.Parse("2006-01-02", *rotationDate); err != nil {
+			slog.Error("Invalid -rotation-date, expected YYYY-MM-DD", "rotation_date", *rotationDate)
+			os.Exit(1)
+		}
+	}
+	cardOrder, err := parseCardOrder(*sortOrder)
+	if err != nil {
+		slog.Error("Invalid -sort", "err", err)
+		os.Exit(1)
+	}
+	reference, err := time.Parse("2006-01-02", *referenceDate)
+	if err != nil {
+		slog.Error("Invalid -reference-date, expected YYYY-MM-DD", "reference_date", *referenceDate)
+		os.Exit(1)
+	}
+	setCalendar, err := loadSetCalendar(defaultSetCalendarFile)
+	if err != nil {
+		slog.Error("Loading set calendar failed", "err", err)
+		os.Exit(1)
+	}
+	symbology, err := loadSymbology(defaultSymbologyFile)
+	if err != nil {
+		slog.Error("Loading card symbols failed", "err", err)
+		os.Exit(1)
+	}
+	templates, err := loadTemplates(*templatesDir)
+	if err != nil {
+		slog.Error("Invalid -templates", "err", err)
+		os.Exit(1)
+	}
+	options := RenderOptions{
+		ImageProxy:    proxy,
+		GalleryDays:   *galleryDays,
+		FeedItems:     *feedItems,
+		Captions:      *captions,
+		RotationDate:  *rotationDate,
+		ReferenceDate: reference,
+		SetCalendar:   setCalendar,
+		Symbology:     symbology,
+		CardOrder:     cardOrder,
+		Templates:     templates,
+		Minify:        *minify,
+		WebSubHub:     *webSubHub,
+	}
 
 	// Load history
 	history, err := loadHistory(historyFile)
 	if err != nil {
-		fmt.Printf("Error loading history: %v\n", err)
+		slog.Error("Loading history failed", "file", historyFile, "err", err)
+		os.Exit(1)
+	}
+	if *archiveFile != "" {
+		if history, err = includeArchive(history, *archiveFile); err != nil {
+			slog.Error("Including archive failed", "err", err)
+			os.Exit(1)
+		}
+	}
+
+	// A card added on two days would show twice; the history should be cleaned up
+	history, duplicates := dropDuplicateAdditions(history)
+	for _, duplicate := range duplicates {
+		slog.Warn("Card added again while still legal", "oracle_id", duplicate.OracleID, "date", duplicate.Date, "first_added", duplicate.First)
+	}
+	if len(duplicates) > 0 {
+		if *strict {
+			slog.Error("History adds cards on two days", "count", len(duplicates), "file", historyFile)
+			os.Exit(1)
+		}
+		slog.Warn("Dropped repeated additions, keeping the earliest", "count", len(duplicates), "file", historyFile)
+	}
+
+	// The format decides the page titles and where the pages go
+	if *format == "" {
+		*format = history.Meta.Format
+	}
+	if *format == "" {
+		*format = "brawl"
+	}
+	if !formatNamePattern.MatchString(*format) {
+		slog.Error("Invalid -format", "format", *format)
+		os.Exit(1)
+	}
+	if games := history.Meta.Games; games != "" && !formatNamePattern.MatchString(games) {
+		slog.Error("Invalid games in history", "games", games)
 		os.Exit(1)
 	}
+	options.Site = newSite(*format, history.Meta.Games, *outDir, *baseURL)
+	outputDir := options.Site.OutputDir
 
 	// Load default cards from cached file
-	fmt.Println("Loading default cards from cache...")
-	artworkCards, err := loadOracleCards("data/default-cards.json")
+	slog.Info("Loading default cards from cache")
+	cardLookup, err := loadCardLookup(*cardsFile)
 	if err != nil {
-		fmt.Printf("Error loading default cards: %v\n", err)
+		slog.Error("Loading default cards failed", "err", err)
 		os.Exit(1)
 	}
-
-	// Create card lookup map with Arena preference
-	cardLookup := make(map[string]Card)
-	
-	// Group cards by ID and prefer Arena versions
-	for _, card := range artworkCards {
-		existing, exists := cardLookup[card.ID]
-		if !exists || (hasArena(card.Games) && !hasArena(existing.Games)) {
-			cardLookup[card.ID] = card
-		}
+	if !history.Meta.perPrinting() {
+		slog.Info("Card cache has one printing per card (oracle_cards), Arena printings can't be preferred")
+	}
+	if added, err := addSnapshotCards(cardLookup, *resultsDir, trackName(*format, history.Meta.Games)); err != nil {
+		slog.Warn("Could not read result snapshots", "err", err)
+	} else if added > 0 {
+		slog.Info("Restored cards missing from the cache from result snapshots", "count", added)
 	}
 
 	// Create output directory
-	os.MkdirAll(outputDir, 0755)
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		slog.Error("Creating output directory failed", "dir", outputDir, "err", err)
+		os.Exit(1)
+	}
+
+	// Stylesheet and theme toggle shared by the pages of every format
+	if err := writeSharedAssets(*outDir); err != nil {
+		slog.Error("Writing stylesheet failed", "err", err)
+		os.Exit(1)
+	}
+
+	// The pages share one conversion; choosing printings is the expensive part
+	cardsByOracle := groupCardsByOracle(cardLookup)
+	cacheFile := renderCacheFile(historyFile, *format, history.Meta.Games)
+	cache := loadRenderCache(cacheFile, templateHash(options.Templates), renderSettings(options, *sortOrder), *force)
+	displayData := convertToDisplayData(history, cardLookup, cardsByOracle, options.CardOrder, cache)
+
+	// Self-host the images of the pages and feeds; those that fail to download stay hotlinked
+	if *mirrorDir != "" {
+		mirrored, err := mirrorImages(collectImageURLs(displayData), filepath.Clean(*mirrorDir), filepath.Clean(*outDir), *baseURL)
+		if err != nil {
+			slog.Error("Mirroring images failed", "err", err)
+			os.Exit(1)
+		}
+		options.ImageProxy.Mirrored = mirrored
+	}
 
 	// Generate HTML
-	if err := generateHTML(history, cardLookup, outputDir); err != nil {
-		fmt.Printf("Error generating HTML: %v\n", err)
+	if err := generateHTML(displayData, outputDir, options); err != nil {
+		slog.Error("Generating HTML failed", "err", err)
+		os.Exit(1)
+	}
+
+	// Generate a page per day
+	if err := generateDayPages(displayData, outputDir, options, cache); err != nil {
+		slog.Error("Generating day pages failed", "err", err)
+		os.Exit(1)
+	}
+	if err := cache.save(cacheFile); err != nil {
+		slog.Warn("Saving the render cache failed, the next render starts over", "file", cacheFile, "err", err)
+	}
+
+	// Generate RSS feed; the hub is only pinged when it changed
+	feedFile := filepath.Join(outputDir, "feed.xml")
+	previousFeed, _ := os.ReadFile(feedFile)
+	if err := generateRSS(displayData, outputDir, options); err != nil {
+		slog.Error("Generating RSS failed", "err", err)
+		os.Exit(1)
+	}
+
+	// Generate JSON Feed
+	if err := generateJSONFeed(displayData, outputDir, options); err != nil {
+		slog.Error("Generating JSON Feed failed", "err", err)
+		os.Exit(1)
+	}
+
+	// Generate search index and page
+	if err := generateSearch(history, cardLookup, cardsByOracle, outputDir, options); err != nil {
+		slog.Error("Generating search failed", "err", err)
+		os.Exit(1)
+	}
+
+	// Generate art gallery
+	if err := generateGallery(displayData, outputDir, options); err != nil {
+		slog.Error("Generating gallery failed", "err", err)
 		os.Exit(1)
 	}
 
-	// Generate RSS feed
-	if err := generateRSS(history, cardLookup, outputDir); err != nil {
-		fmt.Printf("Error generating RSS: %v\n", err)
+	// Generate statistics page
+	if err := generateStats(history, cardLookup, cardsByOracle, outputDir, options); err != nil {
+		slog.Error("Generating statistics failed", "err", err)
 		os.Exit(1)
 	}
 
-	fmt.Printf("HTML and RSS generated in %s/\n", outputDir)
+	// Generate "what's new since" checkpoint pages
+	checkpoints := buildCheckpoints(history, cardLookup, options)
+	if err := generateSincePages(checkpoints, displayData, outputDir, options); err != nil {
+		slog.Error("Generating checkpoint pages failed", "err", err)
+		os.Exit(1)
+	}
+
+	// Generate sitemap and robots.txt
+	if err := generateSitemap(displayData, checkpoints, outputDir, options); err != nil {
+		slog.Error("Generating sitemap failed", "err", err)
+		os.Exit(1)
+	}
+
+	// Generate markdown changelog
+	if err := generateChangelog(displayData, outputDir, options); err != nil {
+		slog.Error("Generating changelog failed", "err", err)
+		os.Exit(1)
+	}
+
+	// Generate per-set JSON API
+	if err := generateSetAPI(history, cardLookup, cardsByOracle, outputDir, options); err != nil {
+		slog.Error("Generating set API failed", "err", err)
+		os.Exit(1)
+	}
+
+	// Generate per-day JSON API
+	if err := generateDayAPI(displayData, outputDir, options); err != nil {
+		slog.Error("Generating day API failed", "err", err)
+		os.Exit(1)
+	}
+
+	slog.Info("HTML, day pages, RSS, JSON Feed, search, gallery, statistics, checkpoint pages, sitemap, changelog, set API and day API generated", "dir", outputDir, "days", len(history.Days))
+
+	// A hub that can't be reached only delays the readers' updates until their next poll
+	if *pingHub {
+		feed, err := os.ReadFile(feedFile)
+		if err != nil {
+			slog.Warn("Reading the feed to ping the WebSub hub failed", "file", feedFile, "err", err)
+		} else if bytes.Equal(feed, previousFeed) {
+			slog.Info("Feed unchanged, not pinging the WebSub hub")
+		} else if err := pingWebSubHub(options.WebSubHub, options.Site.URL+"feed.xml"); err != nil {
+			slog.Warn("Pinging the WebSub hub failed", "hub", options.WebSubHub, "err", err)
+		} else {
+			slog.Info("Pinged the WebSub hub", "hub", options.WebSubHub, "feed", options.Site.URL+"feed.xml")
+		}
+	}
 }
 
 func loadHistory(filename string) (HistoryData, error) {
+	if dir, sharded := shardedHistoryDir(filename); sharded {
+		history, err := loadShardedHistory(dir)
+		if err != nil {
+			return HistoryData{}, err
+		}
+		if err := history.Validate(); err != nil {
+			return HistoryData{}, err
+		}
+		return upgradeHistory(history), nil
+	}
+
 	file, err := os.Open(filename)
 	if err != nil {
 		return HistoryData{}, err
@@ -130,196 +587,251 @@ func loadHistory(filename string) (HistoryData, error) {
 	if err := json.NewDecoder(file).Decode(&history); err != nil {
 		return HistoryData{}, err
 	}
+	if err := history.Validate(); err != nil {
+		return HistoryData{}, err
+	}
 
-	return history, nil
+	return upgradeHistory(history), nil
 }
 
-func loadOracleCards(filename string) ([]Card, error) {
-	data, err := os.ReadFile(filename)
+// loadCardLookup streams the card cache into a lookup by printing id, preferring Arena versions of
+// duplicates. Cards are decoded one at a time, so the file is never held as a slice next to the lookup.
+func loadCardLookup(filename string) (map[string]Card, error) {
+	file, err := openCardFile(filename)
 	if err != nil {
 		return nil, err
 	}
+	defer file.Close()
 
-	var cards []Card
-	if err := json.Unmarshal(data, &cards); err != nil {
+	decoder := json.NewDecoder(file)
+	if err := expectDelim(decoder, '['); err != nil {
 		return nil, err
 	}
-
-	return cards, nil
+	cardLookup := make(map[string]Card)
+	for count := 1; decoder.More(); count++ {
+		var card Card
+		if err := decoder.Decode(&card); err != nil {
+			return nil, fmt.Errorf("decoding card %d: %w", count, err)
+		}
+		existing, exists := cardLookup[card.ID]
+		if !exists || (hasArena(card.Games) && !hasArena(existing.Games)) {
+			cardLookup[card.ID] = card
+		}
+	}
+	if err := expectDelim(decoder, ']'); err != nil {
+		return nil, err
+	}
+	return cardLookup, nil
 }
 
-func generateHTML(history HistoryData, cardLookup map[string]Card, outputDir string) error {
-	// Convert to display format
-	displayData := convertToDisplayData(history, cardLookup)
+// expectDelim reads the next token and checks it is the given array delimiter
+func expectDelim(decoder *json.Decoder, want json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("expected %q in card list, got %v", want, token)
+	}
+	return nil
+}
 
+func generateHTML(data DisplayData, outputDir string, options RenderOptions) error {
 	// Sort days in reverse chronological order (newest first)
-	sort.Slice(displayData.Days, func(i, j int) bool {
-		return displayData.Days[i].Date > displayData.Days[j].Date
-	})
-
-	tmpl := `<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>Brawl Chronicle</title>
-    <link rel="stylesheet" href="style.css">
-    <link rel="stylesheet" href="https://cdnjs.cloudflare.com/ajax/libs/font-awesome/6.4.0/css/all.min.css">
-    <link rel="alternate" type="application/rss+xml" title="Brawl Chronicle RSS Feed" href="feed.xml">
-</head>
-<body>
-    <div class="header">
-        <h1>Brawl Chronicle</h1>
-        <p>Daily tracking of new Magic: The Gathering cards legal in Brawl format</p>
-        <div class="links">
-            <a href="feed.xml" title="RSS Feed" class="header-link">
-                <i class="fas fa-rss"></i> RSS Feed
-            </a>
-            <a href="https://github.com/Mikulas/brawl-chronicle" target="_blank" title="GitHub Project" class="header-link">
-                <i class="fab fa-github"></i> GitHub
-            </a>
-        </div>
-        {{if .Days}}
-        <div class="last-updated">Last updated: {{(index .Days 0).Date}}</div>
-        {{end}}
-    </div>
-
-    {{range .Days}}
-    {{if or .FirstRun (gt (len .Cards) 0)}}
-    <div class="day">
-        <div class="day-header">
-            <div class="date">{{.Date}}</div>
-            <div class="count">
-                {{if .FirstRun}}
-                First Run - {{thousands .TotalCards}} cards
-                {{else}}
-                {{thousands (len .Cards)}} new cards
-                {{end}}
-            </div>
-        </div>
-        
-        {{if .FirstRun}}
-        <div class="first-run">
-            Initial data collection - {{thousands .TotalCards}} Brawl-legal cards in database
-        </div>
-        {{else}}
-        <div class="cards">
-            {{range .Cards}}
-            {{if .ImageURL}}
-            <div class="card">
-                <a href="{{.ScryfallURL}}" target="_blank" title="{{.Name}}">
-                    <img src="{{.ImageURL}}" alt="{{.Name}}" loading="lazy">
-                </a>
-            </div>
-            {{end}}
-            {{end}}
-        </div>
-        {{end}}
-    </div>
-    {{end}}
-    {{end}}
-
-    {{if not .Days}}
-    <div class="no-cards">
-        No data available yet.
-    </div>
-    {{end}}
-</body>
-</html>`
+	displayData := DisplayData{Days: newestFirst(data.Days)}
+	applyReleaseCountdowns(&displayData, options.SetCalendar, options.ReferenceDate)
+	displayData.Social = newIndexSocialMeta(displayData.Days, options)
 
 	// Create template with custom functions
-	funcMap := template.FuncMap{
-		"thousands": addThousandsSeparator,
-	}
-	
-	t, err := template.New("index").Funcs(funcMap).Parse(tmpl)
-	if err != nil {
-		return err
+	permalink := func(date string) string { return "day/" + date + ".html" }
+	t := template.New("index").Funcs(options.Site.funcMap()).Funcs(dayTemplateFuncs(options, permalink))
+	for _, name := range []string{"index.html.tmpl", "day.html.tmpl", "social.html.tmpl"} {
+		if err := options.Templates.parseHTML(t, name); err != nil {
+			return err
+		}
 	}
 
-	file, err := os.Create(filepath.Join(outputDir, "index.html"))
-	if err != nil {
+	if err := writeFilterScript(outputDir); err != nil {
 		return err
 	}
-	defer file.Close()
-
-	return t.Execute(file, displayData)
+	return writeTemplateFile(filepath.Join(outputDir, "index.html"), t, displayData, options.Minify)
 }
 
-func convertToDisplayData(history HistoryData, cardLookup map[string]Card) DisplayData {
+// convertToDisplayData resolves the cards of every day, in history order, each day's sorted by order.
+// cardsByOracle is the groupCardsByOracle of cardLookup. Days the cache has from an earlier render are
+// reused; a nil cache converts every day.
+func convertToDisplayData(history HistoryData, cardLookup map[string]Card, cardsByOracle map[string][]Card, order CardOrder, cache *RenderCache) DisplayData {
 	var displayDays []DisplayDay
-
 	for _, day := range history.Days {
-		var cards []DisplayCard
+		displayDays = append(displayDays, cache.displayDay(day, cardLookup, cardsByOracle, func() DisplayDay {
+			return convertDay(day, cardLookup, cardsByOracle, order)
+		}))
+	}
+	return DisplayData{Days: displayDays}
+}
+
+// convertDay resolves the cards of a day, sorted by order
+func convertDay(day DayResult, cardLookup map[string]Card, cardsByOracle map[string][]Card, order CardOrder) DisplayDay {
+	var cards []DisplayCard
+	watched := make(map[string]bool)
+	for _, oracleID := range day.WatchlistHits {
+		watched[oracleID] = true
+	}
+	
+	// Bans and unbans get their own sections instead of showing up as removals and additions
+	banned := make(map[string]bool)
+	unbanned := make(map[string]bool)
+	for _, change := range day.LegalityChanges {
+		if change.To == "banned" {
+			banned[change.OracleID] = true
+		} else if change.From == "banned" && change.To == "legal" {
+			unbanned[change.OracleID] = true
+		}
+	}
+
+	// Only process individual cards if it's NOT a first run
+	if !day.FirstRun {
+		// Select the best card for each oracle_id; legacy printing ids are shown as they are
+		var cardIDs []string
+		for _, oracleID := range day.AddedOracles {
+			if unbanned[oracleID] {
+				slog.Debug("Skipping unbanned card, it is listed under Unbanned", "date", day.Date, "oracle_id", oracleID)
+				continue
+			}
+			if card, found := selectDayCard(day, oracleID, cardLookup, cardsByOracle[oracleID]); found {
+				slog.Debug("Chose printing", "date", day.Date, "oracle_id", oracleID, "card_id", card.ID,
+					"name", card.Name, "set", card.Set, "pinned", day.CardMapping[oracleID].ID == card.ID)
+				cardIDs = append(cardIDs, card.ID)
+			} else {
+				slog.Debug("Skipping card missing from the card cache", "date", day.Date, "oracle_id", oracleID)
+			}
+		}
+		cardIDs = append(cardIDs, day.UnresolvedCards...)
 		
-		// Only process individual cards if it's NOT a first run
-		if !day.FirstRun {
-			// Handle both new oracle format and legacy format
-			var cardIDs []string
-			
-			if day.AddedOracles != nil {
-				// New oracle-based format: select best card for each oracle_id
-				for _, oracleID := range day.AddedOracles {
-					if bestCard, found := selectBestCard(oracleID, cardLookup); found {
-						cardIDs = append(cardIDs, bestCard.ID)
-					}
-				}
-			} else if day.AddedCards != nil {
-				// Legacy format: use AddedCards directly
-				cardIDs = day.AddedCards
+		// Convert IDs to full card data
+		for _, id := range cardIDs {
+			if card, exists := cardLookup[id]; exists {
+				displayCard := newDisplayCard(card)
+				displayCard.Tags = day.Tags[card.OracleID]
+				displayCard.Watched = watched[card.OracleID]
+				cards = append(cards, displayCard)
+			} else {
+				// If card not found, show just the ID
+				slog.Debug("Printing missing from the card cache, shown by id", "date", day.Date, "card_id", id)
+				cards = append(cards, DisplayCard{
+					ID:   id,
+					Name: "Unknown Card",
+				})
 			}
-			
-			// Convert IDs to full card data
-			for _, id := range cardIDs {
-				if card, exists := cardLookup[id]; exists {
-					// Get image URL (prefer normal, fallback to large, then small)
-					imageURL := ""
-					if card.ImageURIs != nil {
-						if url, ok := card.ImageURIs["normal"]; ok {
-							imageURL = url
-						} else if url, ok := card.ImageURIs["large"]; ok {
-							imageURL = url
-						} else if url, ok := card.ImageURIs["small"]; ok {
-							imageURL = url
-						}
-					}
-					
-					// Build Scryfall URL
-					scryfallURL := fmt.Sprintf("https://scryfall.com/card/%s", card.ID)
-					
-					cards = append(cards, DisplayCard{
-						ID:          card.ID,
-						Name:        card.Name,
-						ImageURL:    imageURL,
-						ScryfallURL: scryfallURL,
-						Colors:      card.Colors,
-						CMC:         card.CMC,
-					})
-				} else {
-					// If card not found, show just the ID
-					cards = append(cards, DisplayCard{
-						ID:   id,
-						Name: "Unknown Card",
-					})
-				}
+		}
+
+		// Sort cards by the chosen order, Wizards style by default, watched cards first
+		sort.Slice(cards, func(i, j int) bool {
+			if cards[i].Watched != cards[j].Watched {
+				return cards[i].Watched
 			}
+			return order(cards[i], cards[j])
+		})
+	}
+	// For first run, cards slice stays empty
+	cardGroups := groupCardsBySet(cards)
 
-			// Sort cards by Wizards style: color order then CMC then name
-			sort.Slice(cards, func(i, j int) bool {
-				return compareCardsWizardsStyle(cards[i], cards[j])
-			})
+	// Known cards that lost legality
+	var removedOracles []string
+	for _, oracleID := range day.RemovedOracles {
+		if !banned[oracleID] {
+			removedOracles = append(removedOracles, oracleID)
 		}
-		// For first run, cards slice stays empty
+	}
 
-		displayDays = append(displayDays, DisplayDay{
-			Date:       day.Date,
-			Cards:      cards,
-			TotalCards: day.TotalCards,
-			FirstRun:   day.FirstRun,
-		})
+	return DisplayDay{
+		Date:       day.Date,
+		Cards:      cards,
+		NowOnArena: resolveDisplayCards(day.ArenaAdded, cardsByOracle, order), // Shown with their Arena printing
+		Removed:    resolveDisplayCards(removedOracles, cardsByOracle, order),
+		Banned:     resolveDisplayCards(sortedKeys(banned), cardsByOracle, order),
+		Unbanned:   resolveDisplayCards(sortedKeys(unbanned), cardsByOracle, order),
+		Previews:   previewDisplayCards(day.Previews),
+		Renamed:    day.Renamed,
+		Sets:       day.Sets,
+		CardGroups: cardGroups,
+		Stats:      day.Stats,
+		TotalCards: day.TotalCards,
+		FirstRun:   day.FirstRun,
+	}
+}
+
+// resolveDisplayCards shows the best printing of each oracle_id in the given order, skipping unknown cards
+func resolveDisplayCards(oracleIDs []string, cardsByOracle map[string][]Card, order CardOrder) []DisplayCard {
+	var cards []DisplayCard
+	for _, oracleID := range oracleIDs {
+		if bestCard, found := selectBestCard(cardsByOracle[oracleID]); found {
+			cards = append(cards, newDisplayCard(bestCard))
+		}
 	}
+	sort.Slice(cards, func(i, j int) bool {
+		return order(cards[i], cards[j])
+	})
+	return cards
+}
 
-	return DisplayData{Days: displayDays}
+// sortedKeys returns the keys of a set in order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+
+// newDisplayCard converts a card to its display form
+func newDisplayCard(card Card) DisplayCard {
+	return DisplayCard{
+		ID:          card.ID,
+		OracleID:    card.OracleID,
+		Name:        cardDisplayName(card),
+		ImageURL:    selectImageURL(cardImageURIs(card)),
+		ImageSizes:  imageCandidates(cardImageURIs(card)),
+		ArtCropURL:  selectArtCropURL(card),
+		ScryfallURL: scryfallCardURL(card.ID, card.Set, card.CollectorNumber),
+		Set:         card.Set,
+		SetName:     card.SetName,
+		ReleasedAt:  card.ReleasedAt,
+		Colors:      cardColors(card),
+		CMC:         card.CMC,
+		ManaCost:    cardManaCost(card),
+		TypeLine:    card.TypeLine,
+		Rarity:      card.Rarity,
+	}
+}
+
+// cardManaCost returns the mana cost of the card, or of its front face when only the faces have one,
+// e.g. for transforming cards
+func cardManaCost(card Card) string {
+	if card.ManaCost == "" && len(card.CardFaces) > 0 {
+		return card.CardFaces[0].ManaCost
+	}
+	return card.ManaCost
+}
+
+// cardColors returns the colors of the card, or of its front face when only the faces have them,
+// e.g. for transforming and modal double-faced cards
+func cardColors(card Card) []string {
+	if card.Colors == nil && len(card.CardFaces) > 0 {
+		return card.CardFaces[0].Colors
+	}
+	return card.Colors
+}
+
+// cardDisplayName is the name a card is shown by: the creature of an adventure card, which it is known
+// by, and the full name of others, e.g. "Fire // Ice" for split cards
+func cardDisplayName(card Card) string {
+	if card.Layout == "adventure" && len(card.CardFaces) > 0 && card.CardFaces[0].Name != "" {
+		return card.CardFaces[0].Name
+	}
+	return card.Name
 }
 
 // getColorOrder returns the priority for Wizards color ordering (WUBRG + multicolor + colorless)
@@ -394,17 +906,57 @@ func hasArena(games []string) bool {
 	return false
 }
 
-// selectBestCard chooses the best card for an oracle_id (prefer Arena, then regular frames)
-func selectBestCard(oracleID string, cardLookup map[string]Card) (Card, bool) {
-	var candidates []Card
-	
-	// Find all cards with this oracle_id
+// groupCardsByOracle indexes cardLookup by oracle_id once, so choosing a printing doesn't scan every
+// card. Each oracle's printings are sorted by id, the order selectBestCard expects.
+func groupCardsByOracle(cardLookup map[string]Card) map[string][]Card {
+	cardsByOracle := make(map[string][]Card)
 	for _, card := range cardLookup {
-		if card.OracleID == oracleID {
-			candidates = append(candidates, card)
-		}
+		cardsByOracle[card.OracleID] = append(cardsByOracle[card.OracleID], card)
 	}
-	
+	for _, cards := range cardsByOracle {
+		// Map iteration order is random; sorting makes the same printing win on every run
+		sort.Slice(cards, func(i, j int) bool {
+			return cards[i].ID < cards[j].ID
+		})
+	}
+	return cardsByOracle
+}
+
+// newestFirst returns a copy of the days sorted newest first, leaving the shared DisplayData in history order
+func newestFirst(days []DisplayDay) []DisplayDay {
+	sorted := append([]DisplayDay(nil), days...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Date > sorted[j].Date
+	})
+	return sorted
+}
+
+// selectDayCard returns the printing the fetcher recorded for an oracle_id added on the day,
+// falling back to selectBestCard among candidates for entries recorded before card_mapping
+func selectDayCard(day DayResult, oracleID string, cardLookup map[string]Card, candidates []Card) (Card, bool) {
+	if card, ok := pinnedDayCard(day, oracleID, cardLookup); ok {
+		return card, true
+	}
+	return selectBestCard(candidates)
+}
+
+// pinnedDayCard looks up the printing recorded for an oracle_id added on the day. Caches from before
+// collector numbers were kept take the one recorded with the printing, for links.
+func pinnedDayCard(day DayResult, oracleID string, cardLookup map[string]Card) (Card, bool) {
+	pinned, ok := day.CardMapping[oracleID]
+	if !ok {
+		return Card{}, false
+	}
+	card, exists := cardLookup[pinned.ID]
+	if exists && card.CollectorNumber == "" {
+		card.CollectorNumber = pinned.CollectorNumber
+	}
+	return card, exists
+}
+
+// selectBestCard chooses the best of an oracle_id's printings from groupCardsByOracle (prefer Arena,
+// then regular frames). Ties go to the first printing, so candidates must be in a stable order.
+func selectBestCard(candidates []Card) (Card, bool) {
 	if len(candidates) == 0 {
 		return Card{}, false
 	}
@@ -445,89 +997,260 @@ func selectBestCard(oracleID string, cardLookup map[string]Card) (Card, bool) {
 	return finalCandidates[0], true
 }
 
-func generateRSS(history HistoryData, cardLookup map[string]Card, outputDir string) error {
-	// Convert to display format
-	displayData := convertToDisplayData(history, cardLookup)
-	
-	// Sort days in reverse chronological order (newest first)
-	sort.Slice(displayData.Days, func(i, j int) bool {
-		return displayData.Days[i].Date > displayData.Days[j].Date
-	})
+// rarityLabels are the rarities shown on cards; other values, e.g. from older caches, show none
+var rarityLabels = map[string]string{
+	"common":   "Common",
+	"uncommon": "Uncommon",
+	"rare":     "Rare",
+	"mythic":   "Mythic",
+	"special":  "Special",
+	"bonus":    "Bonus",
+}
+
+// RarityLabel names the card's rarity, or is empty when it is unknown
+func (card DisplayCard) RarityLabel() string {
+	return rarityLabels[card.Rarity]
+}
+
+// feedImageURL is the image of a card in feed items: the small size when there is one, which keeps
+// feeds light, or through a proxy the usual image resized to rssImageWidth
+func feedImageURL(card DisplayCard, proxy ImageProxy) string {
+	source, width := feedImageSource(card, proxy)
+	return proxy.Rewrite(source, width)
+}
+
+// feedImageSource is the Scryfall image feedImageURL shows and the width it asks the proxy for
+func feedImageSource(card DisplayCard, proxy ImageProxy) (string, int) {
+	if proxy.Template == "" && len(card.ImageSizes) > 0 && card.ImageSizes[0].Width == scryfallImageSizes[0].Width {
+		return card.ImageSizes[0].URL, card.ImageSizes[0].Width
+	}
+	return card.ImageURL, rssImageWidth
+}
+
+// maxFeedMedia caps the media:content elements of one feed item. Readers show a few images of an
+// item; a set release would otherwise list hundreds.
+const maxFeedMedia = 10
+
+// FeedMedia is a card image of a feed item as a Media RSS media:content element
+type FeedMedia struct {
+	URL    string
+	Type   string
+	Width  int // 0 when unknown, e.g. resized by an image proxy
+	Height int
+	Title  string
+}
+
+// newFeedMedia lists the first maxFeedMedia card images of a feed item, in the order of its content
+func newFeedMedia(day DisplayDay, proxy ImageProxy) []FeedMedia {
+	var media []FeedMedia
+	for _, cards := range [][]DisplayCard{day.Cards, day.NowOnArena, day.Previews} {
+		for _, card := range cards {
+			if card.ImageURL == "" {
+				continue
+			}
+			if len(media) == maxFeedMedia {
+				return media
+			}
+			source, _ := feedImageSource(card, proxy)
+			imageURL := feedImageURL(card, proxy)
+			item := FeedMedia{URL: imageURL, Type: imageMediaType(imageURL), Title: card.Name}
+			// A proxy resizes the image unless it's mirrored; Scryfall's sizes are known
+			if _, mirrored := proxy.Mirrored[source]; proxy.Template == "" || mirrored {
+				item.Width, item.Height = scryfallImageSize(card.ImageSizes, source)
+			}
+			media = append(media, item)
+		}
+	}
+	return media
+}
+
+// scryfallImageSize returns the width and height of the candidate with the URL, 0 for other images
+func scryfallImageSize(candidates []ImageCandidate, imageURL string) (int, int) {
+	for _, candidate := range candidates {
+		if candidate.URL != imageURL {
+			continue
+		}
+		for _, size := range scryfallImageSizes {
+			if size.Width == candidate.Width {
+				return size.Width, size.Height
+			}
+		}
+	}
+	return 0, 0
+}
+
+// imageMediaType is the type of an image by the extension of its URL; Scryfall's card images are JPEGs
+func imageMediaType(imageURL string) string {
+	switch imageExtension(imageURL) {
+	case ".png":
+		return "image/png"
+	case ".webp":
+		return "image/webp"
+	case ".gif":
+		return "image/gif"
+	}
+	return "image/jpeg"
+}
 
-	rssTemplate := `<?xml version="1.0" encoding="UTF-8"?>
-<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/">
-	<channel>
-		<title>Brawl Chronicle</title>
-		<link>https://mikulas.github.io/brawl-chronicle/</link>
-		<description>Daily tracking of new Magic: The Gathering cards legal in Brawl format</description>
-		<language>en-us</language>
-		<lastBuildDate>{{.LastUpdate}}</lastBuildDate>
-		{{range .Days}}{{if or .FirstRun (gt (len .Cards) 0)}}
-		<item>
-			<title>{{if .FirstRun}}Initial Collection - {{thousands .TotalCards}} cards{{else}}{{thousands (len .Cards)}} new cards on {{.Date}}{{end}}</title>
-			<link>https://mikulas.github.io/brawl-chronicle/#{{.Date}}</link>
-			<guid>https://mikulas.github.io/brawl-chronicle/#{{.Date}}</guid>
-			<pubDate>{{.PubDate}}</pubDate>
-			<description><![CDATA[
-				{{if .FirstRun}}
-				Initial data collection - {{thousands .TotalCards}} Brawl-legal cards in database
-				{{else}}
-				{{range .Cards}}{{if .ImageURL}}<p><strong>{{.Name}}</strong><br/><img src="{{.ImageURL}}" alt="{{.Name}}" style="max-width:200px;"/></p>{{end}}{{end}}
-				{{end}}
-			]]></description>
-		</item>
-		{{end}}{{end}}
-	</channel>
-</rss>`
+// FeedName is the card's name in feed items, with mythics and rares marked, e.g. "[Mythic] Sheoldred"
+func (card DisplayCard) FeedName() string {
+	if card.Rarity == "mythic" || card.Rarity == "rare" {
+		return "[" + card.RarityLabel() + "] " + card.Name
+	}
+	return card.Name
+}
+
+// defaultFeedItems is the number of newest days with an item kept in the feeds, see -feed-items
+const defaultFeedItems = 20
+
+// maxFeedImages caps the card images of one feed item, so a set release doesn't make a huge item
+const maxFeedImages = 50
+
+// feedDays returns the newest days that make a feed item, at most limit. The first run's item
+// drops out like any other once there are enough newer ones.
+func feedDays(days []DisplayDay, limit int) []DisplayDay {
+	var items []DisplayDay
+	for _, day := range newestFirst(days) {
+		if len(items) == limit {
+			break
+		}
+		if day.FirstRun || day.HasChanges() {
+			items = append(items, day)
+		}
+	}
+	return items
+}
+
+// escapeCDATA keeps text inside a CDATA section by splitting any "]]>" in it across two sections
+func escapeCDATA(text string) string {
+	return strings.ReplaceAll(text, "]]>", "]]]]><![CDATA[>")
+}
+
+// feedItemID is the guid of a day's RSS item and the id of its JSON Feed item: the day's page with a
+// short hash of its cards, so a day the fetcher adds to later on shows up in readers again, once
+func (site Site) feedItemID(day DisplayDay) string {
+	var cards []string
+	sections := map[string][]DisplayCard{
+		"added": day.Cards, "arena": day.NowOnArena, "banned": day.Banned,
+		"unbanned": day.Unbanned, "removed": day.Removed, "preview": day.Previews,
+	}
+	for section, sectionCards := range sections {
+		for _, card := range sectionCards {
+			id := card.OracleID
+			if id == "" {
+				id = card.ID
+			}
+			cards = append(cards, section+":"+id)
+		}
+	}
+	for _, change := range day.Renamed {
+		cards = append(cards, "renamed:"+change.OracleID+":"+change.NewName)
+	}
+	sort.Strings(cards)
 
+	sum := sha256.Sum256([]byte(strings.Join(cards, "\n")))
+	return site.dayURL(day.Date) + "#" + hex.EncodeToString(sum[:4])
+}
+
+// feedItemContent is what feed-content.html.tmpl shows of a day: its card images cut to maxFeedImages,
+// with the number left out
+type feedItemContent struct {
+	DisplayDay
+	HiddenImages int
+}
+
+func newFeedItemContent(day DisplayDay) feedItemContent {
+	remaining := maxFeedImages
+	hidden := 0
+	take := func(cards []DisplayCard) []DisplayCard {
+		if len(cards) <= remaining {
+			remaining -= len(cards)
+			return cards
+		}
+		hidden += len(cards) - remaining
+		kept := cards[:remaining]
+		remaining = 0
+		return kept
+	}
+	day.Cards = take(day.Cards)
+	day.NowOnArena = take(day.NowOnArena)
+	day.Previews = take(day.Previews)
+	return feedItemContent{DisplayDay: day, HiddenImages: hidden}
+}
+
+// FeedTitle is the title of the day's feed item, e.g. "3 new cards from Bloomburrow on 2024-07-26"
+func (day DisplayDay) FeedTitle() string {
+	if day.FirstRun {
+		return "Initial Collection - " + addThousandsSeparator(day.TotalCards) + " cards"
+	}
+	title := day.Summary()
+	if set := day.DominantSet(); set != nil {
+		title += " from " + set.Name
+	}
+	return title + " on " + day.Date
+}
+
+func generateRSS(data DisplayData, outputDir string, options RenderOptions) error {
 	// Create template with custom functions using text/template for proper XML output
 	textFuncMap := text_template.FuncMap{
 		"thousands": addThousandsSeparator,
+		"xml":       text_template.HTMLEscapeString,
+		"cdata":     escapeCDATA,
+		"itemID":    options.Site.feedItemID,
+		"image": func(card DisplayCard) string {
+			return feedImageURL(card, options.ImageProxy)
+		},
 	}
 	
-	t, err := text_template.New("rss").Funcs(textFuncMap).Parse(rssTemplate)
-	if err != nil {
+	t := text_template.New("rss").Funcs(options.Site.funcMap()).Funcs(textFuncMap)
+	if err := options.Templates.parseText(t, "feed.xml.tmpl"); err != nil {
+		return err
+	}
+	if err := options.Templates.parseText(t.New("content"), "feed-content.html.tmpl"); err != nil {
 		return err
 	}
 
 	// Create RSS data with proper dates
 	type RSSDay struct {
 		DisplayDay
-		PubDate string
+		PubDate     string
+		Description string // The item's HTML, from feed-content.html.tmpl
+		Media       []FeedMedia
 	}
 	
 	type RSSData struct {
 		Days       []RSSDay
 		LastUpdate string
+		Hub        string // WebSub hub, empty for none
 	}
 	
+	// Dates come from the days rather than the clock, so rebuilding without changes keeps the feed as it is
 	var rssDays []RSSDay
-	for _, day := range displayData.Days {
+	for _, day := range feedDays(data.Days, options.FeedItems) {
 		// Convert date to RFC2822 format for RSS
 		date, err := time.Parse("2006-01-02", day.Date)
 		if err != nil {
-			date = time.Now() // fallback
+			return err
+		}
+		
+		var description strings.Builder
+		if err := t.ExecuteTemplate(&description, "content", newFeedItemContent(day)); err != nil {
+			return err
 		}
 		
 		rssDays = append(rssDays, RSSDay{
-			DisplayDay: day,
-			PubDate:    date.Format(time.RFC1123Z),
+			DisplayDay:  day,
+			PubDate:     date.Format(time.RFC1123Z),
+			Description: description.String(),
+			Media:       newFeedMedia(day, options.ImageProxy),
 		})
 	}
 	
-	rssData := RSSData{
-		Days:       rssDays,
-		LastUpdate: time.Now().Format(time.RFC1123Z),
+	rssData := RSSData{Days: rssDays, Hub: options.WebSubHub}
+	if len(rssDays) > 0 {
+		rssData.LastUpdate = rssDays[0].PubDate // The newest day
 	}
 
-	// Write RSS file
-	rssFile := filepath.Join(outputDir, "feed.xml")
-	file, err := os.Create(rssFile)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	// Execute template and write raw XML (text/template doesn't escape HTML)
-	return t.Execute(file, rssData)
+	// Write raw XML (text/template doesn't escape HTML)
+	return writeTemplateFile(filepath.Join(outputDir, "feed.xml"), t, rssData, options.Minify)
 }
\ No newline at end of file
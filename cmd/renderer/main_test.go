@@ -0,0 +1,162 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testFaceImages are the images of one face or card, named after it
+func testFaceImages(name string) map[string]string {
+	return map[string]string{
+		"normal":   "https://cards.scryfall.io/normal/" + name + ".jpg",
+		"large":    "https://cards.scryfall.io/large/" + name + ".jpg",
+		"art_crop": "https://cards.scryfall.io/art_crop/" + name + ".jpg",
+	}
+}
+
+func TestNewDisplayCardLayouts(t *testing.T) {
+	tests := []struct {
+		name     string
+		card     Card
+		want     string // Displayed name
+		image    string
+		artCrop  string
+		colors   []string
+		manaCost string
+	}{
+		{
+			name: "normal",
+			card: Card{
+				Name: "Llanowar Elves", Layout: "normal", ManaCost: "{G}", Colors: []string{"G"},
+				ImageURIs: testFaceImages("llanowar"),
+			},
+			want:     "Llanowar Elves",
+			image:    "https://cards.scryfall.io/normal/llanowar.jpg",
+			artCrop:  "https://cards.scryfall.io/art_crop/llanowar.jpg",
+			colors:   []string{"G"},
+			manaCost: "{G}",
+		},
+		{
+			// Scryfall leaves the colors, mana cost and images of transforming cards to their faces
+			name: "transform",
+			card: Card{
+				Name: "Delver of Secrets // Insectile Aberration", Layout: "transform",
+				CardFaces: []CardFace{
+					{Name: "Delver of Secrets", ManaCost: "{U}", Colors: []string{"U"}, ImageURIs: testFaceImages("delver-front")},
+					{Name: "Insectile Aberration", Colors: []string{"U"}, ImageURIs: testFaceImages("delver-back")},
+				},
+			},
+			want:     "Delver of Secrets // Insectile Aberration",
+			image:    "https://cards.scryfall.io/normal/delver-front.jpg",
+			artCrop:  "https://cards.scryfall.io/art_crop/delver-front.jpg",
+			colors:   []string{"U"},
+			manaCost: "{U}",
+		},
+		{
+			// Both faces of a modal double-faced card are castable, the front one is shown
+			name: "modal double-faced",
+			card: Card{
+				Name: "Valki, God of Lies // Tibalt, Cosmic Impostor", Layout: "modal_dfc",
+				CardFaces: []CardFace{
+					{Name: "Valki, God of Lies", ManaCost: "{1}{B}", Colors: []string{"B"}, ImageURIs: testFaceImages("valki")},
+					{Name: "Tibalt, Cosmic Impostor", ManaCost: "{5}{B}{R}", Colors: []string{"B", "R"}, ImageURIs: testFaceImages("tibalt")},
+				},
+			},
+			want:     "Valki, God of Lies // Tibalt, Cosmic Impostor",
+			image:    "https://cards.scryfall.io/normal/valki.jpg",
+			artCrop:  "https://cards.scryfall.io/art_crop/valki.jpg",
+			colors:   []string{"B"},
+			manaCost: "{1}{B}",
+		},
+		{
+			// Adventure cards have one image and the card's colors, their faces only name the two halves
+			name: "adventure",
+			card: Card{
+				Name: "Bonecrusher Giant // Stomp", Layout: "adventure", ManaCost: "{2}{R} // {1}{R}", Colors: []string{"R"},
+				ImageURIs: testFaceImages("bonecrusher"),
+				CardFaces: []CardFace{
+					{Name: "Bonecrusher Giant", ManaCost: "{2}{R}"},
+					{Name: "Stomp", ManaCost: "{1}{R}"},
+				},
+			},
+			want:     "Bonecrusher Giant",
+			image:    "https://cards.scryfall.io/normal/bonecrusher.jpg",
+			artCrop:  "https://cards.scryfall.io/art_crop/bonecrusher.jpg",
+			colors:   []string{"R"},
+			manaCost: "{2}{R} // {1}{R}",
+		},
+		{
+			name: "split",
+			card: Card{
+				Name: "Fire // Ice", Layout: "split", ManaCost: "{1}{R} // {1}{U}", Colors: []string{"U", "R"},
+				ImageURIs: testFaceImages("fire-ice"),
+				CardFaces: []CardFace{
+					{Name: "Fire", ManaCost: "{1}{R}", Colors: []string{"R"}},
+					{Name: "Ice", ManaCost: "{1}{U}", Colors: []string{"U"}},
+				},
+			},
+			want:     "Fire // Ice",
+			image:    "https://cards.scryfall.io/normal/fire-ice.jpg",
+			artCrop:  "https://cards.scryfall.io/art_crop/fire-ice.jpg",
+			colors:   []string{"U", "R"},
+			manaCost: "{1}{R} // {1}{U}",
+		},
+		{
+			name: "colorless transform",
+			card: Card{
+				Name: "Treasure Map // Treasure Cove", Layout: "transform",
+				CardFaces: []CardFace{
+					{Name: "Treasure Map", ManaCost: "{2}", Colors: []string{}, ImageURIs: testFaceImages("treasure-map")},
+					{Name: "Treasure Cove", Colors: []string{}, ImageURIs: testFaceImages("treasure-cove")},
+				},
+			},
+			want:     "Treasure Map // Treasure Cove",
+			image:    "https://cards.scryfall.io/normal/treasure-map.jpg",
+			artCrop:  "https://cards.scryfall.io/art_crop/treasure-map.jpg",
+			colors:   []string{},
+			manaCost: "{2}",
+		},
+		{
+			name: "no images",
+			card: Card{
+				Name: "Opt", Layout: "normal", ManaCost: "{U}", Colors: []string{"U"},
+			},
+			want:     "Opt",
+			colors:   []string{"U"},
+			manaCost: "{U}",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			card := newDisplayCard(test.card)
+			if card.Name != test.want {
+				t.Errorf("name = %q, want %q", card.Name, test.want)
+			}
+			if card.ImageURL != test.image {
+				t.Errorf("image = %q, want %q", card.ImageURL, test.image)
+			}
+			if card.ArtCropURL != test.artCrop {
+				t.Errorf("art crop = %q, want %q", card.ArtCropURL, test.artCrop)
+			}
+			if !reflect.DeepEqual(card.Colors, test.colors) {
+				t.Errorf("colors = %q, want %q", card.Colors, test.colors)
+			}
+			if card.ManaCost != test.manaCost {
+				t.Errorf("mana cost = %q, want %q", card.ManaCost, test.manaCost)
+			}
+			// Every size of the shown image is offered, and only of that one
+			var sizes []string
+			for _, size := range card.ImageSizes {
+				sizes = append(sizes, size.URL)
+			}
+			var want []string
+			if test.image != "" {
+				want = []string{test.image, strings.Replace(test.image, "/normal/", "/large/", 1)}
+			}
+			if !reflect.DeepEqual(sizes, want) {
+				t.Errorf("image sizes = %q, want %q", sizes, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+)
+
+// rawElements keep their content as written: whitespace is significant in <pre> and <textarea>, and
+// scripts and styles are another language
+var rawElements = map[string]bool{
+	"pre":      true,
+	"textarea": true,
+	"script":   true,
+	"style":    true,
+}
+
+// templateExecutor is a parsed html/template or text/template template
+type templateExecutor interface {
+	Execute(w io.Writer, data any) error
+}
+
+// writeTemplateFile writes what t renders of data to filename, minified with -minify
+func writeTemplateFile(filename string, t templateExecutor, data any, minify bool) error {
+	var content bytes.Buffer
+	if err := t.Execute(&content, data); err != nil {
+		return err
+	}
+	if !minify {
+		return os.WriteFile(filename, content.Bytes(), 0644)
+	}
+	return os.WriteFile(filename, []byte(minifyMarkup(content.String())), 0644)
+}
+
+// minifyMarkup drops the whitespace the templates leave around their actions and the comments of
+// rendered HTML or XML. Each run of whitespace outside tags becomes one newline, or one space when it
+// had no newline, so the page shows the same and stays a line per element for diffs. Attribute values,
+// raw elements and CDATA sections are copied as they are, except for the whitespace around the content
+// of a CDATA section, which the feed's template indents.
+func minifyMarkup(markup string) string {
+	var out strings.Builder
+	out.Grow(len(markup))
+	pending := "" // Whitespace to write before the next content; none at the start
+	started := false
+	write := func(content string) {
+		if started {
+			out.WriteString(pending)
+		}
+		pending = ""
+		started = true
+		out.WriteString(content)
+	}
+
+	for i := 0; i < len(markup); {
+		switch {
+		case isMarkupSpace(markup[i]):
+			end := i
+			for end < len(markup) && isMarkupSpace(markup[end]) {
+				end++
+			}
+			if strings.Contains(markup[i:end], "\n") || pending == "\n" {
+				pending = "\n"
+			} else {
+				pending = " "
+			}
+			i = end
+
+		case strings.HasPrefix(markup[i:], "<!--"):
+			end := strings.Index(markup[i+4:], "-->")
+			if end < 0 {
+				write(markup[i:])
+				i = len(markup)
+				break
+			}
+			i += 4 + end + 3
+
+		case strings.HasPrefix(markup[i:], "<![CDATA["):
+			end := strings.Index(markup[i+9:], "]]>")
+			if end < 0 {
+				write(markup[i:])
+				i = len(markup)
+				break
+			}
+			write("<![CDATA[" + strings.TrimSpace(markup[i+9:i+9+end]) + "]]>")
+			i += 9 + end + 3
+
+		case markup[i] == '<':
+			tag, end := minifyTag(markup, i)
+			write(tag)
+			i = end
+			// A raw element runs to its end tag, which is then read as any other tag
+			if name := tagName(tag); rawElements[name] && !strings.HasSuffix(tag, "/>") {
+				content := strings.Index(strings.ToLower(markup[i:]), "</"+name)
+				if content < 0 {
+					content = len(markup) - i
+				}
+				if content > 0 {
+					write(markup[i : i+content])
+				}
+				i += content
+			}
+
+		default:
+			end := i
+			for end < len(markup) && markup[end] != '<' && !isMarkupSpace(markup[end]) {
+				end++
+			}
+			write(markup[i:end])
+			i = end
+		}
+	}
+	if pending == "\n" {
+		out.WriteString(pending) // Keep the file's final newline
+	}
+	return out.String()
+}
+
+// minifyTag reads the tag starting at markup[start], collapsing the whitespace between its attributes,
+// and returns it with the index after it
+func minifyTag(markup string, start int) (string, int) {
+	var tag strings.Builder
+	var quote byte
+	space := false
+	for i := start; i < len(markup); i++ {
+		c := markup[i]
+		switch {
+		case quote != 0:
+			tag.WriteByte(c)
+			if c == quote {
+				quote = 0
+			}
+		case isMarkupSpace(c):
+			space = true
+		default:
+			if space {
+				tag.WriteByte(' ')
+				space = false
+			}
+			tag.WriteByte(c)
+			if c == '"' || c == '\'' {
+				quote = c
+			} else if c == '>' {
+				return tag.String(), i + 1
+			}
+		}
+	}
+	return tag.String(), len(markup)
+}
+
+// tagName returns the lowercase element name of a start tag, empty for end tags and declarations
+func tagName(tag string) string {
+	end := 1
+	for end < len(tag) && !isMarkupSpace(tag[end]) && tag[end] != '>' && tag[end] != '/' {
+		end++
+	}
+	return strings.ToLower(tag[1:end])
+}
+
+func isMarkupSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// minifiedGolden are the representative pages whose minified output is kept in testdata/golden-minify
+var minifiedGolden = []string{"index.html", "day/2024-05-05.html", "feed.xml"}
+
+// domContent reads markup as a browser or feed reader would see it: the elements with their attributes,
+// and the text between them with each run of whitespace as one space. Comments are dropped, and the
+// text of raw elements is kept as written. HTML closes its void elements itself; in feeds a <link> has
+// content.
+func domContent(t *testing.T, markup string, html bool) []string {
+	t.Helper()
+	decoder := xml.NewDecoder(strings.NewReader(markup))
+	decoder.Strict = false
+	if html {
+		decoder.AutoClose = xml.HTMLAutoClose
+	}
+	decoder.Entity = xml.HTMLEntity
+
+	var content []string
+	var text strings.Builder
+	raw := 0
+	flush := func() {
+		if text.Len() == 0 {
+			return
+		}
+		if raw > 0 {
+			content = append(content, "text "+text.String())
+		} else if collapsed := strings.Join(strings.Fields(text.String()), " "); collapsed != "" {
+			content = append(content, "text "+collapsed)
+		} else if len(content) > 0 {
+			content = append(content, "space")
+		}
+		text.Reset()
+	}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch token := token.(type) {
+		case xml.CharData:
+			text.Write(token)
+		case xml.Comment:
+			// Not shown, and the text around it reads as one
+		case xml.StartElement:
+			flush()
+			var attributes []string
+			for _, attribute := range token.Attr {
+				attributes = append(attributes, fmt.Sprintf("%s:%s=%q", attribute.Name.Space, attribute.Name.Local, attribute.Value))
+			}
+			sort.Strings(attributes)
+			content = append(content, "<"+token.Name.Local+" "+strings.Join(attributes, " ")+">")
+			if rawElements[strings.ToLower(token.Name.Local)] {
+				raw++
+			}
+		case xml.EndElement:
+			flush()
+			content = append(content, "</"+token.Name.Local+">")
+			if rawElements[strings.ToLower(token.Name.Local)] {
+				raw--
+			}
+		case xml.Directive:
+			flush()
+			content = append(content, "<!"+strings.Join(strings.Fields(string(token)), " ")+">")
+		case xml.ProcInst:
+			flush()
+			content = append(content, "<?"+token.Target+" "+string(token.Inst)+"?>")
+		}
+	}
+	// Whitespace at the end of the document isn't part of it
+	if text.Len() > 0 && strings.TrimSpace(text.String()) != "" {
+		flush()
+	}
+	return content
+}
+
+// TestGoldenMinifiedSite keeps the minified output of representative pages, and checks that every page
+// and feed reads the same minified as it does as rendered
+func TestGoldenMinifiedSite(t *testing.T) {
+	plainDir := t.TempDir()
+	plainNames := renderFixtureSite(t, plainDir, false)
+	minifiedDir := t.TempDir()
+	minifiedNames := renderFixtureSite(t, minifiedDir, true)
+	if !reflect.DeepEqual(plainNames, minifiedNames) {
+		t.Fatalf("minified render wrote %v, the plain one %v", minifiedNames, plainNames)
+	}
+	checkGolden(t, minifiedDir, filepath.Join("testdata", "golden-minify"), minifiedGolden)
+
+	for _, name := range plainNames {
+		if strings.HasSuffix(name, ".json") {
+			continue
+		}
+		plain := readOutput(t, plainDir, name)
+		minified := readOutput(t, minifiedDir, name)
+		if len(minified) >= len(plain) {
+			t.Errorf("%s: minified to %d bytes from %d", name, len(minified), len(plain))
+		}
+		html := strings.HasSuffix(name, ".html")
+		if !reflect.DeepEqual(domContent(t, minified, html), domContent(t, plain, html)) {
+			t.Errorf("%s reads differently minified", name)
+		}
+	}
+}
+
+func TestMinifyMarkup(t *testing.T) {
+	tests := []struct {
+		name   string
+		markup string
+		want   string
+	}{
+		{
+			name:   "template whitespace",
+			markup: "<ul>\n    \n    <li>Opt</li>\n\n    <li>Sol  Ring</li>\n</ul>\n",
+			want:   "<ul>\n<li>Opt</li>\n<li>Sol Ring</li>\n</ul>\n",
+		},
+		{
+			name:   "comments",
+			markup: "<p>Serra <!-- <b>not shown</b> --> Angel</p>",
+			want:   "<p>Serra Angel</p>",
+		},
+		{
+			name:   "attributes",
+			markup: "<img\n    src=\"a.jpg\"   alt=\"Sol   Ring\n\"\ttitle='x  >  y'>",
+			want:   "<img src=\"a.jpg\" alt=\"Sol   Ring\n\" title='x  >  y'>",
+		},
+		{
+			name:   "pre",
+			markup: "<div>\n  <pre class=\"code\">\n  {T}:   Add {C}{C}.\n</pre>\n</div>",
+			want:   "<div>\n<pre class=\"code\">\n  {T}:   Add {C}{C}.\n</pre>\n</div>",
+		},
+		{
+			name:   "raw element end tag in other case",
+			markup: "<textarea>  a  <b>  </TEXTAREA>  <p>x</p>",
+			want:   "<textarea>  a  <b>  </TEXTAREA> <p>x</p>",
+		},
+		{
+			name:   "cdata",
+			markup: "<description>\n\t\t<![CDATA[\n<p>Opt</p>  <!-- kept -->\n<p>Sol  Ring</p>\n\t\t]]>\n</description>",
+			want:   "<description>\n<![CDATA[<p>Opt</p>  <!-- kept -->\n<p>Sol  Ring</p>]]>\n</description>",
+		},
+		{
+			name:   "unterminated comment",
+			markup: "<p>x</p> <!-- open",
+			want:   "<p>x</p> <!-- open",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := minifyMarkup(test.markup); got != test.want {
+				t.Errorf("minifyMarkup(%q) = %q, want %q", test.markup, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Politeness of -mirror-images towards Scryfall's image servers
+const (
+	mirrorWorkers         = 4
+	mirrorRequestInterval = 100 * time.Millisecond // Between the starts of any two downloads
+	mirrorTimeout         = 60 * time.Second
+	mirrorMaxImageSize    = 20 << 20
+	mirrorUserAgent       = "BrawlChronicle/1.0 renderer"
+)
+
+// mirrorIndexName is the index of the mirrored images, in the mirror directory
+const mirrorIndexName = "index.json"
+
+// MirrorIndex records the file of every mirrored image by its source URL, and the last error of
+// those that failed, which are tried again on the next run
+type MirrorIndex struct {
+	Images map[string]string `json:"images"`
+	Failed map[string]string `json:"failed,omitempty"`
+}
+
+// collectImageURLs lists every image the pages and feeds of the days show, sorted
+func collectImageURLs(data DisplayData) []string {
+	seen := make(map[string]bool)
+	add := func(cards []DisplayCard) {
+		for _, card := range cards {
+			seen[card.ImageURL] = true
+			seen[card.ArtCropURL] = true
+			for _, size := range card.ImageSizes {
+				seen[size.URL] = true
+			}
+		}
+	}
+	for _, day := range data.Days {
+		add(day.Cards)
+		add(day.NowOnArena)
+		add(day.Removed)
+		add(day.Banned)
+		add(day.Unbanned)
+		add(day.Previews)
+	}
+	delete(seen, "")
+
+	urls := make([]string, 0, len(seen))
+	for imageURL := range seen {
+		urls = append(urls, imageURL)
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+// mirrorImages downloads the images that are not mirrored yet into dir, named after a hash of their
+// content, and returns the public address of every mirrored image by source URL. dir has to be inside
+// outputRoot, which is published at baseURL. Failed downloads don't stop the build: they are recorded
+// in the index and their images stay hotlinked.
+func mirrorImages(urls []string, dir string, outputRoot string, baseURL string) (map[string]string, error) {
+	rel, err := filepath.Rel(outputRoot, dir)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("%s is not inside the output directory %s", dir, outputRoot)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	indexFile := filepath.Join(dir, mirrorIndexName)
+	index, err := loadMirrorIndex(indexFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only images without a file are downloaded, so a follow-up run fetches just the new cards
+	var missing []string
+	for _, source := range urls {
+		if name, found := index.Images[source]; found {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				continue
+			}
+		}
+		missing = append(missing, source)
+	}
+	slog.Info("Mirroring images", "dir", dir, "mirrored", len(urls)-len(missing), "downloading", len(missing))
+
+	type download struct {
+		source string
+		name   string
+		err    error
+	}
+	jobs := make(chan string)
+	results := make(chan download)
+	ticker := time.NewTicker(mirrorRequestInterval)
+	defer ticker.Stop()
+	client := &http.Client{Timeout: mirrorTimeout}
+
+	var workers sync.WaitGroup
+	for i := 0; i < mirrorWorkers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for source := range jobs {
+				<-ticker.C
+				name, err := downloadImage(client, source, dir)
+				results <- download{source: source, name: name, err: err}
+			}
+		}()
+	}
+	go func() {
+		for _, source := range missing {
+			jobs <- source
+		}
+		close(jobs)
+		workers.Wait()
+		close(results)
+	}()
+
+	failed := 0
+	for result := range results {
+		if result.err != nil {
+			failed++
+			index.Failed[result.source] = result.err.Error()
+			slog.Debug("Mirroring image failed", "url", result.source, "err", result.err)
+			continue
+		}
+		delete(index.Failed, result.source)
+		index.Images[result.source] = result.name
+	}
+	if err := writeJSONFile(indexFile, index); err != nil {
+		return nil, err
+	}
+	if failed > 0 {
+		slog.Warn("Some images could not be mirrored and stay hotlinked; see the index", "failed", failed, "index", indexFile)
+	}
+
+	public := baseURL + filepath.ToSlash(rel) + "/"
+	mirrored := make(map[string]string)
+	for _, source := range urls {
+		if name, found := index.Images[source]; found {
+			mirrored[source] = public + name
+		}
+	}
+	return mirrored, nil
+}
+
+func loadMirrorIndex(filename string) (MirrorIndex, error) {
+	index := MirrorIndex{Images: make(map[string]string), Failed: make(map[string]string)}
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return index, nil
+	} else if err != nil {
+		return MirrorIndex{}, err
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return MirrorIndex{}, fmt.Errorf("%s: %w", filename, err)
+	}
+	if index.Images == nil {
+		index.Images = make(map[string]string)
+	}
+	if index.Failed == nil {
+		index.Failed = make(map[string]string)
+	}
+	return index, nil
+}
+
+// downloadImage saves an image into dir as the hash of its content with the extension of its URL
+// and returns the file name. Identical images share a file.
+func downloadImage(client *http.Client, source string, dir string) (string, error) {
+	req, err := http.NewRequest("GET", source, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", mirrorUserAgent)
+	req.Header.Set("Accept", "image/*")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %s", resp.Status)
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, mirrorMaxImageSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(content) > mirrorMaxImageSize {
+		return "", fmt.Errorf("image is larger than %d bytes", mirrorMaxImageSize)
+	}
+
+	sum := sha256.Sum256(content)
+	name := hex.EncodeToString(sum[:16]) + imageExtension(source)
+	filename := filepath.Join(dir, name)
+	if _, err := os.Stat(filename); err == nil {
+		return name, nil
+	}
+	temp := filename + ".tmp"
+	if err := os.WriteFile(temp, content, 0644); err != nil {
+		return "", err
+	}
+	return name, os.Rename(temp, filename)
+}
+
+// imageExtension returns the extension of the file an image URL points at, .jpg when it has none
+func imageExtension(source string) string {
+	parsed, err := url.Parse(source)
+	if err != nil {
+		return ".jpg"
+	}
+	if extension := strings.ToLower(path.Ext(parsed.Path)); extension != "" && len(extension) <= 5 {
+		return extension
+	}
+	return ".jpg"
+}
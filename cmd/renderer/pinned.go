@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+)
+
+// PinnedPrinting is the printing the fetcher recorded for an added card in card_mapping. Histories
+// before schema version 3 stored the bare printing id, which decodes as a printing without details.
+type PinnedPrinting struct {
+	ID              string `json:"id"`
+	Set             string `json:"set"`
+	CollectorNumber string `json:"collector_number"`
+	ReleasedAt      string `json:"released_at"`
+}
+
+// UnmarshalJSON accepts both the object and the bare printing id of older histories
+func (pinned *PinnedPrinting) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var id string
+		if err := json.Unmarshal(data, &id); err != nil {
+			return err
+		}
+		*pinned = PinnedPrinting{ID: id}
+		return nil
+	}
+
+	// Without its methods the type decodes as a plain struct instead of recursing
+	type plain PinnedPrinting
+	return json.Unmarshal(data, (*plain)(pinned))
+}
+
+// scryfallCardURL links to a printing's page by set and collector number, falling back to the id for
+// printings from caches written before collector numbers were kept
+func scryfallCardURL(id string, set string, collectorNumber string) string {
+	if set == "" || collectorNumber == "" {
+		return "https://scryfall.com/card/" + id
+	}
+	return "https://scryfall.com/card/" + url.PathEscape(set) + "/" + url.PathEscape(collectorNumber)
+}
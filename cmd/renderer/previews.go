@@ -0,0 +1,33 @@
+package main
+
+// PreviewCard is a card the fetcher found previewed on Scryfall before it became legal.
+// It isn't in the card cache yet, so the entry carries what is needed to show it.
+type PreviewCard struct {
+	OracleID   string `json:"oracle_id"`
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Set        string `json:"set"`
+	SetName    string `json:"set_name"`
+	ReleasedAt string `json:"released_at"`
+	ImageURL   string `json:"image_url"`
+
+	CollectorNumber string `json:"collector_number"`
+}
+
+// previewDisplayCards shows previewed cards in the order the fetcher recorded them
+func previewDisplayCards(previews []PreviewCard) []DisplayCard {
+	var cards []DisplayCard
+	for _, preview := range previews {
+		cards = append(cards, DisplayCard{
+			ID:          preview.ID,
+			OracleID:    preview.OracleID,
+			Name:        preview.Name,
+			ImageURL:    preview.ImageURL,
+			ScryfallURL: scryfallCardURL(preview.ID, preview.Set, preview.CollectorNumber),
+			Set:         preview.Set,
+			SetName:     preview.SetName,
+			ReleasedAt:  preview.ReleasedAt,
+		})
+	}
+	return cards
+}
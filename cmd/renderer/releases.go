@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// defaultSetCalendarFile is the set release calendar cached by the fetcher
+const defaultSetCalendarFile = "data/sets.json"
+
+// SetRelease is a set from the fetcher's release calendar
+type SetRelease struct {
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	ReleasedAt string `json:"released_at"`
+}
+
+// ReleaseCountdown is the banner for the next set release among the chronicled cards
+type ReleaseCountdown struct {
+	SetName string
+	Date    string
+	Days    int
+}
+
+// loadSetCalendar reads the release calendar by set code; a missing calendar is not an error
+func loadSetCalendar(filename string) (map[string]SetRelease, error) {
+	calendar := make(map[string]SetRelease)
+
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return calendar, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var cached struct {
+		Sets []SetRelease `json:"sets"`
+	}
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, err
+	}
+
+	for _, set := range cached.Sets {
+		calendar[set.Code] = set
+	}
+	return calendar, nil
+}
+
+// applyReleaseCountdowns marks cards from sets releasing after the reference date and
+// returns the countdown to the earliest such release. Once the date passes, cards render normally.
+func applyReleaseCountdowns(displayData *DisplayData, calendar map[string]SetRelease, reference time.Time) {
+	today := reference.Format("2006-01-02")
+	var next *ReleaseCountdown
+
+	annotate := func(cards []DisplayCard) {
+		for i := range cards {
+			card := &cards[i]
+			releaseDate, setName := card.ReleasedAt, card.SetName
+			if set, found := calendar[card.Set]; found && set.ReleasedAt != "" {
+				releaseDate, setName = set.ReleasedAt, set.Name
+			}
+			if releaseDate == "" || releaseDate <= today {
+				continue
+			}
+
+			date, err := time.Parse("2006-01-02", releaseDate)
+			if err != nil {
+				continue
+			}
+			card.ReleaseDate = releaseDate
+			card.DaysUntilRelease = int(date.Sub(reference).Hours() / 24)
+
+			if next == nil || releaseDate < next.Date {
+				next = &ReleaseCountdown{SetName: setName, Date: releaseDate, Days: card.DaysUntilRelease}
+			}
+		}
+	}
+
+	for i := range displayData.Days {
+		annotate(displayData.Days[i].Cards)
+		annotate(displayData.Days[i].NowOnArena)
+		annotate(displayData.Days[i].Previews)
+	}
+
+	displayData.NextRelease = next
+}
+
+// daysLabel formats a day count, e.g. "1 day" or "12 days"
+func daysLabel(days int) string {
+	if days == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", days)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testRenderOptions are the renderer's defaults, with the built-in templates, for a site at
+// https://example.com/ rendered to dir
+func testRenderOptions(t *testing.T, dir string) RenderOptions {
+	t.Helper()
+	templates, err := loadTemplates("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := parseCardOrder(defaultCardOrder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return RenderOptions{
+		GalleryDays:   7,
+		FeedItems:     30,
+		ReferenceDate: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		CardOrder:     order,
+		Templates:     templates,
+		Site:          newSite("brawl", "", dir, "https://example.com/"),
+	}
+}
+
+// testCardPrinting is a printing with the fields the pages show
+func testCardPrinting(id string, oracleID string, name string, set string, number string) Card {
+	return Card{
+		ID:              id,
+		OracleID:        oracleID,
+		Name:            name,
+		ManaCost:        "{1}{G}",
+		CMC:             2,
+		TypeLine:        "Creature — Elf Druid",
+		Colors:          []string{"G"},
+		Rarity:          "common",
+		Set:             set,
+		SetName:         strings.ToUpper(set),
+		ReleasedAt:      "2024-01-01",
+		CollectorNumber: number,
+		ImageURIs: map[string]string{
+			"normal":   "https://cards.scryfall.io/normal/front/" + id + ".jpg",
+			"art_crop": "https://cards.scryfall.io/art_crop/front/" + id + ".jpg",
+		},
+		Games: []string{"arena", "paper"},
+	}
+}
+
+// readOutput returns a rendered file of dir
+func readOutput(t *testing.T, dir string, name string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestScryfallLinksUseSetAndNumber(t *testing.T) {
+	dir := t.TempDir()
+	options := testRenderOptions(t, dir)
+	const uuid = "0f2e3c8a-6b1d-4c2e-9a51-2f7d9e1b3c44"
+	cardLookup := map[string]Card{uuid: testCardPrinting(uuid, "oracle-elves", "Llanowar Elves", "dom", "168")}
+	history := HistoryData{Days: []DayResult{
+		{Date: "2024-05-01", FirstRun: true, TotalCards: 1000},
+		{Date: "2024-05-02", AddedOracles: []string{"oracle-elves"}},
+	}}
+	data := convertToDisplayData(history, cardLookup, groupCardsByOracle(cardLookup), options.CardOrder, nil)
+
+	if err := generateHTML(data, dir, options); err != nil {
+		t.Fatal(err)
+	}
+	if err := generateRSS(data, dir, options); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"index.html", "feed.xml"} {
+		content := readOutput(t, dir, name)
+		if !strings.Contains(content, "https://scryfall.com/card/dom/168") {
+			t.Errorf("%s doesn't link the card by set and collector number", name)
+		}
+		if strings.Contains(content, "https://scryfall.com/card/"+uuid) {
+			t.Errorf("%s links the card by its id", name)
+		}
+	}
+}
+
+func TestScryfallCardURL(t *testing.T) {
+	tests := []struct {
+		id, set, number string
+		want            string
+	}{
+		{"abc", "dom", "168", "https://scryfall.com/card/dom/168"},
+		{"abc", "plst", "DOM-168", "https://scryfall.com/card/plst/DOM-168"},
+		{"abc", "sld", "1★", "https://scryfall.com/card/sld/1%E2%98%85"},
+		// Legacy mappings without the printing's details fall back to the id
+		{"abc", "", "", "https://scryfall.com/card/abc"},
+		{"abc", "dom", "", "https://scryfall.com/card/abc"},
+	}
+	for _, test := range tests {
+		if got := scryfallCardURL(test.id, test.set, test.number); got != test.want {
+			t.Errorf("scryfallCardURL(%q, %q, %q) = %s, want %s", test.id, test.set, test.number, got, test.want)
+		}
+	}
+}
+
+// elementIDPattern matches the id attributes of rendered HTML
+var elementIDPattern = regexp.MustCompile(`\sid="([^"]*)"`)
+
+// elementIDs counts the ids of an HTML page
+func elementIDs(html string) map[string]int {
+	ids := make(map[string]int)
+	for _, match := range elementIDPattern.FindAllStringSubmatch(html, -1) {
+		ids[match[1]]++
+	}
+	return ids
+}
+
+func TestEveryDayHasAnAnchor(t *testing.T) {
+	dir := t.TempDir()
+	options := testRenderOptions(t, dir)
+	cardLookup := map[string]Card{
+		"p1": testCardPrinting("p1", "o1", "First", "tst", "1"),
+		"p2": testCardPrinting("p2", "o2", "Second", "tst", "2"),
+		"p3": testCardPrinting("p3", "o3", "Third", "tst", "3"),
+	}
+	history := HistoryData{Days: []DayResult{
+		{Date: "2024-05-01", FirstRun: true, TotalCards: 100},
+		{Date: "2024-05-02", AddedOracles: []string{"o1"}},
+		{Date: "2024-05-03", TotalCards: 101}, // Nothing changed, no section
+		{Date: "2024-05-04", AddedOracles: []string{"o2", "o3"}},
+		{Date: "2024-05-05", RemovedOracles: []string{"o1"}},
+	}}
+	data := convertToDisplayData(history, cardLookup, groupCardsByOracle(cardLookup), options.CardOrder, nil)
+	if err := generateHTML(data, dir, options); err != nil {
+		t.Fatal(err)
+	}
+	if err := generateDayPages(data, dir, options, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	index := readOutput(t, dir, "index.html")
+	indexIDs := elementIDs(index)
+	sections := strings.Count(index, `class="day"`)
+	emitted := 0
+	for _, day := range data.Days {
+		if !day.FirstRun && !day.HasChanges() {
+			if indexIDs[day.Date] != 0 {
+				t.Errorf("index has a section for %s, which has no changes", day.Date)
+			}
+			continue
+		}
+		emitted++
+		if indexIDs[day.Date] != 1 {
+			t.Errorf("index has %d elements with id %s, want 1", indexIDs[day.Date], day.Date)
+		}
+		if !strings.Contains(index, `href="#`+day.Date+`"`) {
+			t.Errorf("index has no anchor link to %s", day.Date)
+		}
+		if ids := elementIDs(readOutput(t, dir, filepath.Join("day", day.Date+".html"))); ids[day.Date] != 1 {
+			t.Errorf("page of %s has %d elements with its id, want 1", day.Date, ids[day.Date])
+		}
+	}
+	if emitted != 4 || sections != emitted {
+		t.Errorf("index has %d day sections for %d emitted days, want 4", sections, emitted)
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// renderCacheFile is the build cache of a track, see RenderCache. It is kept next to the history, e.g.
+// data/render-cache.json or data/render-cache-standard.json, so it is committed with it and the next
+// run of the workflow finds it.
+func renderCacheFile(historyFile string, format string, games string) string {
+	suffix := ""
+	if name := trackName(format, games); name != "" {
+		suffix = "-" + name
+	}
+	return filepath.Join(filepath.Dir(historyFile), "render-cache"+suffix+".json")
+}
+
+// renderCacheVersion changes with how days are converted, so caches of older renderers are dropped
+const renderCacheVersion = 1
+
+// RenderCache keeps each day's DisplayDay and what its page was written from between renders, so only
+// days whose history entry or cards changed are converted again and only pages whose content changed
+// are written. A cache written with other templates or settings is started over.
+type RenderCache struct {
+	Version   int                  `json:"version"`
+	Templates string               `json:"templates"` // templateHash of the renderer that wrote it
+	Settings  string               `json:"settings"`  // renderSettings it was written with
+	Days      map[string]CachedDay `json:"days"`      // By date, only the days of the last render
+
+	previous map[string]CachedDay
+	reused   int
+}
+
+// CachedDay is a day of the render cache
+type CachedDay struct {
+	Input string          `json:"input"`          // dayInputHash of the history entry and its cards
+	Page  string          `json:"page,omitempty"` // pageHash of the day's page as last written
+	Day   json.RawMessage `json:"day"`            // The DisplayDay, before release countdowns
+}
+
+// templateHash identifies the templates of the day pages, overrides included, so changing any of them
+// rebuilds every page
+func templateHash(templates Templates) string {
+	return templates.hash(dayPageTemplates...)
+}
+
+// renderSettings identifies the options that change how days are converted and drawn
+func renderSettings(options RenderOptions, sortOrder string) string {
+	settings, _ := json.Marshal(struct {
+		Sort      string
+		Site      Site
+		Captions  bool
+		Proxy     string
+		Symbology map[string]ManaSymbol
+		Minify    bool
+	}{sortOrder, options.Site, options.Captions, options.ImageProxy.Template, options.Symbology, options.Minify})
+	return hashStrings(string(settings))
+}
+
+// loadRenderCache reads the cache of an earlier render with the same templates and settings. A missing,
+// unreadable or different cache, and any with force, starts over.
+func loadRenderCache(filename string, templates string, settings string, force bool) *RenderCache {
+	cache := &RenderCache{
+		Version:   renderCacheVersion,
+		Templates: templates,
+		Settings:  settings,
+		Days:      make(map[string]CachedDay),
+		previous:  make(map[string]CachedDay),
+	}
+	if force {
+		return cache
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("Reading the render cache failed, rendering every day", "file", filename, "err", err)
+		}
+		return cache
+	}
+	var previous RenderCache
+	if err := json.Unmarshal(data, &previous); err != nil {
+		slog.Warn("Render cache is invalid, rendering every day", "file", filename, "err", err)
+		return cache
+	}
+	if previous.Version != cache.Version || previous.Templates != cache.Templates || previous.Settings != cache.Settings {
+		slog.Info("Templates or settings changed since the last render, rendering every day")
+		return cache
+	}
+	if previous.Days != nil {
+		cache.previous = previous.Days
+	}
+	return cache
+}
+
+// displayDay returns the cached DisplayDay of a day whose history entry and cards are as they were,
+// else converts it. A nil cache always converts.
+func (cache *RenderCache) displayDay(day DayResult, cardLookup map[string]Card, cardsByOracle map[string][]Card, convert func() DisplayDay) DisplayDay {
+	if cache == nil {
+		return convert()
+	}
+
+	input := dayInputHash(day, cardLookup, cardsByOracle)
+	if cached, found := cache.previous[day.Date]; found && cached.Input == input {
+		var display DisplayDay
+		if err := json.Unmarshal(cached.Day, &display); err == nil {
+			display.CardGroups = groupCardsBySet(display.Cards)
+			cache.Days[day.Date] = cached
+			cache.reused++
+			return display
+		}
+	}
+
+	display := convert()
+	if raw, err := json.Marshal(display); err == nil {
+		cache.Days[day.Date] = CachedDay{Input: input, Day: raw}
+	}
+	return display
+}
+
+// pageChanged reports whether a day's page has to be written: its content changed since the last
+// render, or the file is missing. A nil cache writes every page.
+func (cache *RenderCache) pageChanged(day DisplayDay, proxy ImageProxy, filename string) bool {
+	if cache == nil {
+		return true
+	}
+
+	page := pageHash(day, proxy)
+	cached := cache.Days[day.Date]
+	_, err := os.Stat(filename)
+	changed := cached.Page != page || err != nil
+	cached.Page = page
+	cache.Days[day.Date] = cached
+	return changed
+}
+
+// save writes the cache for the next render
+func (cache *RenderCache) save(filename string) error {
+	if cache == nil {
+		return nil
+	}
+	slog.Info("Render cache", "reused_days", cache.reused, "converted_days", len(cache.Days)-cache.reused)
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+// dayInputHash identifies what a day is converted from: its history entry, card_mapping included, and
+// for each card it names the printings there are and the shown data of the ones it is drawn with. A card
+// that reaches the card cache later, gets a new printing, or whose shown data changes (a corrected name,
+// new images, a rarity or collector number fix) is picked up; the rest of the card data, such as
+// legalities and prices, is left out as it doesn't change the pages.
+func dayInputHash(day DayResult, cardLookup map[string]Card, cardsByOracle map[string][]Card) string {
+	entry, _ := json.Marshal(day)
+	parts := []string{string(entry)}
+
+	oracleIDs := append(append(append([]string{}, day.AddedOracles...), day.ArenaAdded...), day.RemovedOracles...)
+	for _, change := range day.LegalityChanges {
+		oracleIDs = append(oracleIDs, change.OracleID)
+	}
+	for _, oracleID := range oracleIDs {
+		parts = append(parts, oracleID)
+		candidates := cardsByOracle[oracleID]
+		for _, card := range candidates {
+			parts = append(parts, card.ID)
+		}
+		// New cards are shown with the day's pinned printing, the other sections with the best one
+		if card, found := pinnedDayCard(day, oracleID, cardLookup); found {
+			parts = append(parts, shownCardData(card))
+		}
+		if card, found := selectBestCard(candidates); found {
+			parts = append(parts, shownCardData(card))
+		}
+	}
+	// Legacy printing ids the history couldn't resolve to an oracle are shown as they are
+	for _, id := range day.UnresolvedCards {
+		parts = append(parts, id)
+		if card, found := cardLookup[id]; found {
+			parts = append(parts, shownCardData(card))
+		}
+	}
+	return hashStrings(parts...)
+}
+
+// shownCardData is the data of a printing that the pages and feeds show, see newDisplayCard
+func shownCardData(card Card) string {
+	display := newDisplayCard(card)
+	var images []string
+	for _, candidate := range display.ImageSizes {
+		images = append(images, candidate.URL)
+	}
+	return strings.Join([]string{
+		display.ID, display.Name, display.ImageURL, strings.Join(images, " "), display.ArtCropURL,
+		display.ScryfallURL, display.Set, display.SetName, display.ReleasedAt, strings.Join(display.Colors, ""),
+		strconv.FormatFloat(display.CMC, 'g', -1, 64), display.ManaCost, display.TypeLine, display.Rarity,
+	}, "\x00")
+}
+
+// pageHash identifies what a day's page is drawn from: the day, release countdowns included, and the
+// addresses its images are mirrored at
+func pageHash(day DisplayDay, proxy ImageProxy) string {
+	content, _ := json.Marshal(day)
+	parts := []string{string(content)}
+	if len(proxy.Mirrored) > 0 {
+		for _, imageURL := range collectImageURLs(DisplayData{Days: []DisplayDay{day}}) {
+			parts = append(parts, imageURL, proxy.Mirrored[imageURL])
+		}
+	}
+	return hashStrings(parts...)
+}
+
+// hashStrings returns a hex SHA-256 of the strings, each ended by a NUL so their boundaries count
+func hashStrings(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
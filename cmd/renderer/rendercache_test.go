@@ -0,0 +1,128 @@
+package main
+
+import "testing"
+
+func TestDayInputHashCardData(t *testing.T) {
+	card := Card{
+		ID:              "printing-1",
+		OracleID:        "oracle-1",
+		Name:            "Llanowar Elves",
+		Rarity:          "common",
+		Set:             "dom",
+		CollectorNumber: "168",
+		ImageURIs:       map[string]string{"normal": "https://cards.scryfall.io/normal/front/1.jpg"},
+	}
+	day := DayResult{Date: "2024-05-01", AddedOracles: []string{"oracle-1"}}
+	hash := func(card Card) string {
+		cardLookup := map[string]Card{card.ID: card}
+		return dayInputHash(day, cardLookup, groupCardsByOracle(cardLookup))
+	}
+	base := hash(card)
+	if hash(card) != base {
+		t.Fatal("dayInputHash is not stable")
+	}
+
+	changes := map[string]func(*Card){
+		"name": func(card *Card) { card.Name = "Llanowar Elf" },
+		"image": func(card *Card) {
+			card.ImageURIs = map[string]string{"normal": "https://cards.scryfall.io/normal/front/2.jpg"}
+		},
+		"rarity":           func(card *Card) { card.Rarity = "uncommon" },
+		"set":              func(card *Card) { card.Set = "m19" },
+		"collector number": func(card *Card) { card.CollectorNumber = "169" },
+	}
+	for name, change := range changes {
+		changed := card
+		change(&changed)
+		if hash(changed) == base {
+			t.Errorf("changing the %s keeps the day's input hash", name)
+		}
+	}
+}
+
+func TestRenderCacheReconvertsChangedCards(t *testing.T) {
+	day := DayResult{Date: "2024-05-01", AddedOracles: []string{"oracle-1"}}
+	cardLookup := map[string]Card{"printing-1": {ID: "printing-1", OracleID: "oracle-1", Name: "Old Name"}}
+
+	converted := 0
+	render := func(previous *RenderCache) *RenderCache {
+		cache := loadRenderCache("", "templates", "settings", true)
+		if previous != nil {
+			cache.previous = previous.Days
+		}
+		cache.displayDay(day, cardLookup, groupCardsByOracle(cardLookup), func() DisplayDay {
+			converted++
+			return DisplayDay{Date: day.Date}
+		})
+		return cache
+	}
+
+	first := render(nil)
+	second := render(first)
+	if converted != 1 {
+		t.Fatalf("unchanged day converted %d times, want once", converted)
+	}
+	cardLookup["printing-1"] = Card{ID: "printing-1", OracleID: "oracle-1", Name: "New Name"}
+	render(second)
+	if converted != 2 {
+		t.Errorf("day converted %d times after its card was renamed, want 2", converted)
+	}
+}
+
+func TestDayInputHashCardMapping(t *testing.T) {
+	pinned := Card{ID: "printing-1", OracleID: "oracle-1", Name: "Llanowar Elves", Set: "dom", CollectorNumber: "168"}
+	reprint := Card{ID: "printing-2", OracleID: "oracle-1", Name: "Llanowar Elves", Set: "m19", CollectorNumber: "314"}
+	day := DayResult{
+		Date:         "2024-05-01",
+		AddedOracles: []string{"oracle-1"},
+		CardMapping:  map[string]PinnedPrinting{"oracle-1": {ID: "printing-1", Set: "dom", CollectorNumber: "168"}},
+	}
+	hash := func(day DayResult, cards ...Card) string {
+		cardLookup := make(map[string]Card)
+		for _, card := range cards {
+			cardLookup[card.ID] = card
+		}
+		return dayInputHash(day, cardLookup, groupCardsByOracle(cardLookup))
+	}
+	base := hash(day, pinned, reprint)
+
+	// Data the pages don't show doesn't convert the day again
+	legalities := pinned
+	legalities.Legalities = map[string]string{"brawl": "legal", "standard": "not_legal"}
+	legalities.OracleText = "{T}: Add {G}."
+	if hash(day, legalities, reprint) != base {
+		t.Error("a change of the legalities and rules text changes the day's input hash")
+	}
+
+	repinned := day
+	repinned.CardMapping = map[string]PinnedPrinting{"oracle-1": {ID: "printing-2", Set: "m19", CollectorNumber: "314"}}
+	if hash(repinned, pinned, reprint) == base {
+		t.Error("pinning another printing keeps the day's input hash")
+	}
+	newPrinting := Card{ID: "printing-3", OracleID: "oracle-1", Name: "Llanowar Elves", Set: "fdn", CollectorNumber: "227"}
+	if hash(day, pinned, reprint, newPrinting) == base {
+		t.Error("a new printing keeps the day's input hash")
+	}
+	renamed := pinned
+	renamed.Name = "Llanowar Elf"
+	if hash(day, renamed, reprint) == base {
+		t.Error("renaming the pinned printing keeps the day's input hash")
+	}
+}
+
+func TestRenderCacheFile(t *testing.T) {
+	tests := []struct {
+		history, format, games string
+		want                   string
+	}{
+		{"data/history.json", "brawl", "", "data/render-cache.json"},
+		{"data/history-standard.json", "standard", "", "data/render-cache-standard.json"},
+		{"data/history-brawl-arena.json", "brawl", "arena", "data/render-cache-brawl-arena.json"},
+		{"history.json", "brawl", "", "render-cache.json"},
+	}
+	for _, test := range tests {
+		if got := renderCacheFile(test.history, test.format, test.games); got != test.want {
+			t.Errorf("renderCacheFile(%q, %q, %q) = %q, want %q", test.history, test.format, test.games, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// historySchemaVersion is the newest history format the renderer understands, see the fetcher's
+// historySchemaVersion for what each version changed
+const historySchemaVersion = 4
+
+// upgradeHistory brings a valid history to historySchemaVersion, so the pages don't have to tell
+// formats apart
+func upgradeHistory(history HistoryData) HistoryData {
+	if history.SchemaVersion < 1 {
+		// Days the fetcher hasn't translated yet list printing ids, which are shown as such like
+		// the printings it couldn't translate
+		for i, day := range history.Days {
+			if day.AddedOracles == nil && day.AddedCards != nil {
+				day.AddedOracles = []string{}
+				day.UnresolvedCards = append(day.UnresolvedCards, day.AddedCards...)
+				day.AddedCards = nil
+				history.Days[i] = day
+			}
+		}
+	}
+	// Version 2 only added optional fields, and the bare printing ids in card_mapping before version 3
+	// decode as pinned printings without details. The baseline_oracles of version 4 only matter to the fetcher.
+	history.SchemaVersion = historySchemaVersion
+	return history
+}
+
+// Validate rejects a history written by a newer fetcher, which this renderer would misread, and days
+// whose date isn't YYYY-MM-DD and dates with two entries, which the pages would show as garbled or
+// doubled days
+func (history HistoryData) Validate() error {
+	if history.SchemaVersion > historySchemaVersion {
+		return fmt.Errorf("schema_version %d is newer than the %d this renderer understands, update it", history.SchemaVersion, historySchemaVersion)
+	}
+	seen := make(map[string]int)
+	for i, day := range history.Days {
+		if _, err := time.Parse("2006-01-02", day.Date); err != nil {
+			return fmt.Errorf("day %d: date %q is not YYYY-MM-DD", i+1, day.Date)
+		}
+		if first, found := seen[day.Date]; found {
+			return fmt.Errorf("date %s has two entries, days %d and %d", day.Date, first, i+1)
+		}
+		seen[day.Date] = i + 1
+	}
+	return nil
+}
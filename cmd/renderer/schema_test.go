@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUpgradeHistory(t *testing.T) {
+	tests := []struct {
+		name    string
+		history string
+		want    []DayResult
+	}{
+		{
+			// Printing ids the fetcher hasn't translated yet are shown as they are
+			name: "v0",
+			history: `{"days": [
+				{"date": "2024-05-01", "first_run": true, "added_cards": ["p-a"]},
+				{"date": "2024-05-02", "added_cards": ["p-b", "p-c"]}
+			]}`,
+			want: []DayResult{
+				{Date: "2024-05-01", FirstRun: true, AddedOracles: []string{}, UnresolvedCards: []string{"p-a"}},
+				{Date: "2024-05-02", AddedOracles: []string{}, UnresolvedCards: []string{"p-b", "p-c"}},
+			},
+		},
+		{
+			name: "v1",
+			history: `{"schema_version": 1, "days": [
+				{"date": "2024-05-02", "added_oracles": ["b"]}
+			]}`,
+			want: []DayResult{{Date: "2024-05-02", AddedOracles: []string{"b"}}},
+		},
+		{
+			// Bare pinned ids are printings without details, printing ids left untranslated are kept
+			name: "v2",
+			history: `{"schema_version": 2, "days": [
+				{"date": "2024-05-02", "added_oracles": ["b"], "card_mapping": {"b": "p-b"}, "unresolved_cards": ["p-gone"]}
+			]}`,
+			want: []DayResult{{
+				Date: "2024-05-02", AddedOracles: []string{"b"},
+				CardMapping:     map[string]PinnedPrinting{"b": {ID: "p-b"}},
+				UnresolvedCards: []string{"p-gone"},
+			}},
+		},
+		{
+			name: "v3",
+			history: `{"schema_version": 3, "days": [
+				{"date": "2024-05-02", "added_oracles": ["b"], "card_mapping": {"b": {"id": "p-b", "set": "dom", "collector_number": "2", "released_at": "2018-04-27"}}}
+			]}`,
+			want: []DayResult{{
+				Date: "2024-05-02", AddedOracles: []string{"b"},
+				CardMapping: map[string]PinnedPrinting{"b": {ID: "p-b", Set: "dom", CollectorNumber: "2", ReleasedAt: "2018-04-27"}},
+			}},
+		},
+		{
+			// baseline_oracles only matter to the fetcher
+			name: "v4",
+			history: `{"schema_version": 4, "baseline_oracles": ["a"], "days": [
+				{"date": "2024-06-01", "added_oracles": ["c"]}
+			]}`,
+			want: []DayResult{{Date: "2024-06-01", AddedOracles: []string{"c"}}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var history HistoryData
+			if err := json.Unmarshal([]byte(test.history), &history); err != nil {
+				t.Fatal(err)
+			}
+			if err := history.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			upgraded := upgradeHistory(history)
+			if upgraded.SchemaVersion != historySchemaVersion {
+				t.Errorf("schema_version = %d after the upgrade, want %d", upgraded.SchemaVersion, historySchemaVersion)
+			}
+			if !reflect.DeepEqual(upgraded.Days, test.want) {
+				t.Errorf("upgraded days = %+v\nwant %+v", upgraded.Days, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsNewerSchema(t *testing.T) {
+	history := HistoryData{SchemaVersion: historySchemaVersion + 1, Days: []DayResult{{Date: "2024-05-01"}}}
+	err := history.Validate()
+	if err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("Validate() = %v, want the newer schema_version refused", err)
+	}
+
+	history.SchemaVersion = historySchemaVersion
+	if err := history.Validate(); err != nil {
+		t.Errorf("Validate() = %v for the current schema_version", err)
+	}
+}
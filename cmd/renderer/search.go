@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/json"
+	"html/template"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SearchEntry is a single card in docs/search-index.json
+type SearchEntry struct {
+	Name        string `json:"name"`
+	Date        string `json:"date"`
+	OracleID    string `json:"oracle_id"`
+	TypeLine    string `json:"type_line,omitempty"`
+	OracleText  string `json:"oracle_text,omitempty"`
+	Image       string `json:"image,omitempty"`
+	ScryfallURL string `json:"scryfall_url,omitempty"`
+}
+
+// reminderTextPattern matches parenthesized reminder text such as "(This creature can't be blocked...)"
+var reminderTextPattern = regexp.MustCompile(`\s*\([^()]*\)`)
+
+// generateSearch writes the search index and the search page
+func generateSearch(history HistoryData, cardLookup map[string]Card, cardsByOracle map[string][]Card, outputDir string, options RenderOptions) error {
+	entries := buildSearchIndex(history, cardLookup, cardsByOracle, options)
+	slog.Info("Search index generated", "cards", len(entries))
+
+	file, err := os.Create(filepath.Join(outputDir, "search-index.json"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := json.NewEncoder(file).Encode(entries); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(outputDir, "search.js"), []byte(searchScript), 0644); err != nil {
+		return err
+	}
+	return generateSearchPage(outputDir, options)
+}
+
+// buildSearchIndex lists every chronicled oracle once, under the earliest day it was added
+func buildSearchIndex(history HistoryData, cardLookup map[string]Card, cardsByOracle map[string][]Card, options RenderOptions) []SearchEntry {
+	// Walk days oldest first so the earliest date wins
+	days := make([]DayResult, len(history.Days))
+	copy(days, history.Days)
+	sort.SliceStable(days, func(i, j int) bool {
+		return days[i].Date < days[j].Date
+	})
+
+	seen := make(map[string]bool)
+	var entries []SearchEntry
+
+	for _, day := range days {
+		for _, oracleID := range day.AddedOracles {
+			if seen[oracleID] {
+				continue
+			}
+			seen[oracleID] = true
+
+			card, found := selectDayCard(day, oracleID, cardLookup, cardsByOracle[oracleID])
+			if !found {
+				continue
+			}
+
+			// The first run's thousands of cards link to Scryfall without an image, which keeps the
+			// index small
+			image := ""
+			if !day.FirstRun {
+				image = options.ImageProxy.Rewrite(selectImageURL(cardImageURIs(card)), searchImageWidth)
+			}
+			entries = append(entries, SearchEntry{
+				Name:        card.Name,
+				Date:        day.Date,
+				OracleID:    oracleID,
+				TypeLine:    card.TypeLine,
+				OracleText:  searchableOracleText(card),
+				Image:       image,
+				ScryfallURL: scryfallCardURL(card.ID, card.Set, card.CollectorNumber),
+			})
+		}
+	}
+
+	// Newest first, then by name, so the index is stable between runs
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Date != entries[j].Date {
+			return entries[i].Date > entries[j].Date
+		}
+		return entries[i].Name < entries[j].Name
+	})
+
+	return entries
+}
+
+// searchableOracleText returns the rules text without reminder text, joining faces of multi-faced cards
+func searchableOracleText(card Card) string {
+	text := card.OracleText
+	if text == "" && len(card.CardFaces) > 0 {
+		var faces []string
+		for _, face := range card.CardFaces {
+			if face.OracleText != "" {
+				faces = append(faces, face.OracleText)
+			}
+		}
+		text = strings.Join(faces, "\n//\n")
+	}
+
+	return strings.TrimSpace(reminderTextPattern.ReplaceAllString(text, ""))
+}
+
+// selectImageURL picks the image to display (prefer normal, fallback to large, then small)
+func selectImageURL(imageURIs map[string]string) string {
+	if imageURIs == nil {
+		return ""
+	}
+	if url, ok := imageURIs["normal"]; ok {
+		return url
+	} else if url, ok := imageURIs["large"]; ok {
+		return url
+	} else if url, ok := imageURIs["small"]; ok {
+		return url
+	}
+	return ""
+}
+
+// searchScript is docs/search.js, which matches the query against the loaded search index. Every term
+// has to appear in the name, type line or rules text; names that only contain the letters of the
+// query in order are listed after those.
+const searchScript = `(function () {
+    var input = document.getElementById("search-input");
+    var status = document.getElementById("search-status");
+    var results = document.getElementById("search-results");
+    var fields = [["name", "Name"], ["type_line", "Type"], ["oracle_text", "Text"]];
+    var index = [];
+
+    // Split the query into lowercase terms, keeping "quoted phrases" together
+    function tokenize(query) {
+        var terms = [];
+        var re = /"([^"]+)"|(\S+)/g;
+        var m;
+        while ((m = re.exec(query.toLowerCase())) !== null) {
+            terms.push(m[1] || m[2]);
+        }
+        return terms;
+    }
+
+    // fuzzyName reports whether the letters of the query appear in the card's name in order, so
+    // typos of missing letters and abbreviations such as "lrdwndgrc" still find the card
+    function fuzzyName(entry, query) {
+        var name = entry.name.toLowerCase();
+        var at = 0;
+        for (var i = 0; i < query.length; i++) {
+            at = name.indexOf(query[i], at);
+            if (at === -1) {
+                return false;
+            }
+            at++;
+        }
+        return true;
+    }
+
+    // Every term must match at least one field; returns the labels of matched fields
+    function match(entry, terms) {
+        var matched = {};
+        for (var i = 0; i < terms.length; i++) {
+            var hit = false;
+            for (var f = 0; f < fields.length; f++) {
+                var value = entry[fields[f][0]];
+                if (value && value.toLowerCase().indexOf(terms[i]) !== -1) {
+                    matched[fields[f][1]] = true;
+                    hit = true;
+                }
+            }
+            if (!hit) {
+                return null;
+            }
+        }
+        return Object.keys(matched);
+    }
+
+    function render() {
+        var terms = tokenize(input.value);
+        results.innerHTML = "";
+        if (terms.length === 0) {
+            status.textContent = index.length.toLocaleString() + " cards indexed";
+            return;
+        }
+
+        // Substring matches first, then names that only match fuzzily
+        var hits = [];
+        var matched = {};
+        for (var i = 0; i < index.length && hits.length < 200; i++) {
+            var badges = match(index[i], terms);
+            if (badges !== null) {
+                hits.push([index[i], badges]);
+                matched[i] = true;
+            }
+        }
+        var query = input.value.toLowerCase().replace(/[^a-z0-9]/g, "");
+        for (var i = 0; i < index.length && hits.length < 200 && query.length >= 3; i++) {
+            if (!matched[i] && fuzzyName(index[i], query)) {
+                hits.push([index[i], ["Similar name"]]);
+            }
+        }
+
+        for (var h = 0; h < hits.length; h++) {
+            var entry = hits[h][0];
+            var badges = hits[h][1];
+
+            var row = document.createElement("div");
+            row.className = "search-result";
+
+            var link = document.createElement("a");
+            link.href = entry.scryfall_url;
+            link.target = "_blank";
+            link.textContent = entry.name;
+            row.appendChild(link);
+
+            for (var b = 0; b < badges.length; b++) {
+                var badge = document.createElement("span");
+                badge.className = "search-badge";
+                badge.textContent = badges[b];
+                row.appendChild(badge);
+            }
+
+            var date = document.createElement("a");
+            date.className = "search-date";
+            date.href = "day/" + entry.date + ".html";
+            date.textContent = entry.date;
+            row.appendChild(date);
+
+            if (entry.type_line) {
+                var type = document.createElement("div");
+                type.className = "search-type";
+                type.textContent = entry.type_line;
+                row.appendChild(type);
+            }
+
+            results.appendChild(row);
+        }
+        status.textContent = hits.length === 200 ? "Showing first 200 matches" : hits.length + " matches";
+    }
+
+    fetch("search-index.json")
+        .then(function (response) { return response.json(); })
+        .then(function (data) {
+            index = data || [];
+            input.addEventListener("input", render);
+            render();
+        })
+        .catch(function () {
+            status.textContent = "Could not load the search index.";
+        });
+})();
+`
+
+func generateSearchPage(outputDir string, options RenderOptions) error {
+	t := template.New("search").Funcs(options.Site.funcMap())
+	if err := options.Templates.parseHTML(t, "search.html.tmpl"); err != nil {
+		return err
+	}
+
+	return writeTemplateFile(filepath.Join(outputDir, "search.html"), t, nil, options.Minify)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Where a card's set in the set API comes from
+const (
+	setSourceRecorded        = "recorded"         // Printing recorded by the fetcher when the card was added
+	setSourceCurrentPrinting = "current_printing" // Best printing in today's card cache
+)
+
+// SetAPICard is a card entry in docs/api/sets/<set_code>.json
+type SetAPICard struct {
+	Name        string `json:"name"`
+	OracleID    string `json:"oracle_id"`
+	Image       string `json:"image,omitempty"`
+	ScryfallURL string `json:"scryfall_url"`
+	DayURL      string `json:"day_url"`
+	DateAdded   string `json:"date_added"`
+	SetSource   string `json:"set_source"`
+}
+
+// SetAPIFile is the content of docs/api/sets/<set_code>.json
+type SetAPIFile struct {
+	Code        string       `json:"code"`
+	Name        string       `json:"name"`
+	ReleaseDate string       `json:"release_date,omitempty"`
+	Cards       []SetAPICard `json:"cards"`
+}
+
+// SetAPIIndexEntry summarizes one set in docs/api/sets/index.json
+type SetAPIIndexEntry struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	ReleaseDate string `json:"release_date,omitempty"`
+	Count       int    `json:"count"`
+	URL         string `json:"url"`
+}
+
+// generateSetAPI writes one JSON file per set with the cards added from it, plus an index
+func generateSetAPI(history HistoryData, cardLookup map[string]Card, cardsByOracle map[string][]Card, outputDir string, options RenderOptions) error {
+	sets := buildSetAPI(history, cardLookup, cardsByOracle, options)
+
+	setsDir := filepath.Join(outputDir, "api", "sets")
+	// Start from scratch so sets that no longer have cards don't linger
+	if err := os.RemoveAll(setsDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(setsDir, 0755); err != nil {
+		return err
+	}
+
+	var index []SetAPIIndexEntry
+	for _, set := range sets {
+		if err := writeJSONFile(filepath.Join(setsDir, set.Code+".json"), set); err != nil {
+			return err
+		}
+		index = append(index, SetAPIIndexEntry{
+			Code:        set.Code,
+			Name:        set.Name,
+			ReleaseDate: set.ReleaseDate,
+			Count:       len(set.Cards),
+			URL:         options.Site.URL + "api/sets/" + set.Code + ".json",
+		})
+	}
+
+	slog.Info("Set API generated", "sets", len(index))
+	return writeJSONFile(filepath.Join(setsDir, "index.json"), index)
+}
+
+// buildSetAPI groups every card added after the first run by the set of its printing
+func buildSetAPI(history HistoryData, cardLookup map[string]Card, cardsByOracle map[string][]Card, options RenderOptions) []SetAPIFile {
+	setsByCode := make(map[string]*SetAPIFile)
+
+	for _, day := range history.Days {
+		if day.FirstRun {
+			continue
+		}
+
+		for _, oracleID := range day.AddedOracles {
+			card, source, found := resolveSetCard(day, oracleID, cardLookup, cardsByOracle)
+			if !found || card.Set == "" {
+				continue
+			}
+
+			set, exists := setsByCode[card.Set]
+			if !exists {
+				set = &SetAPIFile{Code: card.Set, Name: card.SetName}
+				setsByCode[card.Set] = set
+			}
+			if card.ReleasedAt != "" && (set.ReleaseDate == "" || card.ReleasedAt < set.ReleaseDate) {
+				set.ReleaseDate = card.ReleasedAt
+			}
+
+			set.Cards = append(set.Cards, SetAPICard{
+				Name:        card.Name,
+				OracleID:    oracleID,
+				Image:       options.ImageProxy.Rewrite(selectImageURL(cardImageURIs(card)), searchImageWidth),
+				ScryfallURL: scryfallCardURL(card.ID, card.Set, card.CollectorNumber),
+				DayURL:      options.Site.URL + "#" + day.Date,
+				DateAdded:   day.Date,
+				SetSource:   source,
+			})
+		}
+	}
+
+	var sets []SetAPIFile
+	for _, set := range setsByCode {
+		sort.Slice(set.Cards, func(i, j int) bool {
+			if set.Cards[i].Name != set.Cards[j].Name {
+				return set.Cards[i].Name < set.Cards[j].Name
+			}
+			return set.Cards[i].OracleID < set.Cards[j].OracleID
+		})
+		sets = append(sets, *set)
+	}
+
+	// Newest release first
+	sort.Slice(sets, func(i, j int) bool {
+		if sets[i].ReleaseDate != sets[j].ReleaseDate {
+			return sets[i].ReleaseDate > sets[j].ReleaseDate
+		}
+		return sets[i].Code < sets[j].Code
+	})
+
+	return sets
+}
+
+// resolveSetCard prefers the printing the fetcher recorded for the day and
+// falls back to the best printing currently in the card cache
+func resolveSetCard(day DayResult, oracleID string, cardLookup map[string]Card, cardsByOracle map[string][]Card) (Card, string, bool) {
+	if card, ok := pinnedDayCard(day, oracleID, cardLookup); ok {
+		return card, setSourceRecorded, true
+	}
+
+	card, found := selectBestCard(cardsByOracle[oracleID])
+	return card, setSourceCurrentPrinting, found
+}
+
+// writeJSONFile writes v as indented JSON
+func writeJSONFile(filename string, v interface{}) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// historyIndexName is the file of a sharded history that lists its yearly shards, see the fetcher's -shard
+const historyIndexName = "index.json"
+
+// HistoryIndex is everything of a sharded history but the days
+type HistoryIndex struct {
+	SchemaVersion int         `json:"schema_version"`
+	Meta          HistoryMeta `json:"meta"`
+	Shards        []string    `json:"shards"`
+}
+
+// HistoryShard holds the days of one year
+type HistoryShard struct {
+	Year string      `json:"year"`
+	Days []DayResult `json:"days"`
+}
+
+// shardedHistoryDir returns the directory of the sharded history a path names, either the directory
+// itself or the history.json it replaced; found is false for a monolithic history
+func shardedHistoryDir(filename string) (string, bool) {
+	for _, dir := range []string{filename, strings.TrimSuffix(filename, ".json")} {
+		if _, err := os.Stat(filepath.Join(dir, historyIndexName)); err == nil {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+// loadShardedHistory joins the index and its shards into one history
+func loadShardedHistory(dir string) (HistoryData, error) {
+	var index HistoryIndex
+	if err := readJSONFile(filepath.Join(dir, historyIndexName), &index); err != nil {
+		return HistoryData{}, err
+	}
+
+	history := HistoryData{SchemaVersion: index.SchemaVersion, Meta: index.Meta}
+	for _, name := range index.Shards {
+		if filepath.Base(name) != name {
+			return HistoryData{}, fmt.Errorf("%s lists invalid shard %q", filepath.Join(dir, historyIndexName), name)
+		}
+		var shard HistoryShard
+		if err := readJSONFile(filepath.Join(dir, name), &shard); err != nil {
+			return HistoryData{}, err
+		}
+		history.Days = append(history.Days, shard.Days...)
+	}
+	return history, nil
+}
+
+func readJSONFile(filename string, value any) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	return nil
+}
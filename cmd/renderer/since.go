@@ -0,0 +1,169 @@
+package main
+
+import (
+	"html/template"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Checkpoint is a reference date for a "what's new since" page
+type Checkpoint struct {
+	Slug  string // File name under docs/since/
+	Title string
+	Date  string // Empty when the checkpoint can't be determined
+	Note  string // Explains why Date is empty
+}
+
+// SincePage is the template data for a single checkpoint page
+type SincePage struct {
+	Checkpoint
+	Checkpoints []Checkpoint
+	Cards       []DisplayCard
+	DayCount    int
+}
+
+// releaseSetTypes are the set types whose release counts as a "set release" checkpoint
+var releaseSetTypes = map[string]bool{
+	"expansion": true,
+	"core":      true,
+}
+
+// generateSincePages writes docs/since/<checkpoint>.html for every checkpoint
+func generateSincePages(checkpoints []Checkpoint, displayData DisplayData, outputDir string, options RenderOptions) error {
+
+	sinceDir := filepath.Join(outputDir, "since")
+	if err := os.MkdirAll(sinceDir, 0755); err != nil {
+		return err
+	}
+
+	funcMap := template.FuncMap{
+		"thousands": addThousandsSeparator,
+		"image": func(url string) string {
+			return options.ImageProxy.Rewrite(url, htmlImageWidth)
+		},
+	}
+
+	t := template.New("since").Funcs(options.Site.funcMap()).Funcs(funcMap)
+	if err := options.Templates.parseHTML(t, "since.html.tmpl"); err != nil {
+		return err
+	}
+
+	for _, checkpoint := range checkpoints {
+		slog.Info("Checkpoint", "slug", checkpoint.Slug, "since", checkpoint.Date, "note", checkpoint.Note)
+
+		page := SincePage{
+			Checkpoint:  checkpoint,
+			Checkpoints: checkpoints,
+		}
+		if checkpoint.Date != "" {
+			page.Cards, page.DayCount = collectCardsSince(displayData, checkpoint.Date, options.CardOrder)
+		}
+
+		if err := writeTemplateFile(filepath.Join(sinceDir, checkpoint.Slug+".html"), t, page, options.Minify); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// buildCheckpoints determines the checkpoint dates relative to the newest day in history
+func buildCheckpoints(history HistoryData, cardLookup map[string]Card, options RenderOptions) []Checkpoint {
+	lastSet := Checkpoint{Slug: "last-set", Title: "the last set release"}
+	rotation := Checkpoint{Slug: "last-rotation", Title: "the last rotation"}
+	last30 := Checkpoint{Slug: "30-days", Title: "30 days ago"}
+	last90 := Checkpoint{Slug: "90-days", Title: "90 days ago"}
+
+	reference, found := newestHistoryDate(history)
+	if !found {
+		note := "No data available yet."
+		lastSet.Note, rotation.Note, last30.Note, last90.Note = note, note, note, note
+		return []Checkpoint{lastSet, rotation, last30, last90}
+	}
+
+	if date, setName := lastSetRelease(cardLookup, reference); date != "" {
+		lastSet.Title = setName
+		lastSet.Date = date
+	} else {
+		lastSet.Note = "No set release found among the tracked cards."
+	}
+
+	if options.RotationDate != "" {
+		rotation.Date = options.RotationDate
+	} else {
+		rotation.Note = "No rotation date is configured for this site."
+	}
+
+	last30.Date = reference.AddDate(0, 0, -30).Format("2006-01-02")
+	last90.Date = reference.AddDate(0, 0, -90).Format("2006-01-02")
+
+	return []Checkpoint{lastSet, rotation, last30, last90}
+}
+
+// newestHistoryDate returns the date of the newest day in history
+func newestHistoryDate(history HistoryData) (time.Time, bool) {
+	var newest time.Time
+	for _, day := range history.Days {
+		date, err := time.Parse("2006-01-02", day.Date)
+		if err != nil {
+			continue
+		}
+		if date.After(newest) {
+			newest = date
+		}
+	}
+	return newest, !newest.IsZero()
+}
+
+// lastSetRelease finds the most recent expansion or core set released on or before the reference date
+func lastSetRelease(cardLookup map[string]Card, reference time.Time) (string, string) {
+	limit := reference.Format("2006-01-02")
+	var date, setName string
+
+	for _, card := range cardLookup {
+		if !releaseSetTypes[card.SetType] || card.ReleasedAt == "" || card.ReleasedAt > limit {
+			continue
+		}
+		if card.ReleasedAt > date || (card.ReleasedAt == date && card.SetName < setName) {
+			date = card.ReleasedAt
+			setName = card.SetName
+		}
+	}
+
+	return date, setName
+}
+
+// collectCardsSince aggregates the cards added on or after the given date, deduplicated by oracle
+func collectCardsSince(displayData DisplayData, since string, order CardOrder) ([]DisplayCard, int) {
+	seen := make(map[string]bool)
+	var cards []DisplayCard
+	dayCount := 0
+
+	for _, day := range displayData.Days {
+		if day.FirstRun || day.Date < since || len(day.Cards) == 0 {
+			continue
+		}
+		dayCount++
+
+		for _, card := range day.Cards {
+			key := card.OracleID
+			if key == "" {
+				key = card.ID
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			cards = append(cards, card)
+		}
+	}
+
+	sort.Slice(cards, func(i, j int) bool {
+		return order(cards[i], cards[j])
+	})
+
+	return cards, dayCount
+}
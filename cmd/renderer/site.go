@@ -0,0 +1,127 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// formatNamePattern matches Scryfall legality keys such as "brawl" or "paupercommander"
+var formatNamePattern = regexp.MustCompile(`^[a-z]+$`)
+
+// formatDisplayNames are the names of Scryfall formats that don't read well capitalized
+var formatDisplayNames = map[string]string{
+	"standardbrawl":   "Standard Brawl",
+	"paupercommander": "Pauper Commander",
+	"duel":            "Duel Commander",
+	"oldschool":       "Old School",
+	"predh":           "PreDH",
+}
+
+// Site describes the chronicle of one format. Brawl renders to the root of the output directory (docs/
+// by default), other formats and single-game histories to their own subdirectory sharing the stylesheet
+// at the root.
+type Site struct {
+	Format     string // Scryfall format name, e.g. "brawl"
+	FormatName string // Display name, e.g. "Brawl" or "Arena Brawl"
+	URL        string // Public address of the format's pages, ending in "/"
+	OutputDir  string // Directory the pages are written to
+	AssetPath  string // Relative path from the pages to the shared assets at the output root
+	Games      string // Game the tracked printings are limited to, empty for all
+}
+
+// gameAvailability completes "only cards with a printing ..." for each -games mode of the fetcher
+var gameAvailability = map[string]string{
+	"arena": "on Arena",
+	"paper": "in paper",
+	"mtgo":  "on MTGO",
+}
+
+// trackName identifies a history in directory names: empty for Brawl in any game, otherwise
+// the format and the game it is limited to, e.g. "standard" or "brawl-arena"
+func trackName(format string, games string) string {
+	if games != "" {
+		return format + "-" + games
+	}
+	if format == "brawl" {
+		return ""
+	}
+	return format
+}
+
+// newSite returns the site layout of a format under outputRoot, published at baseURL, limited to the
+// printings of one game when games is set
+func newSite(format string, games string, outputRoot string, baseURL string) Site {
+	name, found := formatDisplayNames[format]
+	if !found {
+		name = strings.ToUpper(format[:1]) + format[1:]
+	}
+	if games == "mtgo" {
+		name = "MTGO " + name
+	} else if games != "" {
+		name = strings.ToUpper(games[:1]) + games[1:] + " " + name
+	}
+
+	dir := trackName(format, games)
+	if dir == "" {
+		return Site{Format: format, FormatName: name, URL: baseURL, OutputDir: outputRoot, Games: games}
+	}
+	return Site{
+		Format:     format,
+		FormatName: name,
+		URL:        baseURL + dir + "/",
+		OutputDir:  filepath.Join(outputRoot, dir),
+		AssetPath:  "../",
+		Games:      games,
+	}
+}
+
+// isRoot reports whether the site is the one at the root of the output directory, which owns robots.txt
+func (site Site) isRoot() bool {
+	return site.AssetPath == ""
+}
+
+// checkOutputDir creates the output directory and makes sure files can be written to it, so a bad
+// -out fails before any work is done
+func checkOutputDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return err
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// dayURL is the public address of a day's page
+func (site Site) dayURL(date string) string {
+	return site.URL + "day/" + date + ".html"
+}
+
+// gamesNote says which printings count in a single-game history, e.g. "only cards with a printing in
+// paper", and is empty when every printing counts
+func (site Site) gamesNote() string {
+	if site.Games == "" {
+		return ""
+	}
+	availability, found := gameAvailability[site.Games]
+	if !found {
+		availability = "in " + site.Games
+	}
+	return "only cards with a printing " + availability
+}
+
+// funcMap exposes the site to templates: {{format}}, {{games}}, {{siteURL}}, {{dayURL .Date}} and
+// {{asset "style.css"}}
+func (site Site) funcMap() map[string]interface{} {
+	return map[string]interface{}{
+		"format":  func() string { return site.FormatName },
+		"games":   site.gamesNote,
+		"siteURL": func() string { return site.URL },
+		"dayURL":  site.dayURL,
+		"asset":   func(name string) string { return site.AssetPath + name },
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestNewSiteGames(t *testing.T) {
+	tests := []struct {
+		games      string
+		formatName string
+		note       string
+	}{
+		{"", "Brawl", ""},
+		{"paper", "Paper Brawl", "only cards with a printing in paper"},
+		{"arena", "Arena Brawl", "only cards with a printing on Arena"},
+		{"mtgo", "MTGO Brawl", "only cards with a printing on MTGO"},
+	}
+	for _, test := range tests {
+		site := newSite("brawl", test.games, "docs", "https://example.com/")
+		if site.FormatName != test.formatName {
+			t.Errorf("newSite(%q).FormatName = %q, want %q", test.games, site.FormatName, test.formatName)
+		}
+		if note := site.gamesNote(); note != test.note {
+			t.Errorf("newSite(%q).gamesNote() = %q, want %q", test.games, note, test.note)
+		}
+	}
+}
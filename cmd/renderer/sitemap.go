@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// sitemapNamespace is the schema of sitemap.xml, see https://www.sitemaps.org/protocol.html
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// SitemapURLSet is the root of sitemap.xml
+type SitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []SitemapURL `xml:"url"`
+}
+
+// SitemapURL is one page with the date its content last changed
+type SitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// buildSitemap lists the pages of the site. Every lastmod is a date from the history rather than the
+// time of the build, so an unchanged history renders a byte-identical sitemap.
+func buildSitemap(displayData DisplayData, checkpoints []Checkpoint, site Site) SitemapURLSet {
+	newest := ""
+	for _, day := range displayData.Days {
+		if day.Date > newest {
+			newest = day.Date
+		}
+	}
+
+	// Pages that show the newest days change whenever a day is added
+	urls := []SitemapURL{
+		{Loc: site.URL, LastMod: newest},
+		{Loc: site.URL + "search.html", LastMod: newest},
+		{Loc: site.URL + "gallery.html", LastMod: newest},
+		{Loc: site.URL + "stats.html", LastMod: newest},
+	}
+	for _, checkpoint := range checkpoints {
+		urls = append(urls, SitemapURL{Loc: site.URL + "since/" + checkpoint.Slug + ".html", LastMod: newest})
+	}
+
+	// A day's page only changes with the day itself
+	for _, day := range newestFirst(displayData.Days) {
+		if day.FirstRun || day.HasChanges() {
+			urls = append(urls, SitemapURL{Loc: site.dayURL(day.Date), LastMod: day.Date})
+		}
+	}
+	return SitemapURLSet{Xmlns: sitemapNamespace, URLs: urls}
+}
+
+// generateSitemap writes sitemap.xml and, for the site at the root of the output directory, a
+// robots.txt pointing at it; crawlers only read robots.txt at the root
+func generateSitemap(displayData DisplayData, checkpoints []Checkpoint, outputDir string, options RenderOptions) error {
+	sitemap := buildSitemap(displayData, checkpoints, options.Site)
+	if err := writeXMLFile(filepath.Join(outputDir, "sitemap.xml"), sitemap); err != nil {
+		return err
+	}
+	if !options.Site.isRoot() {
+		return nil
+	}
+
+	robots := "User-agent: *\nAllow: /\n\nSitemap: " + options.Site.URL + "sitemap.xml\n"
+	return os.WriteFile(filepath.Join(outputDir, "robots.txt"), []byte(robots), 0644)
+}
+
+// writeXMLFile writes v as indented XML with the standard header
+func writeXMLFile(filename string, v interface{}) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := io.WriteString(file, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(file)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	_, err = io.WriteString(file, "\n")
+	return err
+}
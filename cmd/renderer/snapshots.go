@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// defaultResultsDir holds the fetcher's daily snapshots of added cards
+const defaultResultsDir = "data/results"
+
+// DaySnapshot is a result file written by the fetcher with the full cards added on a day
+type DaySnapshot struct {
+	Date   string `json:"date"`
+	Format string `json:"format"`
+	Cards  []Card `json:"cards"`
+}
+
+// addSnapshotCards fills in printings missing from the card cache from the daily snapshots of a
+// history, see trackName, so days whose cards left the bulk data still show them. Returns the number added.
+func addSnapshotCards(cardLookup map[string]Card, resultsDir string, name string) (int, error) {
+	dir := filepath.Join(resultsDir, name)
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+
+	added := 0
+	for _, filename := range files {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return added, err
+		}
+		var snapshot DaySnapshot
+		if err := json.Unmarshal(data, &snapshot); err != nil {
+			return added, fmt.Errorf("%s: %w", filename, err)
+		}
+		for _, card := range snapshot.Cards {
+			if _, exists := cardLookup[card.ID]; !exists {
+				cardLookup[card.ID] = card
+				added++
+			}
+		}
+	}
+	return added, nil
+}
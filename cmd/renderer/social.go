@@ -0,0 +1,87 @@
+package main
+
+// SocialMeta is what link previews on Discord, Mastodon and the like show of a page, written as
+// OpenGraph and Twitter card tags by social.html.tmpl
+type SocialMeta struct {
+	Title       string
+	Description string
+	URL         string
+	Image       string // Empty when no card has an image yet
+}
+
+// TwitterCard is the card type: a large image when there is one, a plain summary otherwise
+func (meta SocialMeta) TwitterCard() string {
+	if meta.Image == "" {
+		return "summary"
+	}
+	return "summary_large_image"
+}
+
+// newIndexSocialMeta describes the index by its newest day; days must be newest first. The image is
+// the newest day's first card with one, else the newest such card of an earlier day, e.g. when the
+// newest day is the first run, which shows no cards.
+func newIndexSocialMeta(days []DisplayDay, options RenderOptions) SocialMeta {
+	site := options.Site
+	meta := SocialMeta{
+		Title:       site.FormatName + " Chronicle",
+		Description: "Daily tracking of new Magic: The Gathering cards legal in " + site.FormatName + " format",
+		URL:         site.URL,
+	}
+	if len(days) == 0 {
+		return meta
+	}
+
+	meta.Description = dayDescription(days[0], site.FormatName)
+	for _, day := range days {
+		if image := firstCardImage(day.Cards); image != "" {
+			meta.Image = options.ImageProxy.Rewrite(image, htmlImageWidth)
+			break
+		}
+	}
+	return meta
+}
+
+// newDaySocialMeta describes a day's own page. The image is the first card with one, looking at the
+// new cards before the other sections.
+func newDaySocialMeta(day DisplayDay, options RenderOptions) SocialMeta {
+	site := options.Site
+	meta := SocialMeta{
+		Title:       day.FeedTitle() + " - " + site.FormatName + " Chronicle",
+		Description: dayDescription(day, site.FormatName),
+		URL:         site.dayURL(day.Date),
+	}
+	for _, cards := range [][]DisplayCard{day.Cards, day.NowOnArena, day.Banned, day.Unbanned, day.Removed, day.Previews} {
+		if image := firstCardImage(cards); image != "" {
+			meta.Image = options.ImageProxy.Rewrite(image, htmlImageWidth)
+			break
+		}
+	}
+	return meta
+}
+
+// dayDescription sums up a day for link previews, e.g. "17 new Brawl cards on 2025-08-14"
+func dayDescription(day DisplayDay, formatName string) string {
+	if day.FirstRun {
+		return "Tracking " + addThousandsSeparator(day.TotalCards) + " " + formatName + "-legal cards since " + day.Date
+	}
+	switch count := len(day.Cards); {
+	case count == 1:
+		return "1 new " + formatName + " card on " + day.Date
+	case count > 1:
+		return addThousandsSeparator(count) + " new " + formatName + " cards on " + day.Date
+	}
+	if summary := day.Summary(); summary != "" {
+		return summary + " on " + day.Date
+	}
+	return "No new " + formatName + " cards on " + day.Date
+}
+
+// firstCardImage returns the image of the first card that has one
+func firstCardImage(cards []DisplayCard) string {
+	for _, card := range cards {
+		if card.ImageURL != "" {
+			return card.ImageURL
+		}
+	}
+	return ""
+}
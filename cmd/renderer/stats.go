@@ -0,0 +1,403 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Size of the inline SVG charts in user units; they scale to the page width
+const (
+	chartWidth  = 900
+	chartHeight = 240
+	chartMargin = 40
+)
+
+// statColorFills are the bar colors of the color buckets of colorOrder
+var statColorFills = map[string]string{
+	"W":          "#f0e6bc",
+	"U":          "#4a90d9",
+	"B":          "#555555",
+	"R":          "#d9534f",
+	"G":          "#3c9a5f",
+	"multicolor": "#d4a017",
+	"colorless":  "#a0a0a0",
+}
+
+// statRarityFills are the bar colors of the rarities of rarityOrder
+var statRarityFills = map[string]string{
+	"mythic":   "#d35400",
+	"rare":     "#b7950b",
+	"uncommon": "#7f8c8d",
+	"common":   "#2c3e50",
+	"special":  "#8e44ad",
+	"bonus":    "#8e44ad",
+}
+
+// MonthCount is the number of cards added in one month, e.g. "2025-08"
+type MonthCount struct {
+	Month string
+	Count int
+}
+
+// TotalPoint is the number of cards legal at the end of a day
+type TotalPoint struct {
+	Date  string
+	Total int
+}
+
+// StatBucket is one bar of a distribution
+type StatBucket struct {
+	Label string
+	Count int
+	Fill  string
+}
+
+// StatsPage is the template data of docs/stats.html
+type StatsPage struct {
+	Since      string // Date of the first day in history
+	Added      int    // Cards added after the first run
+	Unresolved int    // Added cards missing from the card cache, counted as unknown in the distributions
+	Months     []MonthCount
+	Totals     []TotalPoint
+	Colors     []StatBucket
+	Rarities   []StatBucket
+	Daily      map[string]int // Cards added by date, after the first run
+
+	Calendars   []template.HTML // A heatmap of the daily additions per year, newest first
+	MonthChart  template.HTML
+	TotalChart  template.HTML
+	ColorChart  template.HTML
+	RarityChart template.HTML
+}
+
+// buildStats counts the additions of every day after the first run by month, color and rarity, and
+// the legal cards after every day, the first run included
+func buildStats(history HistoryData, cardLookup map[string]Card, cardsByOracle map[string][]Card) StatsPage {
+	days := make([]DayResult, len(history.Days))
+	copy(days, history.Days)
+	sort.SliceStable(days, func(i, j int) bool {
+		return days[i].Date < days[j].Date
+	})
+
+	page := StatsPage{Daily: make(map[string]int)}
+	if len(days) == 0 {
+		return page
+	}
+	page.Since = days[0].Date
+
+	months := make(map[string]int)
+	colors := make(map[string]int)
+	rarities := make(map[string]int)
+	total := 0
+	for _, day := range days {
+		// Histories before total_cards was recorded fall back to a running count
+		if day.TotalCards > 0 {
+			total = day.TotalCards
+		} else {
+			total += len(day.AddedOracles) - len(day.RemovedOracles)
+		}
+		page.Totals = append(page.Totals, TotalPoint{Date: day.Date, Total: total})
+		if day.FirstRun {
+			continue
+		}
+
+		months[day.Date[:7]] += len(day.AddedOracles)
+		page.Daily[day.Date] += len(day.AddedOracles)
+		for _, oracleID := range day.AddedOracles {
+			page.Added++
+			card, found := selectDayCard(day, oracleID, cardLookup, cardsByOracle[oracleID])
+			if !found {
+				page.Unresolved++
+				colors["unknown"]++
+				rarities["unknown"]++
+				continue
+			}
+			colors[colorOrder[getColorOrder(cardColors(card))]]++
+			rarities[card.Rarity]++
+		}
+	}
+
+	// Every month from the first addition to the last, so gaps show as empty bars
+	if len(months) > 0 {
+		var keys []string
+		for month := range months {
+			keys = append(keys, month)
+		}
+		sort.Strings(keys)
+		for month := keys[0]; month <= keys[len(keys)-1]; month = nextMonth(month) {
+			page.Months = append(page.Months, MonthCount{Month: month, Count: months[month]})
+		}
+	}
+
+	for _, color := range colorOrder {
+		label := colorLabels[color]
+		if label == "" {
+			label = color
+		}
+		page.Colors = append(page.Colors, StatBucket{Label: label, Count: colors[color], Fill: statColorFills[color]})
+	}
+	page.Rarities = rarityBuckets(rarities)
+	if colors["unknown"] > 0 {
+		page.Colors = append(page.Colors, StatBucket{Label: "Unknown", Count: colors["unknown"], Fill: "#cccccc"})
+	}
+	return page
+}
+
+// rarityBuckets lists the rarities in rarityOrder, then any other rarity by name, then unknown
+func rarityBuckets(counts map[string]int) []StatBucket {
+	var buckets []StatBucket
+	listed := make(map[string]bool)
+	for _, rarity := range rarityOrder {
+		listed[rarity] = true
+		if counts[rarity] > 0 {
+			buckets = append(buckets, StatBucket{Label: rarity, Count: counts[rarity], Fill: statRarityFills[rarity]})
+		}
+	}
+	var others []string
+	for rarity := range counts {
+		if !listed[rarity] && rarity != "unknown" {
+			others = append(others, rarity)
+		}
+	}
+	sort.Strings(others)
+	for _, rarity := range others {
+		buckets = append(buckets, StatBucket{Label: rarity, Count: counts[rarity], Fill: "#8e44ad"})
+	}
+	if counts["unknown"] > 0 {
+		buckets = append(buckets, StatBucket{Label: "unknown", Count: counts["unknown"], Fill: "#cccccc"})
+	}
+	return buckets
+}
+
+// nextMonth returns the month after one such as "2025-12"
+func nextMonth(month string) string {
+	var year, number int
+	fmt.Sscanf(month, "%d-%d", &year, &number)
+	if number == 12 {
+		return fmt.Sprintf("%04d-01", year+1)
+	}
+	return fmt.Sprintf("%04d-%02d", year, number+1)
+}
+
+// monthBarChart draws the additions per month as vertical bars
+func monthBarChart(months []MonthCount) template.HTML {
+	maximum := 0
+	for _, month := range months {
+		maximum = max(maximum, month.Count)
+	}
+	if maximum == 0 {
+		return ""
+	}
+
+	var svg strings.Builder
+	openChart(&svg, "New cards per month")
+	plotWidth := float64(chartWidth - 2*chartMargin)
+	plotHeight := float64(chartHeight - 2*chartMargin)
+	slot := plotWidth / float64(len(months))
+	// Label every month while they fit, else about ten of them
+	labelEvery := max(1, len(months)/10)
+	for i, month := range months {
+		height := plotHeight * float64(month.Count) / float64(maximum)
+		x := float64(chartMargin) + float64(i)*slot
+		y := float64(chartMargin) + plotHeight - height
+		fmt.Fprintf(&svg, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="#667eea"><title>%s: %s</title></rect>`,
+			x+slot*0.1, y, slot*0.8, height, month.Month, addThousandsSeparator(month.Count))
+		if month.Count > 0 && slot >= 30 {
+			fmt.Fprintf(&svg, `<text x="%.1f" y="%.1f" text-anchor="middle" font-size="11">%s</text>`, x+slot/2, y-4, addThousandsSeparator(month.Count))
+		}
+		if i%labelEvery == 0 {
+			fmt.Fprintf(&svg, `<text x="%.1f" y="%d" text-anchor="middle" font-size="11">%s</text>`, x+slot/2, chartHeight-chartMargin+16, month.Month)
+		}
+	}
+	closeChart(&svg)
+	return template.HTML(svg.String())
+}
+
+// totalLineChart draws the legal cards after every day as a line between the lowest and highest total
+func totalLineChart(totals []TotalPoint) template.HTML {
+	if len(totals) == 0 {
+		return ""
+	}
+	lowest, highest := totals[0].Total, totals[0].Total
+	for _, point := range totals {
+		lowest = min(lowest, point.Total)
+		highest = max(highest, point.Total)
+	}
+	span := float64(max(highest-lowest, 1))
+
+	var svg strings.Builder
+	openChart(&svg, "Legal cards over time")
+	plotWidth := float64(chartWidth - 2*chartMargin)
+	plotHeight := float64(chartHeight - 2*chartMargin)
+	var points []string
+	for i, point := range totals {
+		x := float64(chartMargin)
+		if len(totals) > 1 {
+			x += plotWidth * float64(i) / float64(len(totals)-1)
+		}
+		y := float64(chartMargin) + plotHeight - plotHeight*float64(point.Total-lowest)/span
+		points = append(points, fmt.Sprintf("%.1f,%.1f", x, y))
+	}
+	fmt.Fprintf(&svg, `<polyline points="%s" fill="none" stroke="#764ba2" stroke-width="2"/>`, strings.Join(points, " "))
+	fmt.Fprintf(&svg, `<text x="%d" y="%d" font-size="11">%s</text>`, chartMargin, chartMargin-8, addThousandsSeparator(highest))
+	fmt.Fprintf(&svg, `<text x="%d" y="%d" font-size="11">%s</text>`, chartMargin, chartHeight-chartMargin+16, addThousandsSeparator(lowest))
+	fmt.Fprintf(&svg, `<text x="%d" y="%d" text-anchor="middle" font-size="11">%s</text>`, chartWidth/2, chartHeight-chartMargin+16,
+		totals[0].Date+" – "+totals[len(totals)-1].Date)
+	closeChart(&svg)
+	return template.HTML(svg.String())
+}
+
+// distributionChart draws buckets as horizontal bars labeled with their counts
+func distributionChart(title string, buckets []StatBucket) template.HTML {
+	maximum := 0
+	for _, bucket := range buckets {
+		maximum = max(maximum, bucket.Count)
+	}
+	if maximum == 0 {
+		return ""
+	}
+
+	const rowHeight, labelWidth = 28, 110
+	height := 2*chartMargin + rowHeight*len(buckets)
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg class="chart" viewBox="0 0 %d %d" role="img" aria-label="%s">`, chartWidth, height, template.HTMLEscapeString(title))
+	plotWidth := float64(chartWidth - 2*chartMargin - labelWidth - 60)
+	for i, bucket := range buckets {
+		y := chartMargin + i*rowHeight
+		width := plotWidth * float64(bucket.Count) / float64(maximum)
+		fmt.Fprintf(&svg, `<text x="%d" y="%d" font-size="13">%s</text>`, chartMargin, y+rowHeight/2+4, template.HTMLEscapeString(bucket.Label))
+		fmt.Fprintf(&svg, `<rect x="%d" y="%d" width="%.1f" height="%d" fill="%s" stroke="#888" stroke-width="0.5"/>`,
+			chartMargin+labelWidth, y+4, width, rowHeight-8, bucket.Fill)
+		fmt.Fprintf(&svg, `<text x="%.1f" y="%d" font-size="13">%s</text>`, float64(chartMargin+labelWidth)+width+6, y+rowHeight/2+4,
+			addThousandsSeparator(bucket.Count))
+	}
+	closeChart(&svg)
+	return template.HTML(svg.String())
+}
+
+// Size of the cells of the calendar heatmaps, and the room left of and above them for labels
+const (
+	calendarCell   = 14
+	calendarGap    = 2
+	calendarLeft   = 36
+	calendarTop    = 20
+	calendarLevels = 4 // Shades of days with additions, see the .heat-N classes of style.css
+)
+
+// calendarPosition places a day of a year's calendar: a column per week and a row per weekday,
+// Monday first. Week 0 is the one of January 1st, so a year has 53 or 54 columns.
+func calendarPosition(date time.Time) (column int, row int) {
+	january := time.Date(date.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	offset := (int(january.Weekday()) + 6) % 7
+	return (date.YearDay() - 1 + offset) / 7, (int(date.Weekday()) + 6) % 7
+}
+
+// calendarLevel shades a day's additions relative to the busiest day: 0 for none, else 1 to calendarLevels
+func calendarLevel(count int, maximum int) int {
+	if count <= 0 || maximum <= 0 {
+		return 0
+	}
+	return min(calendarLevels, (count*calendarLevels+maximum-1)/maximum)
+}
+
+// calendarHeatmaps draws a GitHub-style calendar of the daily additions for every year with history,
+// newest first. Each day with additions links to its page; shades are relative to the busiest day of
+// all years, so years compare.
+func calendarHeatmaps(daily map[string]int) []template.HTML {
+	maximum := 0
+	years := make(map[int]bool)
+	for date, count := range daily {
+		maximum = max(maximum, count)
+		if day, err := time.Parse("2006-01-02", date); err == nil {
+			years[day.Year()] = true
+		}
+	}
+	if maximum == 0 {
+		return nil
+	}
+
+	var sorted []int
+	for year := range years {
+		sorted = append(sorted, year)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+	var charts []template.HTML
+	for _, year := range sorted {
+		charts = append(charts, calendarHeatmap(year, daily, maximum))
+	}
+	return charts
+}
+
+// calendarHeatmap draws one year, every day of it a cell, empty when nothing was added
+func calendarHeatmap(year int, daily map[string]int, maximum int) template.HTML {
+	last := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+	lastColumn, _ := calendarPosition(last)
+	step := calendarCell + calendarGap
+	width := calendarLeft + (lastColumn+1)*step
+	height := calendarTop + 7*step
+
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg class="chart calendar" viewBox="0 0 %d %d" role="img" aria-label="New cards per day in %d">`, width, height, year)
+	fmt.Fprintf(&svg, `<text x="0" y="%d" font-size="10" font-weight="bold">%d</text>`, calendarTop-6, year)
+	for row, label := range []string{"Mon", "", "Wed", "", "Fri", "", ""} {
+		if label != "" {
+			fmt.Fprintf(&svg, `<text x="0" y="%d" font-size="10">%s</text>`, calendarTop+row*step+calendarCell-3, label)
+		}
+	}
+	for day := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC); day.Year() == year; day = day.AddDate(0, 0, 1) {
+		column, row := calendarPosition(day)
+		x, y := calendarLeft+column*step, calendarTop+row*step
+		if day.Day() == 1 {
+			// Labeled at the first full week of the month, so labels don't overlap the previous month's
+			labelColumn := column
+			if row > 0 {
+				labelColumn++
+			}
+			fmt.Fprintf(&svg, `<text x="%d" y="%d" font-size="10">%s</text>`, calendarLeft+labelColumn*step, calendarTop-6, day.Format("Jan"))
+		}
+
+		date := day.Format("2006-01-02")
+		count := daily[date]
+		cell := fmt.Sprintf(`<rect class="heat-%d" x="%d" y="%d" width="%d" height="%d" rx="2"><title>%s: %s new cards</title></rect>`,
+			calendarLevel(count, maximum), x, y, calendarCell, calendarCell, date, addThousandsSeparator(count))
+		if count > 0 {
+			cell = `<a href="day/` + date + `.html">` + cell + `</a>`
+		}
+		svg.WriteString(cell)
+	}
+	closeChart(&svg)
+	return template.HTML(svg.String())
+}
+
+func openChart(svg *strings.Builder, title string) {
+	fmt.Fprintf(svg, `<svg class="chart" viewBox="0 0 %d %d" role="img" aria-label="%s">`, chartWidth, chartHeight, template.HTMLEscapeString(title))
+	fmt.Fprintf(svg, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#888"/>`,
+		chartMargin, chartHeight-chartMargin, chartWidth-chartMargin, chartHeight-chartMargin)
+}
+
+func closeChart(svg *strings.Builder) {
+	svg.WriteString(`</svg>`)
+}
+
+// generateStats writes docs/stats.html with its charts drawn as inline SVG, so the page needs no script
+func generateStats(history HistoryData, cardLookup map[string]Card, cardsByOracle map[string][]Card, outputDir string, options RenderOptions) error {
+	page := buildStats(history, cardLookup, cardsByOracle)
+	page.Calendars = calendarHeatmaps(page.Daily)
+	page.MonthChart = monthBarChart(page.Months)
+	page.TotalChart = totalLineChart(page.Totals)
+	page.ColorChart = distributionChart("New cards by color", page.Colors)
+	page.RarityChart = distributionChart("New cards by rarity", page.Rarities)
+
+	t := template.New("stats").Funcs(options.Site.funcMap()).Funcs(template.FuncMap{
+		"thousands": addThousandsSeparator,
+	})
+	if err := options.Templates.parseHTML(t, "stats.html.tmpl"); err != nil {
+		return err
+	}
+
+	return writeTemplateFile(filepath.Join(outputDir, "stats.html"), t, page, options.Minify)
+}
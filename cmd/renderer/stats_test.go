@@ -0,0 +1,145 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalendarPosition(t *testing.T) {
+	tests := []struct {
+		date        string
+		column, row int
+	}{
+		// 2024 starts on a Monday: a full first week
+		{"2024-01-01", 0, 0},
+		{"2024-01-07", 0, 6},
+		{"2024-01-08", 1, 0},
+		{"2024-02-29", 8, 3}, // Leap day, a Thursday
+		{"2024-03-01", 8, 4},
+		{"2024-12-31", 52, 1},
+		// 2023 starts on a Sunday: a first week of one day
+		{"2023-01-01", 0, 6},
+		{"2023-01-02", 1, 0},
+		{"2023-12-31", 52, 6},
+		// 2021 ends on a Friday: a partial last week
+		{"2021-12-31", 52, 4},
+		// A leap year starting on a Sunday spills into a 54th column
+		{"2012-01-01", 0, 6},
+		{"2012-12-30", 52, 6},
+		{"2012-12-31", 53, 0},
+	}
+	for _, test := range tests {
+		date, err := time.Parse("2006-01-02", test.date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if column, row := calendarPosition(date); column != test.column || row != test.row {
+			t.Errorf("calendarPosition(%s) = %d, %d; want %d, %d", test.date, column, row, test.column, test.row)
+		}
+	}
+}
+
+func TestCalendarLevel(t *testing.T) {
+	tests := []struct {
+		count, maximum, want int
+	}{
+		{0, 0, 0},
+		{0, 10, 0},
+		{-1, 10, 0},
+		{1, 100, 1},
+		{25, 100, 1},
+		{26, 100, 2},
+		{50, 100, 2},
+		{75, 100, 3},
+		{76, 100, 4},
+		{100, 100, 4},
+		{1, 1, 4},
+		{5, 0, 0},
+	}
+	for _, test := range tests {
+		if got := calendarLevel(test.count, test.maximum); got != test.want {
+			t.Errorf("calendarLevel(%d, %d) = %d, want %d", test.count, test.maximum, got, test.want)
+		}
+	}
+}
+
+var calendarCellPattern = regexp.MustCompile(`<rect class="heat-(\d)" x="(\d+)" y="(\d+)"[^>]*><title>([0-9-]+): `)
+
+func TestCalendarHeatmapLayout(t *testing.T) {
+	step := calendarCell + calendarGap
+	tests := []struct {
+		year    int
+		days    int
+		columns int
+	}{
+		{2023, 365, 53},
+		{2024, 366, 53},
+		{2021, 365, 53},
+		{2012, 366, 54},
+		{1900, 365, 53}, // Not a leap year, divisible by 100
+		{2000, 366, 53}, // A leap year, divisible by 400
+	}
+	for _, test := range tests {
+		daily := map[string]int{strconv.Itoa(test.year) + "-06-15": 3}
+		svg := string(calendarHeatmap(test.year, daily, 3))
+
+		if want := `viewBox="0 0 ` + strconv.Itoa(calendarLeft+test.columns*step) + ` `; !strings.Contains(svg, want) {
+			t.Errorf("%d: want %d columns, got %s", test.year, test.columns, svg[:strings.Index(svg, ">")+1])
+		}
+		cells := calendarCellPattern.FindAllStringSubmatch(svg, -1)
+		if len(cells) != test.days {
+			t.Errorf("%d: %d cells, want one for each of %d days", test.year, len(cells), test.days)
+		}
+		seen := make(map[string]bool)
+		for _, cell := range cells {
+			position := cell[2] + "," + cell[3]
+			if seen[position] {
+				t.Errorf("%d: two cells at %s", test.year, position)
+			}
+			seen[position] = true
+			if !strings.HasPrefix(cell[4], strconv.Itoa(test.year)+"-") {
+				t.Errorf("%d: cell of %s", test.year, cell[4])
+			}
+		}
+		// Only the day with additions is shaded and linked
+		if strings.Count(svg, "<a ") != 1 || !strings.Contains(svg, `<a href="day/`+strconv.Itoa(test.year)+`-06-15.html"><rect class="heat-4"`) {
+			t.Errorf("%d: want the one day with additions linked and shaded", test.year)
+		}
+		if strings.Count(svg, `class="heat-0"`) != test.days-1 {
+			t.Errorf("%d: want every other day an empty cell", test.year)
+		}
+		// Months without data are drawn all the same
+		if strings.Count(svg, `font-size="10">`) != 12+3 {
+			t.Errorf("%d: want 12 month and 3 weekday labels", test.year)
+		}
+	}
+}
+
+func TestCalendarHeatmaps(t *testing.T) {
+	if charts := calendarHeatmaps(map[string]int{"2024-01-01": 0}); charts != nil {
+		t.Errorf("heatmaps drawn without additions: %d", len(charts))
+	}
+
+	daily := map[string]int{"2022-03-01": 4, "2024-12-31": 2, "2022-03-02": 1, "not a date": 1}
+	charts := calendarHeatmaps(daily)
+	if len(charts) != 2 {
+		t.Fatalf("%d heatmaps, want 2022 and 2024", len(charts))
+	}
+	if !strings.Contains(string(charts[0]), "in 2024") || !strings.Contains(string(charts[1]), "in 2022") {
+		t.Error("heatmaps are not newest first")
+	}
+	// The busiest day of all years sets the shades
+	if !strings.Contains(string(charts[0]), `<rect class="heat-2" x="`) {
+		t.Error("2024's day is not shaded relative to 2022's busiest day")
+	}
+	// Drawn the same on every render
+	for i := 0; i < 5; i++ {
+		again := calendarHeatmaps(daily)
+		if again[0] != charts[0] || again[1] != charts[1] {
+			t.Fatal("heatmaps differ between renders")
+		}
+	}
+}
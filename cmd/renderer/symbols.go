@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"html/template"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// defaultSymbologyFile is the list of card symbols cached by the fetcher
+const defaultSymbologyFile = "data/symbology.json"
+
+// symbolImageBase is where Scryfall serves symbol images, named after the symbol without braces and
+// slashes, e.g. {W/U} is WU.svg
+const symbolImageBase = "https://svgs.scryfall.io/card-symbols/"
+
+// manaSymbolPattern matches one symbol of a mana cost such as "{2}{W/U}{G/P}"
+var manaSymbolPattern = regexp.MustCompile(`\{[^{}]*\}`)
+
+// knownSymbolPattern is the grammar of symbols drawn without the cached symbology: generic and
+// colored mana, X/Y/Z, snow, hybrid ({W/U}, {2/W}, {C/W}) and Phyrexian ({W/P}, {W/U/P})
+var knownSymbolPattern = regexp.MustCompile(`^(\d+|[WUBRGCSXYZ]|[WUBRG]/[WUBRG]|[2C]/[WUBRG]|[WUBRGC]/P|[WUBRG]/[WUBRG]/P)$`)
+
+// ManaSymbol is a symbol of the cached symbology
+type ManaSymbol struct {
+	Symbol  string `json:"symbol"`
+	SVGURI  string `json:"svg_uri"`
+	English string `json:"english"` // e.g. "one white mana"
+}
+
+// loadSymbology reads the cached symbols by their text, e.g. "{W}"; a missing cache is not an error
+func loadSymbology(filename string) (map[string]ManaSymbol, error) {
+	symbols := make(map[string]ManaSymbol)
+
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return symbols, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var cached struct {
+		Symbols []ManaSymbol `json:"symbols"`
+	}
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, err
+	}
+
+	for _, symbol := range cached.Symbols {
+		if symbol.SVGURI != "" {
+			symbols[symbol.Symbol] = symbol
+		}
+	}
+	return symbols, nil
+}
+
+// symbolImage returns the image of a symbol such as "{W/P}": the cached one, else one named by the
+// grammar of knownSymbolPattern. Unknown symbols have none.
+func symbolImage(symbol string, symbols map[string]ManaSymbol) (ManaSymbol, bool) {
+	if cached, found := symbols[symbol]; found {
+		return cached, true
+	}
+	inner := strings.Trim(symbol, "{}")
+	if !knownSymbolPattern.MatchString(inner) {
+		return ManaSymbol{}, false
+	}
+	return ManaSymbol{Symbol: symbol, SVGURI: symbolImageBase + strings.ReplaceAll(inner, "/", "") + ".svg"}, true
+}
+
+// renderManaCost draws a mana cost with symbol images. Symbols without an image and anything between
+// symbols, e.g. the " // " of split cards, stay text, so a new kind of symbol still renders.
+func renderManaCost(cost string, symbols map[string]ManaSymbol) template.HTML {
+	var html strings.Builder
+	last := 0
+	for _, match := range manaSymbolPattern.FindAllStringIndex(cost, -1) {
+		html.WriteString(template.HTMLEscapeString(cost[last:match[0]]))
+		symbol := cost[match[0]:match[1]]
+		last = match[1]
+
+		image, found := symbolImage(symbol, symbols)
+		if !found {
+			html.WriteString(template.HTMLEscapeString(symbol))
+			continue
+		}
+		title := image.English
+		if title == "" {
+			title = symbol
+		}
+		html.WriteString(`<img class="mana-symbol" src="` + template.HTMLEscapeString(image.SVGURI) +
+			`" alt="` + template.HTMLEscapeString(symbol) + `" title="` + template.HTMLEscapeString(title) + `">`)
+	}
+	html.WriteString(template.HTMLEscapeString(cost[last:]))
+	return template.HTML(html.String())
+}
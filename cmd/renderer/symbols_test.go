@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSymbolImage(t *testing.T) {
+	tests := []struct {
+		symbol string
+		image  string // Empty when the symbol has no image
+	}{
+		{"{W}", "W.svg"},
+		{"{2}", "2.svg"},
+		{"{10}", "10.svg"},
+		{"{C}", "C.svg"},
+		{"{X}", "X.svg"},
+		{"{Y}", "Y.svg"},
+		{"{S}", "S.svg"},
+		{"{W/U}", "WU.svg"},
+		{"{2/W}", "2W.svg"},
+		{"{C/G}", "CG.svg"},
+		{"{W/P}", "WP.svg"},
+		{"{C/P}", "CP.svg"},
+		{"{G/U/P}", "GUP.svg"},
+		{"{H}", ""},
+		{"{T}", ""},
+		{"{W/W/W}", ""},
+		{"{P}", ""},
+		{"{}", ""},
+		{"{w}", ""},
+	}
+	for _, test := range tests {
+		image, found := symbolImage(test.symbol, nil)
+		if test.image == "" {
+			if found {
+				t.Errorf("symbolImage(%s) = %s, want none", test.symbol, image.SVGURI)
+			}
+			continue
+		}
+		if want := symbolImageBase + test.image; !found || image.SVGURI != want {
+			t.Errorf("symbolImage(%s) = %s, %v; want %s", test.symbol, image.SVGURI, found, want)
+		}
+	}
+}
+
+func TestSymbolImagePrefersSymbology(t *testing.T) {
+	symbols := map[string]ManaSymbol{
+		"{W}":  {Symbol: "{W}", SVGURI: "https://example.com/W.svg", English: "one white mana"},
+		"{TK}": {Symbol: "{TK}", SVGURI: "https://example.com/TK.svg", English: "one ticket"},
+	}
+	if image, _ := symbolImage("{W}", symbols); image.SVGURI != "https://example.com/W.svg" {
+		t.Errorf("symbolImage({W}) = %s, want the cached image", image.SVGURI)
+	}
+	// Symbols outside the grammar are drawn once the symbology knows them
+	if image, found := symbolImage("{TK}", symbols); !found || image.English != "one ticket" {
+		t.Errorf("symbolImage({TK}) = %+v, %v; want the cached symbol", image, found)
+	}
+}
+
+func TestRenderManaCost(t *testing.T) {
+	symbols := map[string]ManaSymbol{
+		"{W}": {Symbol: "{W}", SVGURI: "https://svgs.scryfall.io/card-symbols/W.svg", English: "one white mana"},
+	}
+	tests := []struct {
+		cost string
+		want string
+	}{
+		{"", ""},
+		{"{W}", `<img class="mana-symbol" src="https://svgs.scryfall.io/card-symbols/W.svg" alt="{W}" title="one white mana">`},
+		{"{X}{G/P}", `<img class="mana-symbol" src="https://svgs.scryfall.io/card-symbols/X.svg" alt="{X}" title="{X}">` +
+			`<img class="mana-symbol" src="https://svgs.scryfall.io/card-symbols/GP.svg" alt="{G/P}" title="{G/P}">`},
+		{"{1}{S}", `<img class="mana-symbol" src="https://svgs.scryfall.io/card-symbols/1.svg" alt="{1}" title="{1}">` +
+			`<img class="mana-symbol" src="https://svgs.scryfall.io/card-symbols/S.svg" alt="{S}" title="{S}">`},
+		// Split cards keep the separator between their halves
+		{"{W} // {2/U}", `<img class="mana-symbol" src="https://svgs.scryfall.io/card-symbols/W.svg" alt="{W}" title="one white mana"> // ` +
+			`<img class="mana-symbol" src="https://svgs.scryfall.io/card-symbols/2U.svg" alt="{2/U}" title="{2/U}">`},
+		// Unknown symbols and stray text stay text, escaped
+		{"{Q}{W}", `{Q}<img class="mana-symbol" src="https://svgs.scryfall.io/card-symbols/W.svg" alt="{W}" title="one white mana">`},
+		{"{<b>}", `{&lt;b&gt;}`},
+		{"{W", `{W`},
+	}
+	for _, test := range tests {
+		if got := string(renderManaCost(test.cost, symbols)); got != test.want {
+			t.Errorf("renderManaCost(%q) =\n%s\nwant\n%s", test.cost, got, test.want)
+		}
+	}
+}
+
+func TestLoadSymbology(t *testing.T) {
+	dir := t.TempDir()
+	symbols, err := loadSymbology(filepath.Join(dir, "missing.json"))
+	if err != nil || len(symbols) != 0 {
+		t.Fatalf("loadSymbology(missing) = %v, %v; want no symbols", symbols, err)
+	}
+
+	filename := filepath.Join(dir, "symbology.json")
+	content := `{"symbols":[{"symbol":"{W}","svg_uri":"https://example.com/W.svg","english":"one white mana"},{"symbol":"{½}"}]}`
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	symbols, err = loadSymbology(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(symbols) != 1 || symbols["{W}"].English != "one white mana" {
+		t.Errorf("loadSymbology() = %v, want {W} only, symbols without an image are left out", symbols)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	text_template "text/template"
+	"text/template/parse"
+)
+
+// defaultTemplates are the templates of the pages and feeds, each overridable by a file of the same
+// name in the -templates directory
+//
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+// Templates are the sources of the page and feed templates by file name
+type Templates struct {
+	sources map[string]templateSource
+}
+
+type templateSource struct {
+	File string // Where the template was read from, for errors
+	Text string
+}
+
+// loadTemplates reads the embedded templates, overlaid with those of dir when it's not empty. Files of
+// dir that replace no template are most likely misnamed and warned about. Their syntax is checked
+// right away, so a typo fails before the slow part of the render rather than after it.
+func loadTemplates(dir string) (Templates, error) {
+	templates := Templates{sources: make(map[string]templateSource)}
+	embedded, err := defaultTemplates.ReadDir("templates")
+	if err != nil {
+		return Templates{}, err
+	}
+	for _, entry := range embedded {
+		name := "templates/" + entry.Name()
+		text, err := defaultTemplates.ReadFile(name)
+		if err != nil {
+			return Templates{}, err
+		}
+		templates.sources[entry.Name()] = templateSource{File: name + " (built in)", Text: string(text)}
+	}
+	if dir == "" {
+		return templates, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return Templates{}, err
+	}
+	var overridden []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if _, found := templates.sources[entry.Name()]; !found {
+			slog.Warn("Ignoring file that overrides no template", "file", filepath.Join(dir, entry.Name()), "templates", templates.names())
+			continue
+		}
+		filename := filepath.Join(dir, entry.Name())
+		text, err := os.ReadFile(filename)
+		if err != nil {
+			return Templates{}, err
+		}
+		if err := checkTemplateSyntax(entry.Name(), string(text)); err != nil {
+			return Templates{}, fmt.Errorf("%s: %w", filename, err)
+		}
+		templates.sources[entry.Name()] = templateSource{File: filename, Text: string(text)}
+		overridden = append(overridden, entry.Name())
+	}
+	slog.Info("Templates overridden", "dir", dir, "templates", overridden)
+	return templates, nil
+}
+
+// checkTemplateSyntax parses a template without its functions, which are only known to the page it's
+// parsed for
+func checkTemplateSyntax(name string, text string) error {
+	tree := parse.New(name)
+	tree.Mode = parse.SkipFuncCheck
+	_, err := tree.Parse(text, "", "", make(map[string]*parse.Tree))
+	return err
+}
+
+// names lists the templates that can be overridden
+func (templates Templates) names() []string {
+	names := make([]string, 0, len(templates.sources))
+	for name := range templates.sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// source returns a template by file name; the names are those of the templates/ directory
+func (templates Templates) source(name string) (templateSource, error) {
+	source, found := templates.sources[name]
+	if !found {
+		return templateSource{}, fmt.Errorf("no template %s", name)
+	}
+	return source, nil
+}
+
+// parseHTML adds the named template file to t. Errors start with the file, and their line numbers
+// count from the top of it.
+func (templates Templates) parseHTML(t *template.Template, name string) error {
+	source, err := templates.source(name)
+	if err != nil {
+		return err
+	}
+	if _, err := t.Parse(source.Text); err != nil {
+		return fmt.Errorf("%s: %w", source.File, err)
+	}
+	return nil
+}
+
+// parseText is parseHTML for the templates of the RSS feed, which text/template executes
+func (templates Templates) parseText(t *text_template.Template, name string) error {
+	source, err := templates.source(name)
+	if err != nil {
+		return err
+	}
+	if _, err := t.Parse(source.Text); err != nil {
+		return fmt.Errorf("%s: %w", source.File, err)
+	}
+	return nil
+}
+
+// hash identifies the sources of the named templates, overrides included
+func (templates Templates) hash(names ...string) string {
+	var texts []string
+	for _, name := range names {
+		texts = append(texts, templates.sources[name].Text)
+	}
+	return hashStrings(texts...)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// defaultWebSubHub is the hub feed.xml names for readers to subscribe to, see -websub-hub
+const defaultWebSubHub = "https://pubsubhubbub.appspot.com/"
+
+const webSubTimeout = 30 * time.Second
+
+// pingWebSubHub tells the hub that the feed at topic changed, so it fetches the feed and pushes it to
+// the subscribers
+func pingWebSubHub(hub string, topic string) error {
+	form := url.Values{"hub.mode": {"publish"}, "hub.url": {topic}}
+	req, err := http.NewRequest("POST", hub, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", mirrorUserAgent)
+
+	client := &http.Client{Timeout: webSubTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("HTTP %s", resp.Status)
+	}
+	return nil
+}